@@ -19,6 +19,7 @@ import (
 	"warasin/internal/repository/postgres"
 	"warasin/internal/usecase"
 	"warasin/pkg/database"
+	"warasin/pkg/scheduler"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -75,13 +76,50 @@ func main() {
 	resourceRepo := postgres.NewResourceRepository(db)
 	paymentRepo := postgres.NewPaymentRepository(db)
 	activityRepo := postgres.NewActivityRepository(db)
+	journalAIRepo := postgres.NewJournalAIRepository(db)
+	journalPromptRepo := postgres.NewJournalPromptRepository(db)
+	wellbeingRepo := postgres.NewWellbeingRepository(db)
+	emotionRepo := postgres.NewEmotionRepository(db)
+	moodTagRepo := postgres.NewMoodTagRepository(db)
+	questionnaireRepo := postgres.NewQuestionnaireRepository(db)
+	notificationRepo := postgres.NewNotificationRepository(db)
+	facialReadingRepo := postgres.NewFacialReadingRepository(db)
+	chatSafetyRepo := postgres.NewChatSafetyRepository(db)
+	chatFeedbackRepo := postgres.NewChatFeedbackRepository(db)
+	chatPersonalizationRepo := postgres.NewChatPersonalizationRepository(db)
+	llmUsageRepo := postgres.NewLLMUsageRepository(db)
+	counselorRepo := postgres.NewCounselorRepository(db)
+
+	llmProvider, err := usecase.NewLLMProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up the language model: %v", err)
+	}
+	log.Printf("Using %s model %s", llmProvider.Name(), llmProvider.Model())
 
 	// Initialize use cases
+	llmUsageUsecase := usecase.NewLLMUsageUsecase(llmUsageRepo, userRepo, llmProvider.Model(), cfg)
 	userUsecase := usecase.NewUserUsecase(userRepo)
-	moodUsecase := usecase.NewMoodUsecase(moodRepo, journalRepo)
-	journalUsecase := usecase.NewJournalUsecase(journalRepo, moodUsecase, cfg)
-	chatUsecase := usecase.NewChatUsecase(chatRepo)
+	emotionUsecase := usecase.NewEmotionUsecase(emotionRepo)
+	moodUsecase := usecase.NewMoodUsecase(moodRepo, journalRepo, moodTagRepo, emotionUsecase)
+	facialUsecase := usecase.NewFacialUsecase(facialReadingRepo, moodRepo, emotionUsecase)
+	journalUsecase := usecase.NewJournalUsecase(journalRepo, journalPromptRepo, moodUsecase, emotionUsecase, cfg)
+	journalAIUsecase := usecase.NewJournalAIUsecase(journalRepo, moodRepo, journalAIRepo, llmProvider, llmUsageUsecase)
+	journalPromptUsecase := usecase.NewJournalPromptUsecase(journalPromptRepo, moodRepo)
+	wellbeingUsecase := usecase.NewWellbeingUsecase(wellbeingRepo, moodRepo, resourceRepo, notificationRepo)
+	questionnaireUsecase := usecase.NewQuestionnaireUsecase(questionnaireRepo)
+	notifiers := usecase.NewNotifiers(cfg, notificationRepo)
+	reminderUsecase := usecase.NewReminderUsecase(notificationRepo, moodRepo, userRepo, notifiers)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo, notifiers)
+	var safetyModel usecase.LLMProvider
+	if cfg.ChatSafetyModelEnabled {
+		safetyModel = llmProvider
+	}
+	chatSafetyUsecase := usecase.NewChatSafetyUsecase(chatSafetyRepo, wellbeingRepo, userRepo, safetyModel, llmUsageUsecase, notifiers)
+	chatPersonalizationUsecase := usecase.NewChatPersonalizationUsecase(chatPersonalizationRepo, moodRepo, journalAIRepo, notificationRepo, moodUsecase)
 	resourceUsecase := usecase.NewResourceUsecase(resourceRepo)
+	chatUsecase := usecase.NewChatUsecase(chatRepo, llmProvider, chatSafetyUsecase, llmUsageUsecase, chatPersonalizationUsecase, resourceUsecase, cfg)
+	chatFeedbackUsecase := usecase.NewChatFeedbackUsecase(chatFeedbackRepo, chatRepo)
+	counselorChatUsecase := usecase.NewCounselorChatUsecase(counselorRepo, chatRepo, userRepo)
 	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, userRepo)
 	activityUsecase := usecase.NewActivityUsecase(activityRepo)
 
@@ -91,8 +129,8 @@ func main() {
 	// Configure CORS based on environment
 	corsConfig := cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Accept", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Content-Disposition"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -162,8 +200,21 @@ func main() {
 		router,
 		userUsecase,
 		journalUsecase,
+		journalAIUsecase,
+		journalPromptUsecase,
 		moodUsecase,
+		facialUsecase,
+		emotionUsecase,
+		wellbeingUsecase,
+		questionnaireUsecase,
+		reminderUsecase,
+		notificationUsecase,
 		chatUsecase,
+		chatSafetyUsecase,
+		chatFeedbackUsecase,
+		chatPersonalizationUsecase,
+		llmUsageUsecase,
+		counselorChatUsecase,
 		resourceUsecase,
 		paymentUsecase,
 		activityUsecase,
@@ -185,12 +236,76 @@ func main() {
 
 	log.Println("Server started successfully! 🚀")
 
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Live counselor chat events from every instance
+	go func() {
+		err := database.Listen(jobsCtx, cfg.DatabaseURL, usecase.CounselorChatChannel, counselorChatUsecase.Dispatch, counselorChatUsecase.Resync)
+		if err != nil && jobsCtx.Err() == nil {
+			log.Printf("ERROR: Counselor chat listener stopped: %v", err)
+		}
+	}()
+
+	go scheduler.Every(jobsCtx, "journal-trash-purge", 24*time.Hour, func(ctx context.Context) error {
+		purged, err := journalUsecase.PurgeTrash(time.Duration(cfg.JournalTrashRetentionDays) * 24 * time.Hour)
+		if err != nil {
+			return err
+		}
+		log.Printf("Purged %d journal entries from trash", purged)
+		return nil
+	})
+
+	// The lock keeps replicas from paying for the same summaries
+	go scheduler.Every(jobsCtx, "journal-weekly-summaries", 6*time.Hour, scheduler.Exclusive(db, "journal-weekly-summaries", func(ctx context.Context) error {
+		created, err := journalAIUsecase.GenerateWeeklySummaries(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Printf("Created %d weekly journal summaries", created)
+		return nil
+	}))
+
+	// Imported journals wait on the journals table, the lock keeps replicas from analyzing
+	// the same journal twice
+	go scheduler.Every(jobsCtx, "journal-mood-analysis", 5*time.Minute, scheduler.Exclusive(db, "journal-mood-analysis", func(ctx context.Context) error {
+		analyzed, err := journalUsecase.AnalyzeQueuedMoods(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Analyzed the mood of %d imported journals", analyzed)
+		return nil
+	}))
+
+	// The cooldown check reads before it inserts, so only one replica may raise alerts
+	go scheduler.Every(jobsCtx, "wellbeing-alerts", 6*time.Hour, scheduler.Exclusive(db, "wellbeing-alerts", func(ctx context.Context) error {
+		raised, err := wellbeingUsecase.AnalyzeMoodTrends(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Printf("Raised %d wellbeing alerts", raised)
+		return nil
+	}))
+
+	// Runs often so reminders go out close to each user's local time; the lock keeps
+	// replicas from sending the same batch
+	go scheduler.Every(jobsCtx, "mood-reminders", 5*time.Minute, scheduler.Exclusive(db, "mood-reminders", func(ctx context.Context) error {
+		sent, err := reminderUsecase.SendDueReminders(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Printf("Sent %d mood reminders", sent)
+		return nil
+	}))
+
 	// Wait for interrupt signal to gracefully shut down the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopJobs()
 
 	// Create a deadline to wait for current operations to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
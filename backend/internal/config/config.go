@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -14,6 +15,55 @@ type Config struct {
 	JWTSecret       string
 	JWTExpiresIn    time.Duration
 	MoodModelAPIURL string
+
+	// Google Gemini, shared by the chat and the journal AI features
+	GeminiAPIKey string
+	GeminiModel  string
+
+	// Language model behind the chat and the journal AI features. LLMProvider is gemini,
+	// openai (any OpenAI compatible API) or ollama. An empty model, base URL or key falls
+	// back to the provider's default, for gemini the GEMINI_* settings above.
+	LLMProvider        string
+	LLMModel           string
+	LLMBaseURL         string
+	LLMAPIKey          string
+	LLMTemperature     float64
+	LLMMaxOutputTokens int
+	// Context window of the model, prompts are trimmed to fit it together with the output
+	LLMContextTokens int
+	// Tokens of chat history (summary and recent messages) sent with each message. Older
+	// messages are folded into the session summary once the history grows past it.
+	ChatHistoryTokenBudget int
+	// Resources from the library retrieved for each chat message and cited in the answer, 0 turns retrieval off
+	ChatRetrievalResources int
+
+	// Also ask the language model to classify chat messages for crisis risk, on top of the keyword rules
+	ChatSafetyModelEnabled bool
+
+	// Daily language model quotas per user type, 0 means unlimited. Days run on WIB.
+	LLMDailyRequestsStandard int
+	LLMDailyTokensStandard   int
+	LLMDailyRequestsPremium  int
+	LLMDailyTokensPremium    int
+	// USD per million tokens of the configured model for the usage report. Known hosted
+	// models have built-in prices, set these for others or to override them.
+	LLMPricePromptPerMillion     float64
+	LLMPriceCompletionPerMillion float64
+
+	// Journals in the trash are purged after this many days
+	JournalTrashRetentionDays int
+
+	// SMTP server for email notifications, disabled when SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// VAPID key pair for Web Push, disabled when the private key is empty.
+	// The private key is the base64url encoded P-256 scalar.
+	VAPIDPrivateKey string
+	VAPIDSubject    string
 }
 
 // New creates a new Config struct from environment variables
@@ -28,6 +78,41 @@ func New() *Config {
 		JWTSecret:       getEnv("JWT_SECRET", "your-secret-key"),
 		JWTExpiresIn:    time.Duration(jwtExpiresInHours) * time.Hour,
 		MoodModelAPIURL: getEnv("MOOD_MODEL_API_URL", "https://warasinjournal.azurewebsites.net/predict"),
+
+		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
+		GeminiModel:  getEnv("GEMINI_MODEL", "gemini-1.5-flash"),
+
+		LLMProvider:        getEnv("LLM_PROVIDER", "gemini"),
+		LLMModel:           getEnv("LLM_MODEL", ""),
+		LLMBaseURL:         getEnv("LLM_BASE_URL", ""),
+		LLMAPIKey:          getEnv("LLM_API_KEY", ""),
+		LLMTemperature:     getEnvFloat("LLM_TEMPERATURE", 0.7),
+		LLMMaxOutputTokens: getEnvInt("LLM_MAX_OUTPUT_TOKENS", 500),
+		LLMContextTokens:   getEnvInt("LLM_CONTEXT_TOKENS", 8192),
+
+		ChatHistoryTokenBudget: getEnvInt("CHAT_HISTORY_TOKEN_BUDGET", 2000),
+		ChatRetrievalResources: getEnvInt("CHAT_RETRIEVAL_RESOURCES", 3),
+
+		ChatSafetyModelEnabled: getEnvBool("CHAT_SAFETY_MODEL_ENABLED", false),
+
+		LLMDailyRequestsStandard: getEnvInt("LLM_DAILY_REQUESTS_STANDARD", 50),
+		LLMDailyTokensStandard:   getEnvInt("LLM_DAILY_TOKENS_STANDARD", 100000),
+		LLMDailyRequestsPremium:  getEnvInt("LLM_DAILY_REQUESTS_PREMIUM", 500),
+		LLMDailyTokensPremium:    getEnvInt("LLM_DAILY_TOKENS_PREMIUM", 1000000),
+
+		LLMPricePromptPerMillion:     getEnvFloat("LLM_PRICE_PROMPT_PER_MILLION", 0),
+		LLMPriceCompletionPerMillion: getEnvFloat("LLM_PRICE_COMPLETION_PER_MILLION", 0),
+
+		JournalTrashRetentionDays: getEnvInt("JOURNAL_TRASH_RETENTION_DAYS", 30),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "WarasIn <no-reply@warasin.id>"),
+
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", "mailto:support@warasin.id"),
 	}
 }
 
@@ -39,3 +124,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt gets an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat gets a float environment variable or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type chatFeedbackHandler struct {
+	chatFeedbackUsecase usecase.ChatFeedbackUsecase
+}
+
+// NewChatFeedbackHandler creates a new chat feedback handler
+func NewChatFeedbackHandler(chatFeedbackUsecase usecase.ChatFeedbackUsecase) *chatFeedbackHandler {
+	return &chatFeedbackHandler{
+		chatFeedbackUsecase: chatFeedbackUsecase,
+	}
+}
+
+// chatFeedbackErrorStatus maps unknown messages, sessions and ratings to 404 and everything else to fallback
+func chatFeedbackErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, usecase.ErrChatMessageNotFound), errors.Is(err, usecase.ErrChatSessionNotFound),
+		errors.Is(err, usecase.ErrChatFeedbackNotFound):
+		return http.StatusNotFound
+	}
+	return fallback
+}
+
+// SubmitFeedback rates a bot message with thumbs up or down and optional reasons
+func (h *chatFeedbackHandler) SubmitFeedback(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	messageID, err := strconv.Atoi(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid message ID",
+		})
+		return
+	}
+
+	var request usecase.ChatFeedbackInput
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	feedback, err := h.chatFeedbackUsecase.SubmitFeedback(userID.(int), messageID, request)
+	if err != nil {
+		c.JSON(chatFeedbackErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+func (h *chatFeedbackHandler) DeleteFeedback(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	messageID, err := strconv.Atoi(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid message ID",
+		})
+		return
+	}
+
+	if err := h.chatFeedbackUsecase.DeleteFeedback(userID.(int), messageID); err != nil {
+		c.JSON(chatFeedbackErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Feedback deleted successfully",
+	})
+}
+
+// GetSessionFeedback lists the user's ratings in a session
+func (h *chatFeedbackHandler) GetSessionFeedback(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+
+	feedback, err := h.chatFeedbackUsecase.GetSessionFeedback(userID.(int), sessionID)
+	if err != nil {
+		c.JSON(chatFeedbackErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    len(feedback),
+		"feedback": feedback,
+	})
+}
+
+// GetReport counts ratings per prompt version and model for admins
+func (h *chatFeedbackHandler) GetReport(c *gin.Context) {
+	report, err := h.chatFeedbackUsecase.GetReport(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportNegative downloads the shared thumbs down exchanges as JSON Lines, one exchange per
+// line, for reviewing the prompt
+func (h *chatFeedbackHandler) ExportNegative(c *gin.Context) {
+	exchanges, err := h.chatFeedbackUsecase.ExportNegative(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fileName := "chat-feedback-" + time.Now().Format("20060102") + ".jsonl"
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for _, exchange := range exchanges {
+		if err := encoder.Encode(exchange); err != nil {
+			return
+		}
+	}
+}
@@ -1,15 +1,11 @@
 package handler
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"warasin/internal/domain"
@@ -22,51 +18,6 @@ type chatHandler struct {
 	chatUsecase usecase.ChatUsecase
 }
 
-// Google Gemini API structures
-type GeminiContent struct {
-	Parts []GeminiPart `json:"parts"`
-	Role  string       `json:"role,omitempty"`
-}
-
-type GeminiPart struct {
-	Text string `json:"text"`
-}
-
-type GeminiRequest struct {
-	Contents         []GeminiContent `json:"contents"`
-	GenerationConfig struct {
-		Temperature     float64 `json:"temperature"`
-		MaxOutputTokens int     `json:"maxOutputTokens"`
-		TopP            float64 `json:"topP"`
-		TopK            int     `json:"topK"`
-	} `json:"generationConfig"`
-	SafetySettings []struct {
-		Category  string `json:"category"`
-		Threshold string `json:"threshold"`
-	} `json:"safetySettings"`
-}
-
-type GeminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-			Role string `json:"role"`
-		} `json:"content"`
-		FinishReason  string `json:"finishReason"`
-		SafetyRatings []struct {
-			Category    string `json:"category"`
-			Probability string `json:"probability"`
-		} `json:"safetyRatings"`
-	} `json:"candidates"`
-	UsageMetadata struct {
-		PromptTokenCount     int `json:"promptTokenCount"`
-		CandidatesTokenCount int `json:"candidatesTokenCount"`
-		TotalTokenCount      int `json:"totalTokenCount"`
-	} `json:"usageMetadata"`
-}
-
 type ChatbotRequest struct {
 	SessionID int    `json:"session_id"`
 	Message   string `json:"message" binding:"required"`
@@ -78,6 +29,10 @@ type ChatbotResponse struct {
 	MessageID  int    `json:"message_id"`
 	TokensUsed int    `json:"tokens_used,omitempty"`
 	Model      string `json:"model"`
+
+	Safety *domain.ChatSafety `json:"safety,omitempty"`
+	// Resources from the library the answer was grounded in
+	Citations []domain.ResourceCitation `json:"citations"`
 }
 
 // NewChatHandler creates a new chat handler
@@ -87,44 +42,36 @@ func NewChatHandler(chatUsecase usecase.ChatUsecase) *chatHandler {
 	}
 }
 
-// Mental health system prompt yang dioptimasi untuk Gemini
-const MENTAL_HEALTH_SYSTEM_PROMPT = `You are MindCareBot 🌿, a compassionate and professional mental health companion created to provide emotional support and guidance.
-
-## Your Core Purpose:
-- Provide empathetic emotional support and validation
-- Offer evidence-based coping strategies and wellness techniques
-- Encourage healthy mental health practices
-- Be a caring, non-judgmental listening companion
-
-## Your Communication Style:
-- Use warm, supportive, and encouraging tone
-- Be concise but meaningful in your responses
-- Include gentle emojis occasionally (🌿, 💙, 🤗, ✨)
-- Validate feelings before offering suggestions
-- Ask thoughtful follow-up questions when appropriate
-
-## Mental Health Strategies You Can Suggest:
-- Breathing exercises (4-7-8 technique, box breathing)
-- Mindfulness and grounding techniques (5-4-3-2-1 method)
-- Journaling prompts for self-reflection
-- Progressive muscle relaxation
-- Positive affirmations and self-compassion practices
-- Healthy routine and sleep hygiene tips
-- Light physical activity suggestions
-
-## Important Guidelines:
-- Always validate the person's feelings first
-- Never diagnose or provide medical advice
-- Encourage professional help for serious concerns
-- If someone expresses suicidal thoughts or self-harm, gently but firmly encourage immediate professional help
-- Stay within your role as a supportive companion, not a therapist
-
-## Response Format:
-Keep responses focused, practical, and hopeful. End with gentle encouragement or a supportive question when appropriate.
-
-Remember: You are here to support and guide, not to replace professional mental health care.`
-
-// Google Gemini Chat Handler (FREE - 15 requests/minute, 1500 requests/day)
+func newChatbotResponse(reply *domain.ChatReply) ChatbotResponse {
+	return ChatbotResponse{
+		Response:   reply.Response,
+		SessionID:  reply.SessionID,
+		MessageID:  reply.MessageID,
+		TokensUsed: reply.TokensUsed,
+		Model:      reply.Model,
+		Safety:     reply.Safety,
+		Citations:  reply.Citations,
+	}
+}
+
+// chatReplyErrorStatus maps an unknown or live session and an oversized message to 400
+func chatReplyErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, usecase.ErrChatSessionNotFound):
+		return http.StatusBadRequest, "Invalid session or session not found"
+	case errors.Is(err, usecase.ErrChatMessageTooLong), errors.Is(err, usecase.ErrChatSessionIsLive):
+		return http.StatusBadRequest, err.Error()
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// userTypeString reads the user type the auth middleware stored, empty when there is none
+func userTypeString(value interface{}) string {
+	userType, _ := value.(string)
+	return userType
+}
+
+// Chatbot handler, the path still says gemini but the model comes from LLM_PROVIDER
 func (h *chatHandler) GeminiChat(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -144,200 +91,102 @@ func (h *chatHandler) GeminiChat(c *gin.Context) {
 		return
 	}
 
-	// Validate session belongs to user (jika session_id disediakan)
-	if request.SessionID > 0 {
-		_, _, err := h.chatUsecase.GetMessages(request.SessionID, userID.(int), 1, 0)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"message": "Invalid session or session not found",
-			})
-			return
-		}
-	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
 
-	// Build conversation
-	contents, err := h.buildGeminiContents(request.SessionID, userID.(int), request.Message)
+	userType, _ := c.Get("userType")
+	reply, err := h.chatUsecase.Reply(ctx, userID.(int), request.SessionID, userTypeString(userType), request.Message)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		if abortOnQuotaExceeded(c, err) {
+			return
+		}
+		status, message := chatReplyErrorStatus(err)
+		c.JSON(status, gin.H{
 			"error":   true,
-			"message": "Failed to build conversation context: " + err.Error(),
+			"message": message,
 		})
 		return
 	}
 
-	// Call Gemini API
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
-	defer cancel()
+	c.JSON(http.StatusOK, newChatbotResponse(reply))
+}
 
-	geminiResp, err := h.CallGeminiAPI(ctx, contents)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+// GeminiChatStream is GeminiChat over Server-Sent Events. Text is relayed as "token" events
+// while the model generates it, then the bot message is saved and a "done" event carries the
+// same body GeminiChat returns. Failures after the stream started arrive as an "error" event.
+// When the client disconnects the upstream call is cancelled and nothing is saved.
+func (h *chatHandler) GeminiChatStream(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"error":   true,
-			"message": fmt.Sprintf("AI service error: %v", err),
+			"message": "User not authenticated",
 		})
 		return
 	}
 
-	var aiResponse string
-	if len(geminiResp.Candidates) > 0 && len(geminiResp.Candidates[0].Content.Parts) > 0 {
-		aiResponse = strings.TrimSpace(geminiResp.Candidates[0].Content.Parts[0].Text)
-	}
-
-	if aiResponse == "" {
-		aiResponse = "I'm here to support you. How can I help you today? 🌿"
+	var request ChatbotRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid request format: " + err.Error(),
+		})
+		return
 	}
 
-	// Save bot response to database (jika session valid)
-	var botMessage *domain.ChatMessage
-	if request.SessionID > 0 {
-		botMessage, err = h.chatUsecase.SendMessage(request.SessionID, userID.(int), aiResponse, "bot")
-		if err != nil {
-			fmt.Printf("Failed to save bot message: %v\n", err)
+	// Headers are sent with the first token, so errors before it still get a normal status
+	started := false
+	startStream := func() {
+		if started {
+			return
 		}
+		started = true
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		// Stop nginx from buffering the whole stream
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
 	}
 
-	response := ChatbotResponse{
-		Response:   aiResponse,
-		SessionID:  request.SessionID,
-		Model:      "gemini-1.5-flash",
-		TokensUsed: geminiResp.UsageMetadata.TotalTokenCount,
-	}
-
-	if botMessage != nil {
-		response.MessageID = botMessage.ID
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-func (h *chatHandler) CallGeminiAPI(ctx context.Context, contents []GeminiContent) (*GeminiResponse, error) {
-	// Get free API key from https://aistudio.google.com/app/apikey
-	geminiKey := os.Getenv("GEMINI_API_KEY")
-	if geminiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
-	}
-
-	// Gunakan model gratis terbaik
-	apiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent?key=%s", geminiKey)
-
-	requestBody := GeminiRequest{
-		Contents: contents,
-		GenerationConfig: struct {
-			Temperature     float64 `json:"temperature"`
-			MaxOutputTokens int     `json:"maxOutputTokens"`
-			TopP            float64 `json:"topP"`
-			TopK            int     `json:"topK"`
-		}{
-			Temperature:     0.7,
-			MaxOutputTokens: 500,
-			TopP:            0.8,
-			TopK:            40,
-		},
-		SafetySettings: []struct {
-			Category  string `json:"category"`
-			Threshold string `json:"threshold"`
-		}{
-			{
-				Category:  "HARM_CATEGORY_HARASSMENT",
-				Threshold: "BLOCK_MEDIUM_AND_ABOVE",
-			},
-			{
-				Category:  "HARM_CATEGORY_HATE_SPEECH",
-				Threshold: "BLOCK_MEDIUM_AND_ABOVE",
-			},
-			{
-				Category:  "HARM_CATEGORY_SEXUALLY_EXPLICIT",
-				Threshold: "BLOCK_MEDIUM_AND_ABOVE",
-			},
-			{
-				Category:  "HARM_CATEGORY_DANGEROUS_CONTENT",
-				Threshold: "BLOCK_MEDIUM_AND_ABOVE",
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
+	// The request context is cancelled when the client goes away, which also aborts the upstream call
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
 
-	body, err := io.ReadAll(resp.Body)
+	userType, _ := c.Get("userType")
+	reply, err := h.chatUsecase.StreamReply(ctx, userID.(int), request.SessionID, userTypeString(userType), request.Message, func(text string) error {
+		startStream()
+		c.SSEvent("token", gin.H{"text": text})
+		c.Writer.Flush()
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	return &geminiResp, nil
-}
-
-func (h *chatHandler) buildGeminiContents(sessionID int, userID int, currentMessage string) ([]GeminiContent, error) {
-	contents := []GeminiContent{
-		{
-			Parts: []GeminiPart{{
-				Text: MENTAL_HEALTH_SYSTEM_PROMPT,
-			}},
-			Role: "user",
-		},
-		{
-			Parts: []GeminiPart{{
-				Text: "I understand my role as MindCareBot 🌿. I'm here to provide compassionate mental health support and guidance. I'm ready to listen and help with empathy, practical coping strategies, and encouragement. How can I support you today?",
-			}},
-			Role: "model",
-		},
-	}
-
-	// Add conversation history jika session ada
-	if sessionID > 0 {
-		messages, _, err := h.chatUsecase.GetMessages(sessionID, userID, 8, 0)
-		if err != nil {
-			return nil, err
+		if c.Request.Context().Err() != nil {
+			log.Printf("Chat stream for user %d cancelled by client: %v", userID.(int), err)
+			return
 		}
-
-		// Add conversation history (reverse order)
-		for i := len(messages) - 1; i >= 0; i-- {
-			msg := messages[i]
-			role := "user"
-			if msg.SenderType == "bot" {
-				role = "model"
-			}
-
-			contents = append(contents, GeminiContent{
-				Parts: []GeminiPart{{Text: msg.MessageContent}},
-				Role:  role,
+		if !started && abortOnQuotaExceeded(c, err) {
+			return
+		}
+		status, message := chatReplyErrorStatus(err)
+		if !started {
+			c.JSON(status, gin.H{
+				"error":   true,
+				"message": message,
 			})
+			return
 		}
+		c.SSEvent("error", gin.H{
+			"error":   true,
+			"message": message,
+		})
+		c.Writer.Flush()
+		return
 	}
 
-	// Add current message
-	contents = append(contents, GeminiContent{
-		Parts: []GeminiPart{{Text: currentMessage}},
-		Role:  "user",
-	})
-
-	return contents, nil
+	startStream()
+	c.SSEvent("done", newChatbotResponse(reply))
+	c.Writer.Flush()
 }
 
 // Existing handlers tetap sama
@@ -392,8 +241,10 @@ func (h *chatHandler) GetSessions(c *gin.Context) {
 	// Parse query parameters with defaults
 	limitStr := c.DefaultQuery("limit", "10")
 	offsetStr := c.DefaultQuery("offset", "0")
-	// startDate := c.Query("start_date")
-	// endDate := c.Query("end_date")
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	tag := c.Query("tag")
+	archived := c.Query("archived") == "true"
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 {
@@ -405,11 +256,9 @@ func (h *chatHandler) GetSessions(c *gin.Context) {
 		offset = 0
 	}
 
-	// For now, ignore date filters to avoid SQL parameter issues
-	// Pass empty strings which will be handled properly in usecase
-	sessions, total, err := h.chatUsecase.GetSessions(userID.(int), limit, offset, "", "")
+	sessions, total, err := h.chatUsecase.GetSessions(userID.(int), limit, offset, startDate, endDate, tag, archived)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   true,
 			"message": "Failed to get sessions: " + err.Error(),
 		})
@@ -471,6 +320,7 @@ func (h *chatHandler) GetMessages(c *gin.Context) {
 
 	limitStr := c.DefaultQuery("limit", "50")
 	beforeIDStr := c.DefaultQuery("before_id", "0")
+	aroundIDStr := c.DefaultQuery("around_id", "0")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
@@ -482,8 +332,27 @@ func (h *chatHandler) GetMessages(c *gin.Context) {
 		beforeID = 0
 	}
 
-	messages, total, err := h.chatUsecase.GetMessages(sessionID, userID.(int), limit, beforeID)
+	aroundID, err := strconv.Atoi(aroundIDStr)
 	if err != nil {
+		aroundID = 0
+	}
+
+	// around_id jumps to a message, e.g. a search result, with the messages next to it
+	var messages []*domain.ChatMessage
+	var total int
+	if aroundID > 0 {
+		messages, total, err = h.chatUsecase.GetMessagesAround(sessionID, userID.(int), aroundID, limit)
+	} else {
+		messages, total, err = h.chatUsecase.GetMessages(sessionID, userID.(int), limit, beforeID)
+	}
+	if err != nil {
+		if errors.Is(err, usecase.ErrChatMessageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   true,
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   true,
 			"message": err.Error(),
@@ -510,7 +379,7 @@ func (h *chatHandler) DeleteSession(c *gin.Context) {
 
 	err = h.chatUsecase.DeleteSession(sessionID, userID.(int))
 	if err != nil {
-		if err.Error() == "session not found" {
+		if errors.Is(err, usecase.ErrChatSessionNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   true,
 				"message": "Session not found",
@@ -528,3 +397,179 @@ func (h *chatHandler) DeleteSession(c *gin.Context) {
 		"message": "Session deleted successfully",
 	})
 }
+
+// sessionIDParam reads the session ID from the path, answering 400 when it isn't a number
+func sessionIDParam(c *gin.Context) (int, bool) {
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid session ID",
+		})
+		return 0, false
+	}
+	return sessionID, true
+}
+
+// respondSessionUpdate answers with the updated session, 404 when it isn't the user's
+func respondSessionUpdate(c *gin.Context, session *domain.ChatSession, err error) {
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrChatSessionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// RenameSession sets the session's title, generated titles never replace it
+func (h *chatHandler) RenameSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Title string `json:"title" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	session, err := h.chatUsecase.RenameSession(sessionID, userID.(int), request.Title)
+	respondSessionUpdate(c, session, err)
+}
+
+func (h *chatHandler) PinSession(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+func (h *chatHandler) UnpinSession(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+func (h *chatHandler) setPinned(c *gin.Context, pinned bool) {
+	userID, _ := c.Get("userID")
+	sessionID, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.chatUsecase.PinSession(sessionID, userID.(int), pinned)
+	respondSessionUpdate(c, session, err)
+}
+
+func (h *chatHandler) ArchiveSession(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+func (h *chatHandler) UnarchiveSession(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *chatHandler) setArchived(c *gin.Context, archived bool) {
+	userID, _ := c.Get("userID")
+	sessionID, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.chatUsecase.ArchiveSession(sessionID, userID.(int), archived)
+	respondSessionUpdate(c, session, err)
+}
+
+func (h *chatHandler) AddSessionTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Tag string `json:"tag" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	session, err := h.chatUsecase.AddSessionTag(sessionID, userID.(int), request.Tag)
+	respondSessionUpdate(c, session, err)
+}
+
+func (h *chatHandler) RemoveSessionTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.chatUsecase.RemoveSessionTag(sessionID, userID.(int), c.Param("tag"))
+	respondSessionUpdate(c, session, err)
+}
+
+// GetTags lists the tags on the user's sessions, the most used first
+func (h *chatHandler) GetTags(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	tags, err := h.chatUsecase.GetTags(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": len(tags),
+		"tags":  tags,
+	})
+}
+
+// SearchMessages searches all of the user's chats, ?q= takes words, "phrases", or and
+// -excluded words. Results link to their message with session_id and message_id, which
+// GetMessages opens with around_id.
+func (h *chatHandler) SearchMessages(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	results, total, err := h.chatUsecase.SearchMessages(userID.(int), c.Query("q"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   total,
+		"results": results,
+	})
+}
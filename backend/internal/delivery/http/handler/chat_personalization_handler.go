@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type chatPersonalizationHandler struct {
+	chatPersonalizationUsecase usecase.ChatPersonalizationUsecase
+}
+
+// NewChatPersonalizationHandler creates a new chat personalization handler
+func NewChatPersonalizationHandler(chatPersonalizationUsecase usecase.ChatPersonalizationUsecase) *chatPersonalizationHandler {
+	return &chatPersonalizationHandler{
+		chatPersonalizationUsecase: chatPersonalizationUsecase,
+	}
+}
+
+func (h *chatPersonalizationHandler) GetSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	settings, err := h.chatPersonalizationUsecase.GetSettings(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings gives or revokes consent to add the user's mood context, and optionally
+// their journal summaries, to chat prompts
+func (h *chatPersonalizationHandler) UpdateSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		Enabled         *bool `json:"enabled" binding:"required"`
+		IncludeJournals bool  `json:"include_journals"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.chatPersonalizationUsecase.UpdateSettings(userID.(int), *request.Enabled, request.IncludeJournals)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// Revoke turns personalization off, ?delete_log=true also deletes the record of what was sent
+func (h *chatPersonalizationHandler) Revoke(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	settings, err := h.chatPersonalizationUsecase.Revoke(userID.(int), c.Query("delete_log") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// Preview shows the context that would be sent now, ?include_journals=true with the journal summary
+func (h *chatPersonalizationHandler) Preview(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	preview, err := h.chatPersonalizationUsecase.Preview(userID.(int), c.Query("include_journals") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// GetLog lists the context blocks that were sent to the model, newest first
+func (h *chatPersonalizationHandler) GetLog(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.chatPersonalizationUsecase.GetLog(userID.(int), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   total,
+		"entries": entries,
+	})
+}
+
+func (h *chatPersonalizationHandler) DeleteLog(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	deleted, err := h.chatPersonalizationUsecase.DeleteLog(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Context log deleted successfully",
+		"deleted": deleted,
+	})
+}
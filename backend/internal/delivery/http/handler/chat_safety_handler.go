@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type chatSafetyHandler struct {
+	chatSafetyUsecase usecase.ChatSafetyUsecase
+}
+
+// NewChatSafetyHandler creates a new chat safety handler
+func NewChatSafetyHandler(chatSafetyUsecase usecase.ChatSafetyUsecase) *chatSafetyHandler {
+	return &chatSafetyHandler{
+		chatSafetyUsecase: chatSafetyUsecase,
+	}
+}
+
+func (h *chatSafetyHandler) GetSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	settings, err := h.chatSafetyUsecase.GetSettings(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings picks the emergency contact and gives or withdraws consent to notify them
+func (h *chatSafetyHandler) UpdateSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		EmergencyContactID *int  `json:"emergency_contact_id"`
+		NotifyContact      *bool `json:"notify_contact" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.chatSafetyUsecase.UpdateSettings(userID.(int), request.EmergencyContactID, *request.NotifyContact)
+	if err != nil {
+		c.JSON(wellbeingErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetEvents lists safety events of all users for admins to review
+func (h *chatSafetyHandler) GetEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	events, total, err := h.chatSafetyUsecase.GetEvents(c.Query("risk_level"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  total,
+		"events": events,
+	})
+}
@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/usecase"
+	"warasin/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// Time a new connection has to send its auth frame
+	liveChatAuthTimeout = 10 * time.Second
+	// Clients send a ping at least this often, silent connections are closed
+	liveChatIdleTimeout   = 75 * time.Second
+	liveChatWriteTimeout  = 10 * time.Second
+	liveChatMaxFrameBytes = 64 * 1024
+)
+
+type counselorChatHandler struct {
+	counselorChatUsecase usecase.CounselorChatUsecase
+	jwtService           auth.JWTService
+}
+
+// NewCounselorChatHandler creates a new counselor chat handler
+func NewCounselorChatHandler(counselorChatUsecase usecase.CounselorChatUsecase, jwtService auth.JWTService) *counselorChatHandler {
+	return &counselorChatHandler{
+		counselorChatUsecase: counselorChatUsecase,
+		jwtService:           jwtService,
+	}
+}
+
+// counselorChatErrorStatus maps not-found errors to 404, invalid requests to 400 and
+// everything else to fallback
+func counselorChatErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, usecase.ErrChatSessionNotFound), errors.Is(err, usecase.ErrCounselorNotAssigned):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrNotACounselor):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// GetAssignment returns the counselor assigned to the user
+func (h *counselorChatHandler) GetAssignment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	assignment, err := h.counselorChatUsecase.GetAssignment(userID.(int))
+	if err != nil {
+		c.JSON(counselorChatErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// StartSession opens a live session with the user's counselor
+func (h *counselorChatHandler) StartSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	session, err := h.counselorChatUsecase.StartSession(userID.(int))
+	if err != nil {
+		c.JSON(counselorChatErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetSessions lists the sessions of the counselor, ?status=active for the open ones
+func (h *counselorChatHandler) GetSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	sessions, total, err := h.counselorChatUsecase.GetCounselorSessions(userID.(int), c.Query("status") == "active", limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    total,
+		"sessions": sessions,
+	})
+}
+
+// GetMessages pages through a live session's history for either participant
+func (h *counselorChatHandler) GetMessages(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid session ID",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	beforeID, err := strconv.Atoi(c.DefaultQuery("before_id", "0"))
+	if err != nil {
+		beforeID = 0
+	}
+
+	messages, total, err := h.counselorChatUsecase.GetMessages(sessionID, userID.(int), limit, beforeID)
+	if err != nil {
+		c.JSON(counselorChatErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    total,
+		"messages": messages,
+	})
+}
+
+// AssignCounselor lets an admin give a user a counselor
+func (h *counselorChatHandler) AssignCounselor(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	var request struct {
+		CounselorID int `json:"counselor_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	assignment, err := h.counselorChatUsecase.AssignCounselor(userID, request.CounselorID)
+	if err != nil {
+		c.JSON(counselorChatErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *counselorChatHandler) UnassignCounselor(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.counselorChatUsecase.UnassignCounselor(userID); err != nil {
+		c.JSON(counselorChatErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Counselor unassigned successfully",
+	})
+}
+
+// LiveChat upgrades to a WebSocket for a live session. Browsers can't set headers on
+// WebSocket requests, so the first frame authenticates: {"type":"auth","token":"...",
+// "after_id":N}. The server answers with "ready" and a "backfill" of the messages after
+// after_id, then relays the session's events. See domain.LiveChatEvent for the frames.
+func (h *counselorChatHandler) LiveChat(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid session ID",
+		})
+		return
+	}
+
+	server := websocket.Server{
+		// No cookies are involved, the token in the auth frame is what counts, so any
+		// origin and clients without one are accepted
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			h.serveLiveChat(conn, sessionID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *counselorChatHandler) serveLiveChat(conn *websocket.Conn, sessionID int) {
+	defer conn.Close()
+	conn.MaxPayloadBytes = liveChatMaxFrameBytes
+
+	// The reader answers on the same connection the writer relays events on
+	var writeMu sync.Mutex
+	send := func(event *domain.LiveChatEvent) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(liveChatWriteTimeout))
+		return websocket.JSON.Send(conn, event)
+	}
+	sendError := func(message string) {
+		_ = send(&domain.LiveChatEvent{Type: "error", SessionID: sessionID, Error: message, At: time.Now()})
+	}
+
+	conn.SetReadDeadline(time.Now().Add(liveChatAuthTimeout))
+	var authEvent domain.LiveChatEvent
+	if err := websocket.JSON.Receive(conn, &authEvent); err != nil || authEvent.Type != "auth" {
+		sendError(`The first frame must be {"type":"auth","token":"..."}`)
+		return
+	}
+	claims, err := h.jwtService.ValidateToken(authEvent.Token)
+	if err != nil {
+		sendError("Invalid or expired token")
+		return
+	}
+
+	sub, err := h.counselorChatUsecase.Join(sessionID, claims.UserID)
+	if err != nil {
+		sendError(err.Error())
+		return
+	}
+	defer h.counselorChatUsecase.Leave(sub)
+
+	err = send(&domain.LiveChatEvent{
+		Type:      "ready",
+		SessionID: sessionID,
+		UserID:    claims.UserID,
+		Role:      sub.Role,
+		Session:   sub.Session,
+		At:        time.Now(),
+	})
+	if err != nil {
+		return
+	}
+	if !h.sendBackfill(sub, authEvent.AfterID, send, sendError) {
+		return
+	}
+
+	// Events arrive until Leave, or until the hub drops a connection that fell behind.
+	// Closing the connection then ends the read loop below.
+	go func() {
+		defer conn.Close()
+		for event := range sub.Events {
+			if err := send(event); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(liveChatIdleTimeout))
+
+		var event domain.LiveChatEvent
+		if err := websocket.JSON.Receive(conn, &event); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				sendError("Invalid frame: " + err.Error())
+				continue
+			}
+			return
+		}
+
+		switch event.Type {
+		case "message":
+			if _, err := h.counselorChatUsecase.Send(sub, event.Content, event.ClientID); err != nil {
+				sendError(err.Error())
+			}
+		case "typing":
+			h.counselorChatUsecase.SetTyping(sub, event.Typing != nil && *event.Typing)
+		case "delivered":
+			if err := h.counselorChatUsecase.MarkDelivered(sub, event.MessageID); err != nil {
+				log.Printf("ERROR: Failed to mark live chat messages delivered: %v", err)
+			}
+		case "read":
+			if err := h.counselorChatUsecase.MarkRead(sub, event.MessageID); err != nil {
+				log.Printf("ERROR: Failed to mark live chat messages read: %v", err)
+			}
+		case "sync":
+			if !h.sendBackfill(sub, event.AfterID, send, sendError) {
+				return
+			}
+		case "ping":
+			if err := send(&domain.LiveChatEvent{Type: "pong", SessionID: sessionID, At: time.Now()}); err != nil {
+				return
+			}
+		default:
+			sendError("Unknown frame type " + strconv.Quote(event.Type))
+		}
+	}
+}
+
+// sendBackfill sends the messages after afterID and reports whether the connection is still usable
+func (h *counselorChatHandler) sendBackfill(sub *usecase.LiveChatSubscription, afterID int, send func(*domain.LiveChatEvent) error, sendError func(string)) bool {
+	backfill, err := h.counselorChatUsecase.Backfill(sub, afterID)
+	if err != nil {
+		log.Printf("ERROR: Live chat backfill for session %d failed: %v", sub.Session.ID, err)
+		sendError("Failed to load messages")
+		return true
+	}
+	return send(backfill) == nil
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"warasin/internal/domain"
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type emotionHandler struct {
+	emotionUsecase usecase.EmotionUsecase
+}
+
+// NewEmotionHandler creates a new emotion handler
+func NewEmotionHandler(emotionUsecase usecase.EmotionUsecase) *emotionHandler {
+	return &emotionHandler{
+		emotionUsecase: emotionUsecase,
+	}
+}
+
+// emotionRequest is the body for creating and updating emotions. The label is only read on create.
+type emotionRequest struct {
+	Label       string   `json:"label"`
+	Valence     *float64 `json:"valence" binding:"required"`
+	Arousal     *float64 `json:"arousal" binding:"required"`
+	NameID      string   `json:"name_id" binding:"required"`
+	NameEN      string   `json:"name_en" binding:"required"`
+	Emoji       string   `json:"emoji"`
+	ModelLabels []string `json:"model_labels"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+func (r *emotionRequest) toEmotion() *domain.Emotion {
+	emotion := &domain.Emotion{
+		Label:       r.Label,
+		Valence:     *r.Valence,
+		Arousal:     *r.Arousal,
+		NameID:      r.NameID,
+		NameEN:      r.NameEN,
+		Emoji:       r.Emoji,
+		ModelLabels: r.ModelLabels,
+		IsActive:    true,
+	}
+	if r.IsActive != nil {
+		emotion.IsActive = *r.IsActive
+	}
+	return emotion
+}
+
+type entryTypeRequest struct {
+	EntryType string `json:"entry_type"`
+	NameID    string `json:"name_id" binding:"required"`
+	NameEN    string `json:"name_en" binding:"required"`
+	IsActive  *bool  `json:"is_active"`
+}
+
+func (r *entryTypeRequest) toEntryType() *domain.MoodEntryType {
+	entryType := &domain.MoodEntryType{
+		EntryType: r.EntryType,
+		NameID:    r.NameID,
+		NameEN:    r.NameEN,
+		IsActive:  true,
+	}
+	if r.IsActive != nil {
+		entryType.IsActive = *r.IsActive
+	}
+	return entryType
+}
+
+// GetEmotions lists the active emotions users can pick from
+func (h *emotionHandler) GetEmotions(c *gin.Context) {
+	emotions, err := h.emotionUsecase.GetEmotions(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"emotions": emotions,
+	})
+}
+
+func (h *emotionHandler) GetEntryTypes(c *gin.Context) {
+	entryTypes, err := h.emotionUsecase.GetEntryTypes(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entry_types": entryTypes,
+	})
+}
+
+func (h *emotionHandler) CreateEmotion(c *gin.Context) {
+	var request emotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	emotion, err := h.emotionUsecase.CreateEmotion(request.toEmotion())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, emotion)
+}
+
+func (h *emotionHandler) UpdateEmotion(c *gin.Context) {
+	emotionID, err := strconv.Atoi(c.Param("emotion_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid emotion ID",
+		})
+		return
+	}
+
+	var request emotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	emotion := request.toEmotion()
+	emotion.ID = emotionID
+
+	updated, err := h.emotionUsecase.UpdateEmotion(emotion)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrEmotionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *emotionHandler) DeactivateEmotion(c *gin.Context) {
+	emotionID, err := strconv.Atoi(c.Param("emotion_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid emotion ID",
+		})
+		return
+	}
+
+	if err := h.emotionUsecase.DeactivateEmotion(emotionID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrEmotionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Emotion deactivated successfully",
+	})
+}
+
+func (h *emotionHandler) CreateEntryType(c *gin.Context) {
+	var request entryTypeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entryType, err := h.emotionUsecase.CreateEntryType(request.toEntryType())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entryType)
+}
+
+func (h *emotionHandler) UpdateEntryType(c *gin.Context) {
+	var request entryTypeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entryType := request.toEntryType()
+	entryType.EntryType = c.Param("entry_type")
+
+	updated, err := h.emotionUsecase.UpdateEntryType(entryType)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrEntryTypeNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
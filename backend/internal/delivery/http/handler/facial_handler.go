@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"warasin/internal/domain"
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type facialHandler struct {
+	facialUsecase usecase.FacialUsecase
+}
+
+// NewFacialHandler creates a new facial reading handler
+func NewFacialHandler(facialUsecase usecase.FacialUsecase) *facialHandler {
+	return &facialHandler{
+		facialUsecase: facialUsecase,
+	}
+}
+
+// IngestReadings accepts a batch of expression scores from one detection session.
+// Only scores are accepted, never images.
+func (h *facialHandler) IngestReadings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		SessionID string                         `json:"session_id" binding:"required"`
+		Readings  []*domain.FacialEmotionReading `json:"readings" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.facialUsecase.IngestReadings(userID.(int), request.SessionID, request.Readings)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidFacialReadings) || errors.Is(err, usecase.ErrNoRecognisedEmotions) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   true,
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": "Failed to save facial readings: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type journalAIHandler struct {
+	journalAIUsecase usecase.JournalAIUsecase
+}
+
+// NewJournalAIHandler creates a new journal AI handler
+func NewJournalAIHandler(journalAIUsecase usecase.JournalAIUsecase) *journalAIHandler {
+	return &journalAIHandler{
+		journalAIUsecase: journalAIUsecase,
+	}
+}
+
+func (h *journalAIHandler) GetSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	settings, err := h.journalAIUsecase.GetSettings(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *journalAIHandler) UpdateSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		ReflectionsEnabled   bool `json:"reflections_enabled"`
+		WeeklySummaryEnabled bool `json:"weekly_summary_enabled"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.journalAIUsecase.UpdateSettings(userID.(int), request.ReflectionsEnabled, request.WeeklySummaryEnabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *journalAIHandler) GenerateReflection(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	journalID, err := strconv.Atoi(c.Param("journal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid journal ID",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	reflection, err := h.journalAIUsecase.GenerateReflection(ctx, journalID, userID.(int))
+	if err != nil {
+		if abortOnQuotaExceeded(c, err) {
+			return
+		}
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrJournalAIDisabled) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reflection)
+}
+
+func (h *journalAIHandler) GetReflection(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	journalID, err := strconv.Atoi(c.Param("journal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid journal ID",
+		})
+		return
+	}
+
+	reflection, err := h.journalAIUsecase.GetReflection(journalID, userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if reflection == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   true,
+			"message": "Reflection not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, reflection)
+}
+
+func (h *journalAIHandler) GetSummaries(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	summaries, total, err := h.journalAIUsecase.GetSummaries(userID.(int), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     total,
+		"summaries": summaries,
+	})
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"warasin/internal/usecase"
 
@@ -24,7 +27,8 @@ func (h *journalHandler) Create(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	var request struct {
-		Content string `json:"content" binding:"required"`
+		Content  string `json:"content" binding:"required"`
+		PromptID int    `json:"prompt_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -35,7 +39,7 @@ func (h *journalHandler) Create(c *gin.Context) {
 		return
 	}
 
-	journal, err := h.journalUsecase.Create(userID.(int), request.Content)
+	journal, err := h.journalUsecase.Create(userID.(int), request.Content, request.PromptID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   true,
@@ -44,6 +48,7 @@ func (h *journalHandler) Create(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", journalETag(journal.Version))
 	c.JSON(http.StatusCreated, journal) // Changed to 201 Created
 }
 
@@ -61,7 +66,8 @@ func (h *journalHandler) AnalyzeAndSave(c *gin.Context) {
 	}
 
 	var request struct {
-		Content string `json:"content" binding:"required"`
+		Content  string `json:"content" binding:"required"`
+		PromptID int    `json:"prompt_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -74,7 +80,7 @@ func (h *journalHandler) AnalyzeAndSave(c *gin.Context) {
 		return
 	}
 
-	journal, moodEntry, err := h.journalUsecase.AnalyzeAndSaveJournalWithMood(userID, request.Content)
+	journal, moodEntry, err := h.journalUsecase.AnalyzeAndSaveJournalWithMood(userID, request.Content, request.PromptID)
 	if err != nil {
 		// More specific error handling could be added here based on error type
 		c.JSON(http.StatusInternalServerError, gin.H{"error": true, "message": "Failed to analyze and save journal: " + err.Error()})
@@ -88,6 +94,72 @@ func (h *journalHandler) AnalyzeAndSave(c *gin.Context) {
 	})
 }
 
+// Import handles multipart uploads of Markdown ZIP, Day One JSON or CSV journal exports
+func (h *journalHandler) Import(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Import file is required",
+		})
+		return
+	}
+
+	if fileHeader.Size > usecase.MaxJournalImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   true,
+			"message": "Import file is too large",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Failed to read import file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, usecase.MaxJournalImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Failed to read import file",
+		})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dry_run", "false"))
+	analyzeMood, _ := strconv.ParseBool(c.DefaultPostForm("analyze_mood", "false"))
+
+	result, err := h.journalUsecase.Import(userID.(int), usecase.JournalImportOptions{
+		Format:      c.PostForm("format"),
+		FileName:    fileHeader.Filename,
+		Data:        data,
+		Timezone:    c.PostForm("timezone"),
+		DryRun:      dryRun,
+		AnalyzeMood: analyzeMood,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if result.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
+
 func (h *journalHandler) GetAll(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
@@ -149,9 +221,12 @@ func (h *journalHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", journalETag(journal.Version))
 	c.JSON(http.StatusOK, journal)
 }
 
+// Update requires an If-Match header with the ETag from GetByID. When the journal changed in the
+// meantime it responds 412 with both versions so the client can merge them.
 func (h *journalHandler) Update(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	journalID, err := strconv.Atoi(c.Param("journal_id"))
@@ -163,6 +238,24 @@ func (h *journalHandler) Update(c *gin.Context) {
 		return
 	}
 
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error":   true,
+			"message": "If-Match header with the journal ETag is required",
+		})
+		return
+	}
+
+	expectedVersion, err := parseJournalETag(ifMatch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	var request struct {
 		Content string `json:"content" binding:"required"`
 	}
@@ -175,8 +268,24 @@ func (h *journalHandler) Update(c *gin.Context) {
 		return
 	}
 
-	journal, err := h.journalUsecase.Update(journalID, userID.(int), request.Content)
+	journal, err := h.journalUsecase.Update(journalID, userID.(int), request.Content, expectedVersion)
 	if err != nil {
+		var conflict *usecase.JournalVersionConflictError
+		if errors.As(err, &conflict) {
+			c.Header("ETag", journalETag(conflict.Current.Version))
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"error":   true,
+				"message": err.Error(),
+				"conflict": gin.H{
+					"current": conflict.Current,
+					"yours": gin.H{
+						"content":      request.Content,
+						"base_version": expectedVersion,
+					},
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{ // Or StatusNotFound
 			"error":   true,
 			"message": err.Error(),
@@ -184,6 +293,7 @@ func (h *journalHandler) Update(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", journalETag(journal.Version))
 	c.JSON(http.StatusOK, journal)
 }
 
@@ -209,6 +319,111 @@ func (h *journalHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Journal entry deleted successfully",
+		"message": "Journal entry moved to trash",
 	})
 }
+
+func (h *journalHandler) GetTrash(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	journals, total, err := h.journalUsecase.GetTrash(userID.(int), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   total,
+		"entries": journals,
+	})
+}
+
+func (h *journalHandler) Restore(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	journalID, err := strconv.Atoi(c.Param("journal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid journal ID",
+		})
+		return
+	}
+
+	journal, err := h.journalUsecase.Restore(journalID, userID.(int))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrJournalNotInTrash) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, journal)
+}
+
+func (h *journalHandler) DeletePermanently(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	journalID, err := strconv.Atoi(c.Param("journal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid journal ID",
+		})
+		return
+	}
+
+	err = h.journalUsecase.DeletePermanently(journalID, userID.(int))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrJournalNotInTrash) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Journal entry permanently deleted",
+	})
+}
+
+// journalETag formats a journal version as a strong ETag
+func journalETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseJournalETag reads the version from an If-Match value. "*" returns 0, meaning any version.
+func parseJournalETag(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || version <= 0 {
+		return 0, errors.New("invalid If-Match header")
+	}
+	return version, nil
+}
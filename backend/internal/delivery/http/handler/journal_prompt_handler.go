@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type journalPromptHandler struct {
+	journalPromptUsecase usecase.JournalPromptUsecase
+}
+
+// NewJournalPromptHandler creates a new journal prompt handler
+func NewJournalPromptHandler(journalPromptUsecase usecase.JournalPromptUsecase) *journalPromptHandler {
+	return &journalPromptHandler{
+		journalPromptUsecase: journalPromptUsecase,
+	}
+}
+
+func (h *journalPromptHandler) GetPrompts(c *gin.Context) {
+	prompts, err := h.journalPromptUsecase.GetPrompts(c.Query("category"), c.Query("language"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prompts": prompts,
+	})
+}
+
+func (h *journalPromptHandler) GetTodayPrompt(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	prompt, err := h.journalPromptUsecase.GetTodayPrompt(userID.(int), c.DefaultQuery("language", "id"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// GetStats reports which prompts lead to more writing (admin only)
+func (h *journalPromptHandler) GetStats(c *gin.Context) {
+	stats, err := h.journalPromptUsecase.GetStats(c.Query("language"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prompts": stats,
+	})
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type llmUsageHandler struct {
+	llmUsageUsecase usecase.LLMUsageUsecase
+}
+
+// NewLLMUsageHandler creates a new LLM usage handler
+func NewLLMUsageHandler(llmUsageUsecase usecase.LLMUsageUsecase) *llmUsageHandler {
+	return &llmUsageHandler{
+		llmUsageUsecase: llmUsageUsecase,
+	}
+}
+
+// abortOnQuotaExceeded answers 429 with the reset time when err is an LLMQuotaError and
+// reports whether it did
+func abortOnQuotaExceeded(c *gin.Context, err error) bool {
+	var quotaErr *usecase.LLMQuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(time.Until(quotaErr.ResetAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":    true,
+		"message":  usecase.ErrLLMQuotaExceeded.Error(),
+		"reset_at": quotaErr.ResetAt,
+	})
+	return true
+}
+
+// GetQuotaStatus shows the user how much of today's AI quota is left
+func (h *llmUsageHandler) GetQuotaStatus(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	status, err := h.llmUsageUsecase.GetQuotaStatus(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetReport sums usage and estimated cost of all users for admins
+func (h *llmUsageHandler) GetReport(c *gin.Context) {
+	report, err := h.llmUsageUsecase.GetReport(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
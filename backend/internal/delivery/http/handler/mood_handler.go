@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"warasin/internal/usecase"
 
@@ -70,6 +72,7 @@ func (h *moodHandler) GetAll(c *gin.Context) {
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 	entryType := c.Query("entry_type")
+	source := c.Query("source")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
@@ -81,7 +84,7 @@ func (h *moodHandler) GetAll(c *gin.Context) {
 		offset = 0
 	}
 
-	entries, total, err := h.moodUsecase.GetAll(userID.(int), limit, offset, startDate, endDate, entryType)
+	entries, total, err := h.moodUsecase.GetAll(userID.(int), limit, offset, startDate, endDate, entryType, source)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   true,
@@ -126,3 +129,226 @@ func (h *moodHandler) GetByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, entry)
 }
+
+// GetAnalytics returns aggregated mood statistics so clients don't need the raw entries
+func (h *moodHandler) GetAnalytics(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	analytics, err := h.moodUsecase.GetAnalytics(
+		userID.(int),
+		c.Query("start_date"),
+		c.Query("end_date"),
+		c.Query("timezone"),
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetForecast predicts the next 7 days, or explains that there isn't enough history yet
+func (h *moodHandler) GetForecast(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	forecast, err := h.moodUsecase.GetForecast(userID.(int), c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// Export downloads the mood history as CSV, iCalendar or a FHIR R4 Bundle
+func (h *moodHandler) Export(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	export, err := h.moodUsecase.Export(userID.(int), usecase.MoodExportOptions{
+		Format:    c.DefaultQuery("format", "csv"),
+		StartDate: c.Query("start_date"),
+		EndDate:   c.Query("end_date"),
+		Timezone:  c.Query("timezone"),
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, usecase.ErrMoodExportTimezone), errors.Is(err, usecase.ErrMoodExportStartDate),
+			errors.Is(err, usecase.ErrMoodExportEndDate), errors.Is(err, usecase.ErrMoodExportRange),
+			errors.Is(err, usecase.ErrMoodExportFormat):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName+`"`)
+	c.Data(http.StatusOK, export.ContentType, export.Data)
+}
+
+func (h *moodHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	entryID, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid entry ID",
+		})
+		return
+	}
+
+	// All fields are optional, only the ones sent are changed
+	var request struct {
+		JournalID      *int       `json:"journal_id"`
+		EntryType      *string    `json:"entry_type"`
+		PrimaryEmotion *string    `json:"primary_emotion"`
+		IntensityLevel *float64   `json:"intensity_level"`
+		TriggerFactor  *string    `json:"trigger_factor"`
+		CopingStrategy *string    `json:"coping_strategy"`
+		RecordedAt     *time.Time `json:"recorded_at"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.moodUsecase.Update(entryID, userID.(int), usecase.MoodEntryUpdate{
+		JournalID:      request.JournalID,
+		EntryType:      request.EntryType,
+		PrimaryEmotion: request.PrimaryEmotion,
+		IntensityLevel: request.IntensityLevel,
+		TriggerFactor:  request.TriggerFactor,
+		CopingStrategy: request.CopingStrategy,
+		RecordedAt:     request.RecordedAt,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecase.ErrMoodEntryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *moodHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	entryID, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid entry ID",
+		})
+		return
+	}
+
+	if err := h.moodUsecase.Delete(entryID, userID.(int)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrMoodEntryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Mood entry deleted successfully",
+	})
+}
+
+// GetHistory returns the edit and delete history of one entry
+func (h *moodHandler) GetHistory(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	entryID, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid entry ID",
+		})
+		return
+	}
+
+	history, err := h.moodUsecase.GetHistory(entryID, userID.(int))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrMoodEntryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+	})
+}
+
+// GetTags lists the user's trigger and coping tags, optionally filtered by kind
+func (h *moodHandler) GetTags(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	tags, err := h.moodUsecase.GetTags(userID.(int), c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+// GetInsights reports trigger and coping strategy patterns over the last `days` days
+func (h *moodHandler) GetInsights(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "0"))
+	if err != nil {
+		days = 0
+	}
+
+	followUpHours, err := strconv.Atoi(c.DefaultQuery("follow_up_hours", "0"))
+	if err != nil {
+		followUpHours = 0
+	}
+
+	insights, err := h.moodUsecase.GetTagInsights(userID.(int), days, followUpHours)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, insights)
+}
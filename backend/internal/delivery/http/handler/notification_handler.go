@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"warasin/internal/domain"
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type notificationHandler struct {
+	notificationUsecase usecase.NotificationUsecase
+	reminderUsecase     usecase.ReminderUsecase
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationUsecase usecase.NotificationUsecase, reminderUsecase usecase.ReminderUsecase) *notificationHandler {
+	return &notificationHandler{
+		notificationUsecase: notificationUsecase,
+		reminderUsecase:     reminderUsecase,
+	}
+}
+
+// notificationErrorStatus maps not-found errors to 404, missing push setup to 503 and everything else to fallback
+func notificationErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, usecase.ErrNotificationNotFound), errors.Is(err, usecase.ErrPushSubscriptionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrPushNotConfigured):
+		return http.StatusServiceUnavailable
+	}
+	return fallback
+}
+
+func (h *notificationHandler) GetNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	notifications, total, unread, err := h.notificationUsecase.GetNotifications(userID.(int), c.Query("unread") == "true", limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":         total,
+		"unread":        unread,
+		"notifications": notifications,
+	})
+}
+
+func (h *notificationHandler) MarkRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	notificationID, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid notification ID",
+		})
+		return
+	}
+
+	if err := h.notificationUsecase.MarkRead(notificationID, userID.(int)); err != nil {
+		c.JSON(notificationErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notification marked as read",
+	})
+}
+
+func (h *notificationHandler) MarkAllRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	updated, err := h.notificationUsecase.MarkAllRead(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"updated": updated,
+	})
+}
+
+func (h *notificationHandler) GetPushPublicKey(c *gin.Context) {
+	publicKey, err := h.notificationUsecase.GetPushPublicKey()
+	if err != nil {
+		c.JSON(notificationErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"public_key": publicKey,
+	})
+}
+
+// AddPushSubscription accepts the JSON of a browser PushSubscription
+func (h *notificationHandler) AddPushSubscription(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		Endpoint string `json:"endpoint" binding:"required"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.notificationUsecase.AddPushSubscription(&domain.PushSubscription{
+		UserID:   userID.(int),
+		Endpoint: request.Endpoint,
+		P256dh:   request.Keys.P256dh,
+		Auth:     request.Keys.Auth,
+	})
+	if err != nil {
+		c.JSON(notificationErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+func (h *notificationHandler) DeletePushSubscription(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	subscriptionID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid subscription ID",
+		})
+		return
+	}
+
+	if err := h.notificationUsecase.DeletePushSubscription(subscriptionID, userID.(int)); err != nil {
+		c.JSON(notificationErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Push subscription deleted successfully",
+	})
+}
+
+func (h *notificationHandler) GetReminderSchedule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	schedule, err := h.reminderUsecase.GetSchedule(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *notificationHandler) UpdateReminderSchedule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		Enabled         *bool    `json:"enabled" binding:"required"`
+		Timezone        string   `json:"timezone"`
+		ReminderTime    string   `json:"reminder_time" binding:"required"`
+		QuietHoursStart *string  `json:"quiet_hours_start"`
+		QuietHoursEnd   *string  `json:"quiet_hours_end"`
+		Channels        []string `json:"channels" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.reminderUsecase.UpdateSchedule(&domain.ReminderSchedule{
+		UserID:          userID.(int),
+		Enabled:         *request.Enabled,
+		Timezone:        request.Timezone,
+		ReminderTime:    request.ReminderTime,
+		QuietHoursStart: request.QuietHoursStart,
+		QuietHoursEnd:   request.QuietHoursEnd,
+		Channels:        request.Channels,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type questionnaireHandler struct {
+	questionnaireUsecase usecase.QuestionnaireUsecase
+}
+
+// NewQuestionnaireHandler creates a new questionnaire handler
+func NewQuestionnaireHandler(questionnaireUsecase usecase.QuestionnaireUsecase) *questionnaireHandler {
+	return &questionnaireHandler{
+		questionnaireUsecase: questionnaireUsecase,
+	}
+}
+
+func questionnaireErrorStatus(err error, fallback int) int {
+	if errors.Is(err, usecase.ErrQuestionnaireNotFound) {
+		return http.StatusNotFound
+	}
+	return fallback
+}
+
+func (h *questionnaireHandler) GetQuestionnaires(c *gin.Context) {
+	questionnaires, err := h.questionnaireUsecase.GetQuestionnaires()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questionnaires": questionnaires,
+	})
+}
+
+func (h *questionnaireHandler) GetQuestionnaire(c *gin.Context) {
+	questionnaire, err := h.questionnaireUsecase.GetQuestionnaire(c.Param("code"))
+	if err != nil {
+		c.JSON(questionnaireErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, questionnaire)
+}
+
+func (h *questionnaireHandler) Submit(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		Answers []int `json:"answers" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.questionnaireUsecase.Submit(userID.(int), c.Param("code"), request.Answers)
+	if err != nil {
+		c.JSON(questionnaireErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *questionnaireHandler) GetHistory(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	responses, total, err := h.questionnaireUsecase.GetHistory(userID.(int), c.Query("code"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     total,
+		"responses": responses,
+	})
+}
+
+func (h *questionnaireHandler) GetTrend(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "0"))
+	if err != nil {
+		days = 0
+	}
+
+	trend, err := h.questionnaireUsecase.GetTrend(userID.(int), c.Param("code"), days)
+	if err != nil {
+		c.JSON(questionnaireErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"warasin/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type wellbeingHandler struct {
+	wellbeingUsecase usecase.WellbeingUsecase
+}
+
+// NewWellbeingHandler creates a new wellbeing handler
+func NewWellbeingHandler(wellbeingUsecase usecase.WellbeingUsecase) *wellbeingHandler {
+	return &wellbeingHandler{
+		wellbeingUsecase: wellbeingUsecase,
+	}
+}
+
+// wellbeingErrorStatus maps not-found errors to 404 and everything else to fallback
+func wellbeingErrorStatus(err error, fallback int) int {
+	if errors.Is(err, usecase.ErrWellbeingAlertNotFound) || errors.Is(err, usecase.ErrTrustedContactNotFound) {
+		return http.StatusNotFound
+	}
+	return fallback
+}
+
+func (h *wellbeingHandler) GetAlerts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		offset = 0
+	}
+
+	alerts, total, err := h.wellbeingUsecase.GetAlerts(userID.(int), c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  total,
+		"alerts": alerts,
+	})
+}
+
+func (h *wellbeingHandler) GetAlert(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	alertID, err := strconv.Atoi(c.Param("alert_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid alert ID",
+		})
+		return
+	}
+
+	alert, err := h.wellbeingUsecase.GetAlert(alertID, userID.(int))
+	if err != nil {
+		c.JSON(wellbeingErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+func (h *wellbeingHandler) UpdateAlertStatus(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	alertID, err := strconv.Atoi(c.Param("alert_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid alert ID",
+		})
+		return
+	}
+
+	var request struct {
+		Status string `json:"status" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	alert, err := h.wellbeingUsecase.UpdateAlertStatus(alertID, userID.(int), request.Status)
+	if err != nil {
+		c.JSON(wellbeingErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+func (h *wellbeingHandler) ShareAlert(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	alertID, err := strconv.Atoi(c.Param("alert_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid alert ID",
+		})
+		return
+	}
+
+	var request struct {
+		ContactID int `json:"contact_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	share, err := h.wellbeingUsecase.ShareAlert(alertID, userID.(int), request.ContactID)
+	if err != nil {
+		c.JSON(wellbeingErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+func (h *wellbeingHandler) GetContacts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	contacts, err := h.wellbeingUsecase.GetContacts(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contacts": contacts,
+	})
+}
+
+func (h *wellbeingHandler) AddContact(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var request struct {
+		Name         string `json:"name" binding:"required"`
+		Relationship string `json:"relationship"`
+		Email        string `json:"email"`
+		Phone        string `json:"phone"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	contact, err := h.wellbeingUsecase.AddContact(userID.(int), request.Name, request.Relationship, request.Email, request.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+func (h *wellbeingHandler) DeleteContact(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	contactID, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Invalid contact ID",
+		})
+		return
+	}
+
+	if err := h.wellbeingUsecase.DeleteContact(contactID, userID.(int)); err != nil {
+		c.JSON(wellbeingErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Trusted contact deleted successfully",
+	})
+}
@@ -15,8 +15,21 @@ func RegisterRoutes(
 	router *gin.Engine,
 	userUsecase usecase.UserUsecase,
 	journalUsecase usecase.JournalUsecase,
+	journalAIUsecase usecase.JournalAIUsecase,
+	journalPromptUsecase usecase.JournalPromptUsecase,
 	moodUsecase usecase.MoodUsecase,
+	facialUsecase usecase.FacialUsecase,
+	emotionUsecase usecase.EmotionUsecase,
+	wellbeingUsecase usecase.WellbeingUsecase,
+	questionnaireUsecase usecase.QuestionnaireUsecase,
+	reminderUsecase usecase.ReminderUsecase,
+	notificationUsecase usecase.NotificationUsecase,
 	chatUsecase usecase.ChatUsecase,
+	chatSafetyUsecase usecase.ChatSafetyUsecase,
+	chatFeedbackUsecase usecase.ChatFeedbackUsecase,
+	chatPersonalizationUsecase usecase.ChatPersonalizationUsecase,
+	llmUsageUsecase usecase.LLMUsageUsecase,
+	counselorChatUsecase usecase.CounselorChatUsecase,
 	resourceUsecase usecase.ResourceUsecase,
 	paymentUsecase usecase.PaymentUsecase,
 	activityUsecase usecase.ActivityUsecase,
@@ -33,8 +46,20 @@ func RegisterRoutes(
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userUsecase, jwtService)
 	journalHandler := handler.NewJournalHandler(journalUsecase)
+	journalAIHandler := handler.NewJournalAIHandler(journalAIUsecase)
+	journalPromptHandler := handler.NewJournalPromptHandler(journalPromptUsecase)
 	moodHandler := handler.NewMoodHandler(moodUsecase)
+	facialHandler := handler.NewFacialHandler(facialUsecase)
+	emotionHandler := handler.NewEmotionHandler(emotionUsecase)
+	wellbeingHandler := handler.NewWellbeingHandler(wellbeingUsecase)
+	questionnaireHandler := handler.NewQuestionnaireHandler(questionnaireUsecase)
+	notificationHandler := handler.NewNotificationHandler(notificationUsecase, reminderUsecase)
 	chatHandler := handler.NewChatHandler(chatUsecase)
+	chatSafetyHandler := handler.NewChatSafetyHandler(chatSafetyUsecase)
+	chatFeedbackHandler := handler.NewChatFeedbackHandler(chatFeedbackUsecase)
+	chatPersonalizationHandler := handler.NewChatPersonalizationHandler(chatPersonalizationUsecase)
+	llmUsageHandler := handler.NewLLMUsageHandler(llmUsageUsecase)
+	counselorChatHandler := handler.NewCounselorChatHandler(counselorChatUsecase, jwtService)
 	resourceHandler := handler.NewResourceHandler(resourceUsecase)
 	paymentHandler := handler.NewPaymentHandler(paymentUsecase)
 	activityHandler := handler.NewActivityHandler(activityUsecase)
@@ -42,6 +67,8 @@ func RegisterRoutes(
 	// Auth middleware
 	authMiddleware := middleware.AuthMiddleware(jwtService)
 	premiumMiddleware := middleware.RequireUserType("premium")
+	adminMiddleware := middleware.RequireUserType("admin")
+	counselorMiddleware := middleware.RequireUserType("counselor")
 
 	// Activity logging middleware
 	logActivityMiddleware := handler.LogUserActivity(activityUsecase)
@@ -69,7 +96,18 @@ func RegisterRoutes(
 	{
 		journal.POST("", journalHandler.Create, logActivityMiddleware)                          // For creating journal directly
 		journal.POST("/analyze-and-save", journalHandler.AnalyzeAndSave, logActivityMiddleware) // New route
+		journal.POST("/import", journalHandler.Import, logActivityMiddleware)
 		journal.GET("", journalHandler.GetAll)
+		journal.GET("/trash", journalHandler.GetTrash)
+		journal.POST("/trash/:journal_id/restore", journalHandler.Restore, logActivityMiddleware)
+		journal.DELETE("/trash/:journal_id", journalHandler.DeletePermanently, logActivityMiddleware)
+		journal.GET("/ai-settings", journalAIHandler.GetSettings)
+		journal.PUT("/ai-settings", journalAIHandler.UpdateSettings, logActivityMiddleware)
+		journal.GET("/summaries", journalAIHandler.GetSummaries)
+		journal.GET("/prompts", journalPromptHandler.GetPrompts)
+		journal.GET("/prompts/today", journalPromptHandler.GetTodayPrompt)
+		journal.POST("/:journal_id/reflection", journalAIHandler.GenerateReflection, logActivityMiddleware)
+		journal.GET("/:journal_id/reflection", journalAIHandler.GetReflection)
 		journal.GET("/:journal_id", journalHandler.GetByID)
 		journal.PATCH("/:journal_id", journalHandler.Update, logActivityMiddleware)
 		journal.DELETE("/:journal_id", journalHandler.Delete, logActivityMiddleware)
@@ -79,8 +117,59 @@ func RegisterRoutes(
 	mood := v1.Group("/mood").Use(authMiddleware)
 	{
 		mood.POST("", moodHandler.Create, logActivityMiddleware)
+		mood.POST("/facial-readings", facialHandler.IngestReadings, logActivityMiddleware)
 		mood.GET("", moodHandler.GetAll)
+		mood.GET("/analytics", moodHandler.GetAnalytics)
+		mood.GET("/export", moodHandler.Export, logActivityMiddleware)
+		mood.GET("/forecast", moodHandler.GetForecast)
+		mood.GET("/insights", moodHandler.GetInsights)
+		mood.GET("/tags", moodHandler.GetTags)
+		mood.GET("/emotions", emotionHandler.GetEmotions)
+		mood.GET("/entry-types", emotionHandler.GetEntryTypes)
 		mood.GET("/:entry_id", moodHandler.GetByID)
+		mood.PATCH("/:entry_id", moodHandler.Update, logActivityMiddleware)
+		mood.DELETE("/:entry_id", moodHandler.Delete, logActivityMiddleware)
+		mood.GET("/:entry_id/history", moodHandler.GetHistory)
+	}
+
+	// Wellbeing alerts and trusted contacts
+	wellbeing := v1.Group("/wellbeing").Use(authMiddleware)
+	{
+		wellbeing.GET("/alerts", wellbeingHandler.GetAlerts)
+		wellbeing.GET("/alerts/:alert_id", wellbeingHandler.GetAlert)
+		wellbeing.PATCH("/alerts/:alert_id", wellbeingHandler.UpdateAlertStatus, logActivityMiddleware)
+		wellbeing.POST("/alerts/:alert_id/share", wellbeingHandler.ShareAlert, logActivityMiddleware)
+		wellbeing.GET("/contacts", wellbeingHandler.GetContacts)
+		wellbeing.POST("/contacts", wellbeingHandler.AddContact, logActivityMiddleware)
+		wellbeing.DELETE("/contacts/:contact_id", wellbeingHandler.DeleteContact, logActivityMiddleware)
+	}
+
+	// Screening questionnaires (PHQ-9, GAD-7, PSS)
+	questionnaires := v1.Group("/questionnaires").Use(authMiddleware)
+	{
+		questionnaires.GET("", questionnaireHandler.GetQuestionnaires)
+		questionnaires.GET("/responses", questionnaireHandler.GetHistory)
+		questionnaires.GET("/:code", questionnaireHandler.GetQuestionnaire)
+		questionnaires.POST("/:code/responses", questionnaireHandler.Submit, logActivityMiddleware)
+		questionnaires.GET("/:code/trend", questionnaireHandler.GetTrend)
+	}
+
+	// Mood check-in reminders
+	reminders := v1.Group("/reminders").Use(authMiddleware)
+	{
+		reminders.GET("/schedule", notificationHandler.GetReminderSchedule)
+		reminders.PUT("/schedule", notificationHandler.UpdateReminderSchedule, logActivityMiddleware)
+	}
+
+	// In-app inbox and web push subscriptions
+	notifications := v1.Group("/notifications").Use(authMiddleware)
+	{
+		notifications.GET("", notificationHandler.GetNotifications)
+		notifications.PATCH("/:notification_id/read", notificationHandler.MarkRead)
+		notifications.POST("/read-all", notificationHandler.MarkAllRead)
+		notifications.GET("/push/public-key", notificationHandler.GetPushPublicKey)
+		notifications.POST("/push/subscriptions", notificationHandler.AddPushSubscription, logActivityMiddleware)
+		notifications.DELETE("/push/subscriptions/:subscription_id", notificationHandler.DeletePushSubscription, logActivityMiddleware)
 	}
 
 	// Chat routes - Updated dengan Gemini integration
@@ -94,6 +183,14 @@ func RegisterRoutes(
 			sessions.PATCH("/:session_id", chatHandler.EndSession, logActivityMiddleware)
 			sessions.DELETE("/:session_id", chatHandler.DeleteSession, logActivityMiddleware) // Tambahkan line ini
 			sessions.GET("", chatHandler.GetSessions)
+			sessions.PUT("/:session_id/title", chatHandler.RenameSession, logActivityMiddleware)
+			sessions.POST("/:session_id/pin", chatHandler.PinSession, logActivityMiddleware)
+			sessions.DELETE("/:session_id/pin", chatHandler.UnpinSession, logActivityMiddleware)
+			sessions.POST("/:session_id/archive", chatHandler.ArchiveSession, logActivityMiddleware)
+			sessions.DELETE("/:session_id/archive", chatHandler.UnarchiveSession, logActivityMiddleware)
+			sessions.POST("/:session_id/tags", chatHandler.AddSessionTag, logActivityMiddleware)
+			sessions.DELETE("/:session_id/tags/:tag", chatHandler.RemoveSessionTag, logActivityMiddleware)
+			sessions.GET("/:session_id/feedback", chatFeedbackHandler.GetSessionFeedback)
 		}
 
 		// Chat messages routes
@@ -103,9 +200,43 @@ func RegisterRoutes(
 			messages.GET("", chatHandler.GetMessages)
 		}
 
+		// Thumbs up or down on bot messages
+		chat.PUT("/messages/:message_id/feedback", chatFeedbackHandler.SubmitFeedback, logActivityMiddleware)
+		chat.DELETE("/messages/:message_id/feedback", chatFeedbackHandler.DeleteFeedback, logActivityMiddleware)
+
+		// Full-text search across all of the user's messages
+		chat.GET("/search", chatHandler.SearchMessages)
+
+		// Tags on the user's sessions, filter the list with GET /sessions?tag=
+		chat.GET("/tags", chatHandler.GetTags)
+
 		// Google Gemini AI Chat endpoint (FREE)
 		chat.POST("/gemini", chatHandler.GeminiChat, logActivityMiddleware)
+		chat.POST("/gemini/stream", chatHandler.GeminiChatStream, logActivityMiddleware)
+
+		// Emergency contact consent for crisis situations
+		chat.GET("/safety/settings", chatSafetyHandler.GetSettings)
+		chat.PUT("/safety/settings", chatSafetyHandler.UpdateSettings, logActivityMiddleware)
+
+		// Consent to share mood and journal context with the bot, and what was shared
+		chat.GET("/personalization", chatPersonalizationHandler.GetSettings)
+		chat.PUT("/personalization", chatPersonalizationHandler.UpdateSettings, logActivityMiddleware)
+		chat.DELETE("/personalization", chatPersonalizationHandler.Revoke, logActivityMiddleware)
+		chat.GET("/personalization/preview", chatPersonalizationHandler.Preview)
+		chat.GET("/personalization/log", chatPersonalizationHandler.GetLog)
+		chat.DELETE("/personalization/log", chatPersonalizationHandler.DeleteLog, logActivityMiddleware)
+	}
+
+	// Live chat between a user and their assigned counselor
+	counselorChat := v1.Group("/counselor").Use(authMiddleware)
+	{
+		counselorChat.GET("", counselorChatHandler.GetAssignment)
+		counselorChat.POST("/sessions", counselorChatHandler.StartSession, logActivityMiddleware)
+		counselorChat.GET("/sessions", counselorMiddleware, counselorChatHandler.GetSessions)
+		counselorChat.GET("/sessions/:session_id/messages", counselorChatHandler.GetMessages)
 	}
+	// Authenticates with its first WebSocket frame, browsers can't send the header
+	v1.GET("/counselor/sessions/:session_id/live", counselorChatHandler.LiveChat)
 
 	// Resource routes
 	resources := v1.Group("/resources")
@@ -135,9 +266,38 @@ func RegisterRoutes(
 		payments.GET("", paymentHandler.GetPaymentHistory)
 	}
 
+	// Today's AI usage against the plan's daily quota
+	usage := v1.Group("/usage").Use(authMiddleware)
+	{
+		usage.GET("", llmUsageHandler.GetQuotaStatus)
+	}
+
 	// Activity log routes
 	activity := v1.Group("/activity").Use(authMiddleware)
 	{
 		activity.GET("", activityHandler.GetActivityHistory)
 	}
+
+	// Admin routes
+	admin := v1.Group("/admin").Use(authMiddleware, adminMiddleware)
+	{
+		admin.GET("/journal-prompts/stats", journalPromptHandler.GetStats)
+
+		admin.GET("/emotions", emotionHandler.GetEmotions)
+		admin.POST("/emotions", emotionHandler.CreateEmotion)
+		admin.PUT("/emotions/:emotion_id", emotionHandler.UpdateEmotion)
+		admin.DELETE("/emotions/:emotion_id", emotionHandler.DeactivateEmotion)
+		admin.GET("/mood-entry-types", emotionHandler.GetEntryTypes)
+		admin.POST("/mood-entry-types", emotionHandler.CreateEntryType)
+		admin.PUT("/mood-entry-types/:entry_type", emotionHandler.UpdateEntryType)
+
+		admin.GET("/chat/safety-events", chatSafetyHandler.GetEvents)
+		admin.GET("/chat/feedback", chatFeedbackHandler.GetReport)
+		admin.GET("/chat/feedback/export", chatFeedbackHandler.ExportNegative)
+
+		admin.GET("/llm-usage", llmUsageHandler.GetReport)
+
+		admin.PUT("/counselor-assignments/:user_id", counselorChatHandler.AssignCounselor)
+		admin.DELETE("/counselor-assignments/:user_id", counselorChatHandler.UnassignCounselor)
+	}
 }
@@ -9,6 +9,30 @@ type ChatSession struct {
 	UserID    int        `json:"user_id"`
 	StartTime time.Time  `json:"start_time"`
 	EndTime   *time.Time `json:"end_time,omitempty"`
+	// Set for live sessions with a counselor instead of the bot
+	CounselorID *int `json:"counselor_id,omitempty"`
+	// Empty until generated after the first exchange or set by the user
+	Title       string     `json:"title"`
+	TitleSource string     `json:"title_source,omitempty"` // model, heuristic, user
+	PinnedAt    *time.Time `json:"pinned_at,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	Tags        []string   `json:"tags"`
+}
+
+// ChatSessionFilter narrows the session list. Zero dates are open ends.
+type ChatSessionFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	// Archived lists only archived sessions, otherwise they are left out
+	Archived bool
+	// Tag lists only sessions carrying it, all sessions when empty
+	Tag string
+}
+
+// ChatTagCount is one of the user's session tags and how many sessions carry it
+type ChatTagCount struct {
+	Tag          string `json:"tag"`
+	SessionCount int    `json:"session_count"`
 }
 
 type ChatMessage struct {
@@ -16,5 +40,49 @@ type ChatMessage struct {
 	SessionID      int       `json:"session_id"`
 	MessageContent string    `json:"message_content"`
 	SentAt         time.Time `json:"sent_at"`
-	SenderType     string    `json:"sender_type"` // user, bot
+	SenderType     string    `json:"sender_type"` // user, bot, therapist
+	// Receipts from the other side of a counselor session
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	// What produced a bot message
+	Model         string `json:"model,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+// ChatSearchResult is a message matching a chat search. Snippet is HTML escaped with
+// the matched words wrapped in <mark>.
+type ChatSearchResult struct {
+	MessageID    int       `json:"message_id"`
+	SessionID    int       `json:"session_id"`
+	SessionTitle string    `json:"session_title"`
+	SenderType   string    `json:"sender_type"`
+	SentAt       time.Time `json:"sent_at"`
+	Snippet      string    `json:"snippet"`
+	Rank         float64   `json:"rank"`
+}
+
+// ChatReply is the bot's answer to a chat message
+type ChatReply struct {
+	Response   string `json:"response"`
+	SessionID  int    `json:"session_id"`
+	MessageID  int    `json:"message_id"`
+	TokensUsed int    `json:"tokens_used,omitempty"`
+	Model      string `json:"model"`
+
+	// Set when the message was classified as risky
+	Safety *ChatSafety `json:"safety,omitempty"`
+	// Library resources retrieved for the message and given to the model
+	Citations []ResourceCitation `json:"citations"`
+}
+
+// ChatSessionSummary is a rolling summary of the older part of a long session, so the
+// bot remembers it without sending every message to the model
+type ChatSessionSummary struct {
+	SessionID         int       `json:"session_id"`
+	Summary           string    `json:"summary"`
+	SummarizedUntilID int       `json:"summarized_until_id"`
+	MessageCount      int       `json:"message_count"`
+	Model             string    `json:"model"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
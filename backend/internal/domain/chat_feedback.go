@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+)
+
+// ChatMessageFeedback is a user's rating of one bot message
+type ChatMessageFeedback struct {
+	MessageID int      `json:"message_id"`
+	UserID    int      `json:"user_id"`
+	Rating    string   `json:"rating"` // up, down
+	Reasons   []string `json:"reasons"`
+	Comment   string   `json:"comment,omitempty"`
+	// The user agreed to have the exchange exported for reviewing the prompt
+	ShareForReview bool      `json:"share_for_review"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ChatFeedbackReportRow sums the ratings of the bot messages one prompt version and model produced
+type ChatFeedbackReportRow struct {
+	PromptVersion string         `json:"prompt_version"`
+	Model         string         `json:"model"`
+	Ratings       int            `json:"ratings"`
+	Up            int            `json:"up"`
+	Down          int            `json:"down"`
+	DownRate      float64        `json:"down_rate"`
+	Reasons       map[string]int `json:"reasons"`
+}
+
+// ChatFeedbackReport is the admin overview of bot message ratings
+type ChatFeedbackReport struct {
+	StartDate string                   `json:"start_date"`
+	EndDate   string                   `json:"end_date"`
+	Rows      []*ChatFeedbackReportRow `json:"rows"`
+}
+
+// ChatFeedbackExchange is a negatively rated bot message with the user message it answered.
+// Only exchanges the user agreed to share are exported, and without who the user is.
+type ChatFeedbackExchange struct {
+	MessageID     int       `json:"message_id"`
+	PromptVersion string    `json:"prompt_version"`
+	Model         string    `json:"model"`
+	UserMessage   string    `json:"user_message"`
+	BotMessage    string    `json:"bot_message"`
+	Reasons       []string  `json:"reasons"`
+	Comment       string    `json:"comment,omitempty"`
+	SentAt        time.Time `json:"sent_at"`
+	RatedAt       time.Time `json:"rated_at"`
+}
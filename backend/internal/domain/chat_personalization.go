@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+)
+
+// ChatPersonalizationSettings holds the user's consent to have MindCareBot see a summary
+// of their moods and, when IncludeJournals is set, their journal summaries
+type ChatPersonalizationSettings struct {
+	UserID          int        `json:"user_id"`
+	Enabled         bool       `json:"enabled"`
+	IncludeJournals bool       `json:"include_journals"`
+	ConsentedAt     *time.Time `json:"consented_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ChatContextLog is a personal context block exactly as it was added to a chat prompt
+type ChatContextLog struct {
+	ID        int       `json:"log_id"`
+	UserID    int       `json:"user_id"`
+	SessionID *int      `json:"session_id,omitempty"`
+	Context   string    `json:"context"`
+	Sources   []string  `json:"sources"` // mood_trend, triggers, coping, journal_summary
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+)
+
+// ChatSafetySettings holds the user's consent to have their emergency contact told
+// when they appear to be in crisis during a chat
+type ChatSafetySettings struct {
+	UserID             int        `json:"user_id"`
+	EmergencyContactID *int       `json:"emergency_contact_id"`
+	NotifyContact      bool       `json:"notify_contact"`
+	ConsentedAt        *time.Time `json:"consented_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// ChatSafetyEvent records a chat message classified as risky. The message itself is not stored.
+type ChatSafetyEvent struct {
+	ID                int        `json:"event_id"`
+	UserID            int        `json:"user_id"`
+	SessionID         *int       `json:"session_id,omitempty"`
+	RiskLevel         string     `json:"risk_level"` // medium, high
+	MatchedRules      []string   `json:"matched_rules"`
+	ModelRiskLevel    string     `json:"model_risk_level,omitempty"`
+	Language          string     `json:"language"` // id, en
+	NotifiedContactID *int       `json:"notified_contact_id,omitempty"`
+	NotifiedAt        *time.Time `json:"notified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// ChatSafety is attached to a chat reply when the message was classified as risky
+type ChatSafety struct {
+	RiskLevel       string    `json:"risk_level"`
+	EventID         int       `json:"event_id"`
+	Hotlines        []Hotline `json:"hotlines"`
+	ContactNotified bool      `json:"contact_notified"`
+
+	// Set on high risk, sent instead of a model answer
+	CrisisResponse string `json:"-"`
+}
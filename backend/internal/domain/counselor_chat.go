@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+)
+
+// CounselorAssignment links a user to the counselor they can chat with live
+type CounselorAssignment struct {
+	UserID         int       `json:"user_id"`
+	CounselorID    int       `json:"counselor_id"`
+	CounselorName  string    `json:"counselor_name"`
+	CounselorEmail string    `json:"counselor_email"`
+	AssignedAt     time.Time `json:"assigned_at"`
+}
+
+// LiveChatEvent is one frame of a live counselor session. Clients send message, typing,
+// delivered, read, sync and ping. The server sends ready, message, typing, delivered,
+// read, presence, backfill, resync, ended, pong and error.
+type LiveChatEvent struct {
+	Type      string `json:"type"`
+	SessionID int    `json:"session_id,omitempty"`
+	// Who caused the event and on which side, user or therapist
+	UserID int    `json:"user_id,omitempty"`
+	Role   string `json:"role,omitempty"`
+
+	// Auth frame, the first one a client sends
+	Token string `json:"token,omitempty"`
+	// Sent message, and the id the client gave it to match the echo
+	Content  string       `json:"content,omitempty"`
+	ClientID string       `json:"client_id,omitempty"`
+	Message  *ChatMessage `json:"message,omitempty"`
+	// Receipts cover every message of the other side up to and including MessageID
+	MessageID int `json:"message_id,omitempty"`
+	// Backfill of messages after AfterID, Truncated when only the newest were sent
+	AfterID   int            `json:"after_id,omitempty"`
+	Messages  []*ChatMessage `json:"messages,omitempty"`
+	Truncated bool           `json:"truncated,omitempty"`
+
+	Typing  *bool        `json:"typing,omitempty"`
+	Online  *bool        `json:"online,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Session *ChatSession `json:"session,omitempty"`
+
+	At time.Time `json:"at"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+)
+
+// Emotion is one entry of the emotion taxonomy. Valence doubles as the intensity_level
+// stored on mood entries, so 0 is very negative and 1 very positive.
+type Emotion struct {
+	ID          int       `json:"emotion_id"`
+	Label       string    `json:"label"`
+	Valence     float64   `json:"valence"`
+	Arousal     float64   `json:"arousal"`
+	NameID      string    `json:"name_id"`
+	NameEN      string    `json:"name_en"`
+	Emoji       string    `json:"emoji"`
+	ModelLabels []string  `json:"model_labels"` // Labels returned by the mood model that map to this emotion
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type MoodEntryType struct {
+	EntryType string `json:"entry_type"`
+	NameID    string `json:"name_id"`
+	NameEN    string `json:"name_en"`
+	IsActive  bool   `json:"is_active"`
+}
@@ -5,9 +5,93 @@ import (
 )
 
 type Journal struct {
-	ID        int       `json:"journal_id"`
+	ID        int        `json:"journal_id"`
+	UserID    int        `json:"user_id"`
+	Content   string     `json:"content"`
+	PromptID  *int       `json:"prompt_id,omitempty"` // Daily prompt this entry answered, if any
+	Version   int        `json:"version"`             // Incremented on every update, exposed as the ETag
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Set while the journal is in the trash
+}
+
+// JournalImportEntry is a single entry parsed from an import file
+type JournalImportEntry struct {
+	Source    string    `json:"source"` // file name or CSV row the entry came from
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JournalImportIssue describes an entry that could not be imported
+type JournalImportIssue struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// JournalImportResult summarizes a journal import or its dry-run preview
+type JournalImportResult struct {
+	Format             string                `json:"format"` // markdown, dayone, csv
+	DryRun             bool                  `json:"dry_run"`
+	Parsed             int                   `json:"parsed"`
+	Imported           int                   `json:"imported"`
+	MoodAnalysisQueued int                   `json:"mood_analysis_queued"`
+	Skipped            []*JournalImportIssue `json:"skipped"`
+	Entries            []*JournalImportEntry `json:"entries,omitempty"` // Only filled for dry runs
+	Journals           []*Journal            `json:"journals,omitempty"`
+}
+
+// JournalAISettings holds a user's opt-in choices for AI features on their journals
+type JournalAISettings struct {
+	UserID               int       `json:"user_id"`
+	ReflectionsEnabled   bool      `json:"reflections_enabled"`
+	WeeklySummaryEnabled bool      `json:"weekly_summary_enabled"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// JournalReflection is a short AI-written reflection on a single journal entry
+type JournalReflection struct {
+	ID        int       `json:"reflection_id"`
+	JournalID int       `json:"journal_id"`
 	UserID    int       `json:"user_id"`
 	Content   string    `json:"content"`
+	Model     string    `json:"model"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JournalSummary is an AI-written summary of a week of journaling
+type JournalSummary struct {
+	ID               int       `json:"summary_id"`
+	UserID           int       `json:"user_id"`
+	PeriodStart      time.Time `json:"period_start"`
+	PeriodEnd        time.Time `json:"period_end"`
+	EntryCount       int       `json:"entry_count"`
+	Summary          string    `json:"summary"`
+	Themes           []string  `json:"themes"`
+	MoodShifts       string    `json:"mood_shifts"`
+	SuggestedPrompts []string  `json:"suggested_prompts"`
+	Model            string    `json:"model"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// JournalPrompt is a curated journaling prompt
+type JournalPrompt struct {
+	ID       int    `json:"prompt_id"`
+	Category string `json:"category"` // gratitude, cbt, anxiety, sleep
+	Language string `json:"language"` // id, en
+	Text     string `json:"text"`
+}
+
+// JournalPromptStats shows how much people write when answering a prompt
+type JournalPromptStats struct {
+	JournalPrompt
+	EntryCount   int     `json:"entry_count"`
+	UserCount    int     `json:"user_count"`
+	AvgWordCount float64 `json:"avg_word_count"`
+}
+
+// DailyJournalPrompt is the prompt picked for a user today and why it was picked
+type DailyJournalPrompt struct {
+	Prompt   *JournalPrompt `json:"prompt"`
+	Category string         `json:"category"`
+	Reason   string         `json:"reason"`
 }
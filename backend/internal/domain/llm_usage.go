@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+)
+
+// LLMUsageRow is the usage of one feature and model on one day, summed over users
+type LLMUsageRow struct {
+	UsageDate        time.Time
+	Feature          string
+	Model            string
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// LLMQuotaStatus is a user's language model usage today against their plan's limits.
+// A limit of 0 means unlimited.
+type LLMQuotaStatus struct {
+	UserType      string    `json:"user_type"`
+	Date          string    `json:"date"`
+	RequestsUsed  int       `json:"requests_used"`
+	RequestsLimit int       `json:"requests_limit"`
+	TokensUsed    int       `json:"tokens_used"`
+	TokensLimit   int       `json:"tokens_limit"`
+	ResetAt       time.Time `json:"reset_at"`
+}
+
+// LLMUsageTotals are usage numbers with their estimated cost
+type LLMUsageTotals struct {
+	Name             string  `json:"name,omitempty"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// LLMUserUsage is the usage of one user in a report period
+type LLMUserUsage struct {
+	UserID           int     `json:"user_id"`
+	Email            string  `json:"email"`
+	UserType         string  `json:"user_type"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// LLMUsageReport sums language model usage over a period for admins
+type LLMUsageReport struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	LLMUsageTotals
+	ByModel   []*LLMUsageTotals `json:"by_model"`
+	ByFeature []*LLMUsageTotals `json:"by_feature"`
+	ByDay     []*LLMUsageTotals `json:"by_day"`
+	TopUsers  []*LLMUserUsage   `json:"top_users"`
+	// Models without a known price count as free in the estimate
+	UnpricedModels []string `json:"unpriced_models"`
+}
@@ -14,4 +14,171 @@ type MoodEntry struct {
 	IntensityLevel float64   `json:"intensity_level"`
 	TriggerFactor  string    `json:"trigger_factor"`
 	CopingStrategy string    `json:"coping_strategy"`
+	Source         string    `json:"source"` // journal, manual, facial
+
+	FacialSessionID string `json:"facial_session_id,omitempty"`
+}
+
+// FacialEmotionReading is one frame of expression scores from the browser, e.g.
+// {"happy": 0.82, "neutral": 0.12}. Images never leave the device.
+type FacialEmotionReading struct {
+	CapturedAt time.Time          `json:"captured_at"`
+	Scores     map[string]float64 `json:"scores"`
+}
+
+// FacialSessionResult is the mood entry a facial session was aggregated into
+type FacialSessionResult struct {
+	SessionID    string             `json:"session_id"`
+	ReadingCount int                `json:"reading_count"`
+	Accepted     int                `json:"accepted"` // Readings stored from this batch, duplicates are skipped
+	Scores       map[string]float64 `json:"scores"`   // Mean score per taxonomy emotion
+	Entry        *MoodEntry         `json:"entry"`
+}
+
+// MoodEntryAudit records the state of an entry before and after it was edited or deleted
+type MoodEntryAudit struct {
+	ID        int        `json:"audit_id"`
+	EntryID   int        `json:"entry_id"`
+	UserID    int        `json:"user_id"`
+	Action    string     `json:"action"` // update, delete
+	OldValues *MoodEntry `json:"old_values"`
+	NewValues *MoodEntry `json:"new_values,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MoodAverage is the average intensity of one day, week or month. Period is the
+// first day of the bucket (YYYY-MM-DD) in the user's timezone.
+type MoodAverage struct {
+	Source           string  `json:"source,omitempty"` // Only set when grouped by source
+	Period           string  `json:"period"`
+	AverageIntensity float64 `json:"average_intensity"`
+	EntryCount       int     `json:"entry_count"`
+}
+
+type EmotionCount struct {
+	Emotion          string  `json:"emotion"`
+	Count            int     `json:"count"`
+	Percentage       float64 `json:"percentage"`
+	AverageIntensity float64 `json:"average_intensity"`
+}
+
+// WeekdayAverage uses ISO weekday numbers, 1 is Monday and 7 is Sunday
+type WeekdayAverage struct {
+	Weekday          int     `json:"weekday"`
+	Name             string  `json:"name"`
+	AverageIntensity float64 `json:"average_intensity"`
+	EntryCount       int     `json:"entry_count"`
+}
+
+// MoodStreak counts consecutive days with at least one mood entry. The current
+// streak is still alive when the last entry was today or yesterday.
+type MoodStreak struct {
+	Current       int    `json:"current"`
+	Longest       int    `json:"longest"`
+	LastEntryDate string `json:"last_entry_date,omitempty"`
+}
+
+type MoodAnalytics struct {
+	Timezone            string            `json:"timezone"`
+	StartDate           string            `json:"start_date"`
+	EndDate             string            `json:"end_date"`
+	TotalEntries        int               `json:"total_entries"`
+	AverageIntensity    float64           `json:"average_intensity"`
+	Daily               []*MoodAverage    `json:"daily"`
+	Weekly              []*MoodAverage    `json:"weekly"`
+	Monthly             []*MoodAverage    `json:"monthly"`
+	EmotionDistribution []*EmotionCount   `json:"emotion_distribution"`
+	Weekdays            []*WeekdayAverage `json:"weekdays"`
+	BestWeekday         *WeekdayAverage   `json:"best_weekday"`
+	WorstWeekday        *WeekdayAverage   `json:"worst_weekday"`
+	Streak              *MoodStreak       `json:"streak"`
+	Sources             []*MoodSource     `json:"sources"`
+}
+
+// MoodSource summarises the entries of one source so facial readings can be
+// compared with self-reported moods
+type MoodSource struct {
+	Source           string         `json:"source"`
+	AverageIntensity float64        `json:"average_intensity"`
+	EntryCount       int            `json:"entry_count"`
+	Daily            []*MoodAverage `json:"daily"`
+}
+
+// MoodTag is a normalized trigger or coping strategy, reused across a user's entries
+type MoodTag struct {
+	ID          int    `json:"tag_id"`
+	UserID      int    `json:"user_id"`
+	Kind        string `json:"kind"` // trigger, coping
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	UsageCount  int    `json:"usage_count"`
+}
+
+// TriggerInsight tells how often entries tagged with a trigger came with a low mood,
+// counting the entry itself and anything recorded in the following window.
+type TriggerInsight struct {
+	TagID            int     `json:"tag_id"`
+	Tag              string  `json:"tag"`
+	SampleCount      int     `json:"sample_count"`
+	LowMoodCount     int     `json:"low_mood_count"`
+	LowMoodRate      float64 `json:"low_mood_rate"`
+	AverageIntensity float64 `json:"average_intensity"`
+	LowConfidence    bool    `json:"low_confidence"`
+}
+
+// CopingInsight compares the mood when a strategy was used with the moods recorded after it.
+// SampleCount only includes uses that have a follow-up entry.
+type CopingInsight struct {
+	TagID           int     `json:"tag_id"`
+	Tag             string  `json:"tag"`
+	UsageCount      int     `json:"usage_count"`
+	SampleCount     int     `json:"sample_count"`
+	ImprovedCount   int     `json:"improved_count"`
+	ImprovementRate float64 `json:"improvement_rate"`
+	AverageChange   float64 `json:"average_change"`
+	LowConfidence   bool    `json:"low_confidence"`
+}
+
+type MoodTagInsights struct {
+	PeriodDays          int               `json:"period_days"`
+	FollowUpHours       int               `json:"follow_up_hours"`
+	LowMoodThreshold    float64           `json:"low_mood_threshold"`
+	MinSamples          int               `json:"min_samples"`
+	BaselineSampleCount int               `json:"baseline_sample_count"`
+	BaselineLowMoodRate float64           `json:"baseline_low_mood_rate"`
+	Triggers            []*TriggerInsight `json:"triggers"`
+	CopingStrategies    []*CopingInsight  `json:"coping_strategies"`
+}
+
+// MoodForecast predicts the user's mood for the coming days. Without enough history
+// SufficientData is false, Message explains why and Days is empty.
+type MoodForecast struct {
+	Timezone        string             `json:"timezone"`
+	GeneratedAt     time.Time          `json:"generated_at"`
+	SufficientData  bool               `json:"sufficient_data"`
+	Message         string             `json:"message,omitempty"`
+	DaysWithData    int                `json:"days_with_data"`
+	EntriesUsed     int                `json:"entries_used"`
+	ConfidenceLevel float64            `json:"confidence_level"` // Coverage of the lower/upper bands, e.g. 0.8
+	Days            []*MoodForecastDay `json:"days"`
+}
+
+type MoodForecastDay struct {
+	Date               string                `json:"date"` // YYYY-MM-DD in the user's timezone
+	Weekday            string                `json:"weekday"`
+	PredictedIntensity float64               `json:"predicted_intensity"`
+	Lower              float64               `json:"lower"`
+	Upper              float64               `json:"upper"`
+	LowMoodRisk        bool                  `json:"low_mood_risk"`
+	Periods            []*MoodForecastPeriod `json:"periods"`
+}
+
+// MoodForecastPeriod is a part of the day: night (00-06), morning (06-12),
+// afternoon (12-18) or evening (18-24)
+type MoodForecastPeriod struct {
+	Period             string  `json:"period"`
+	PredictedIntensity float64 `json:"predicted_intensity"`
+	Lower              float64 `json:"lower"`
+	Upper              float64 `json:"upper"`
+	LowMoodRisk        bool    `json:"low_mood_risk"`
 }
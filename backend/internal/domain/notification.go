@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+)
+
+// ReminderSchedule is when a user wants to be reminded to log their mood, in their own timezone
+type ReminderSchedule struct {
+	UserID          int        `json:"user_id"`
+	Enabled         bool       `json:"enabled"`
+	Timezone        string     `json:"timezone"`
+	ReminderTime    string     `json:"reminder_time"`               // HH:MM local time
+	QuietHoursStart *string    `json:"quiet_hours_start,omitempty"` // HH:MM, may wrap past midnight
+	QuietHoursEnd   *string    `json:"quiet_hours_end,omitempty"`
+	Channels        []string   `json:"channels"` // in_app, email, push
+	LastSentOn      *time.Time `json:"last_sent_on,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Channels enabled on this server, filled in when the schedule is shown to the user
+	AvailableChannels []string `json:"available_channels,omitempty"`
+}
+
+// Notification is an entry in the user's in-app inbox
+type Notification struct {
+	ID        int        `json:"notification_id"`
+	UserID    int        `json:"user_id"`
+	Kind      string     `json:"kind"` // mood_reminder
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PushSubscription is a browser Web Push subscription
+type PushSubscription struct {
+	ID        int       `json:"subscription_id"`
+	UserID    int       `json:"user_id"`
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"p256dh"`
+	Auth      string    `json:"auth"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"time"
+)
+
+// Questionnaire is one version of a screening instrument such as PHQ-9
+type Questionnaire struct {
+	ID             int                     `json:"questionnaire_id"`
+	Code           string                  `json:"code"` // phq9, gad7, pss
+	Version        int                     `json:"version"`
+	TitleID        string                  `json:"title_id"`
+	TitleEN        string                  `json:"title_en"`
+	InstructionsID string                  `json:"instructions_id"`
+	InstructionsEN string                  `json:"instructions_en"`
+	Definition     QuestionnaireDefinition `json:"definition"`
+	IsActive       bool                    `json:"is_active"`
+	CreatedAt      time.Time               `json:"created_at"`
+}
+
+// QuestionnaireDefinition is stored as JSON so new versions don't need a schema change
+type QuestionnaireDefinition struct {
+	Items         []QuestionnaireItem   `json:"items"`
+	Options       []QuestionnaireOption `json:"options"`        // Same answer options for every item
+	ReverseScored []int                 `json:"reverse_scored"` // Item numbers scored as (max option - answer)
+	SeverityBands []SeverityBand        `json:"severity_bands"`
+	SafetyRules   []SafetyRule          `json:"safety_rules"`
+}
+
+type QuestionnaireItem struct {
+	Number int    `json:"number"`
+	TextID string `json:"text_id"`
+	TextEN string `json:"text_en"`
+}
+
+type QuestionnaireOption struct {
+	Value   int    `json:"value"`
+	LabelID string `json:"label_id"`
+	LabelEN string `json:"label_en"`
+}
+
+// SeverityBand covers total scores from Min to Max, both inclusive
+type SeverityBand struct {
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Severity string `json:"severity"`
+	LabelID  string `json:"label_id"`
+	LabelEN  string `json:"label_en"`
+}
+
+// SafetyRule triggers a safety response when an item is answered with MinValue or higher
+type SafetyRule struct {
+	Item     int `json:"item"`
+	MinValue int `json:"min_value"`
+}
+
+type QuestionnaireResponse struct {
+	ID              int       `json:"response_id"`
+	UserID          int       `json:"user_id"`
+	QuestionnaireID int       `json:"questionnaire_id"`
+	Code            string    `json:"code"`
+	Version         int       `json:"version"`
+	Answers         []int     `json:"answers"`
+	TotalScore      int       `json:"total_score"`
+	MaxScore        int       `json:"max_score"`
+	Severity        string    `json:"severity"`
+	SafetyFlagged   bool      `json:"safety_flagged"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Only set on the submission response
+	SeverityBand *SeverityBand `json:"severity_band,omitempty"`
+	Safety       *SafetyNotice `json:"safety,omitempty"`
+}
+
+// SafetyNotice is shown right away when an answer points to risk of self-harm
+type SafetyNotice struct {
+	MessageID string    `json:"message_id"`
+	MessageEN string    `json:"message_en"`
+	Hotlines  []Hotline `json:"hotlines"`
+}
+
+// QuestionnaireTrend is the score history of one instrument, oldest first, for charts
+type QuestionnaireTrend struct {
+	Code          string                `json:"code"`
+	MaxScore      int                   `json:"max_score"`
+	SeverityBands []SeverityBand        `json:"severity_bands"`
+	Points        []*QuestionnaireScore `json:"points"`
+}
+
+type QuestionnaireScore struct {
+	ResponseID int       `json:"response_id"`
+	Version    int       `json:"version"`
+	TotalScore int       `json:"total_score"`
+	Severity   string    `json:"severity"`
+	CreatedAt  time.Time `json:"created_at"`
+}
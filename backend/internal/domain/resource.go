@@ -22,3 +22,21 @@ type ResourceInteraction struct {
 	Rating       float64 `json:"rating"`
 	FeedbackText string  `json:"feedback_text"`
 }
+
+// ResourceMatch is a resource found for a chat message, with the parts of its content that matched
+type ResourceMatch struct {
+	ResourceID   int
+	Title        string
+	ContentType  string
+	Language     string
+	Excerpt      string
+	MatchedTerms int
+	Rank         float64
+}
+
+// ResourceCitation points to a resource from the library that was given to the bot for its answer
+type ResourceCitation struct {
+	ResourceID  int    `json:"resource_id"`
+	Title       string `json:"title"`
+	ContentType string `json:"content_type,omitempty"`
+}
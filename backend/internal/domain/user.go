@@ -9,7 +9,7 @@ type User struct {
 	Email        string    `json:"email"`
 	Name         string    `json:"name"`
 	Password     string    `json:"-"`             // Never expose in JSON, nullable for OAuth users
-	UserType     string    `json:"user_type"`     // standard, premium
+	UserType     string    `json:"user_type"`     // standard, premium, admin, counselor
 	GoogleID     string    `json:"-"`             // Google OAuth ID, never expose
 	Avatar       string    `json:"avatar"`        // Profile picture URL
 	AuthProvider string    `json:"auth_provider"` // local, google
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+)
+
+// WellbeingAlert is raised when a user's mood stays low or drops sharply over several days
+type WellbeingAlert struct {
+	ID              int        `json:"alert_id"`
+	UserID          int        `json:"user_id"`
+	AlertType       string     `json:"alert_type"` // sustained_low, sharp_drop
+	Severity        string     `json:"severity"`   // moderate, high
+	RecentAverage   float64    `json:"recent_average"`
+	BaselineAverage *float64   `json:"baseline_average,omitempty"`
+	WindowStart     time.Time  `json:"window_start"`
+	WindowEnd       time.Time  `json:"window_end"`
+	Status          string     `json:"status"` // active, acknowledged, dismissed
+	SharedContactID *int       `json:"shared_contact_id,omitempty"`
+	SharedAt        *time.Time `json:"shared_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Filled in when the alert is shown to the user
+	Message   string      `json:"message,omitempty"`
+	Resources []*Resource `json:"resources,omitempty"`
+	Hotlines  []Hotline   `json:"hotlines,omitempty"`
+}
+
+// TrustedContact is someone the user may choose to share an alert with
+type TrustedContact struct {
+	ID           int       `json:"contact_id"`
+	UserID       int       `json:"user_id"`
+	Name         string    `json:"name"`
+	Relationship string    `json:"relationship"`
+	Email        string    `json:"email,omitempty"`
+	Phone        string    `json:"phone,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Hotline struct {
+	Name        string `json:"name"`
+	Number      string `json:"number"`
+	Description string `json:"description"`
+}
+
+// WellbeingAlertShare is what the user forwards to their trusted contact
+type WellbeingAlertShare struct {
+	Alert   *WellbeingAlert `json:"alert"`
+	Contact *TrustedContact `json:"contact"`
+	Message string          `json:"message"`
+
+	// Prefilled links the user can open to send the message themselves
+	EmailURL    string `json:"email_url,omitempty"`
+	WhatsAppURL string `json:"whatsapp_url,omitempty"`
+}
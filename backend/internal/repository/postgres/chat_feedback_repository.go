@@ -0,0 +1,278 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type chatFeedbackRepository struct {
+	db *sql.DB
+}
+
+// ChatFeedbackRepository interface
+type ChatFeedbackRepository interface {
+	Upsert(feedback *domain.ChatMessageFeedback) (*domain.ChatMessageFeedback, error)
+	GetByMessageID(messageID, userID int) (*domain.ChatMessageFeedback, error)
+	GetBySessionID(sessionID, userID int) ([]*domain.ChatMessageFeedback, error)
+	Delete(messageID, userID int) error
+	GetReport(start, end time.Time) ([]*domain.ChatFeedbackReportRow, error)
+	GetSharedNegative(start, end time.Time, limit int) ([]*domain.ChatFeedbackExchange, error)
+}
+
+// NewChatFeedbackRepository creates a new chat feedback repository
+func NewChatFeedbackRepository(db *sql.DB) ChatFeedbackRepository {
+	return &chatFeedbackRepository{
+		db: db,
+	}
+}
+
+const chatFeedbackColumns = `message_id, user_id, rating, reasons, comment, share_for_review, created_at, updated_at`
+
+// scanChatFeedback reads one row selected with chatFeedbackColumns
+func scanChatFeedback(scan func(dest ...interface{}) error) (*domain.ChatMessageFeedback, error) {
+	var feedback domain.ChatMessageFeedback
+	var comment sql.NullString
+
+	err := scan(
+		&feedback.MessageID,
+		&feedback.UserID,
+		&feedback.Rating,
+		pq.Array(&feedback.Reasons),
+		&comment,
+		&feedback.ShareForReview,
+		&feedback.CreatedAt,
+		&feedback.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback.Comment = comment.String
+	if feedback.Reasons == nil {
+		feedback.Reasons = []string{}
+	}
+
+	return &feedback, nil
+}
+
+// Upsert stores the user's rating of a message, replacing an earlier one
+func (r *chatFeedbackRepository) Upsert(feedback *domain.ChatMessageFeedback) (*domain.ChatMessageFeedback, error) {
+	query := `
+		INSERT INTO chat_message_feedback (message_id, user_id, rating, reasons, comment, share_for_review, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $7)
+		ON CONFLICT (message_id) DO UPDATE SET
+			rating = EXCLUDED.rating,
+			reasons = EXCLUDED.reasons,
+			comment = EXCLUDED.comment,
+			share_for_review = EXCLUDED.share_for_review,
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + chatFeedbackColumns
+
+	return scanChatFeedback(r.db.QueryRow(
+		query,
+		feedback.MessageID,
+		feedback.UserID,
+		feedback.Rating,
+		pq.Array(feedback.Reasons),
+		feedback.Comment,
+		feedback.ShareForReview,
+		time.Now(),
+	).Scan)
+}
+
+func (r *chatFeedbackRepository) GetByMessageID(messageID, userID int) (*domain.ChatMessageFeedback, error) {
+	query := `SELECT ` + chatFeedbackColumns + ` FROM chat_message_feedback WHERE message_id = $1 AND user_id = $2`
+
+	feedback, err := scanChatFeedback(r.db.QueryRow(query, messageID, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// GetBySessionID returns the user's ratings of the session's messages
+func (r *chatFeedbackRepository) GetBySessionID(sessionID, userID int) ([]*domain.ChatMessageFeedback, error) {
+	query := `
+		SELECT f.message_id, f.user_id, f.rating, f.reasons, f.comment, f.share_for_review, f.created_at, f.updated_at
+		FROM chat_message_feedback f
+		JOIN chat_messages m ON m.message_id = f.message_id
+		WHERE m.session_id = $1 AND f.user_id = $2
+		ORDER BY f.message_id
+	`
+
+	rows, err := r.db.Query(query, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedback []*domain.ChatMessageFeedback
+	for rows.Next() {
+		item, err := scanChatFeedback(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		feedback = append(feedback, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+func (r *chatFeedbackRepository) Delete(messageID, userID int) error {
+	result, err := r.db.Exec(`DELETE FROM chat_message_feedback WHERE message_id = $1 AND user_id = $2`, messageID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetReport counts the ratings given in [start, end) per prompt version and model of the
+// rated messages, with how often each reason was picked
+func (r *chatFeedbackRepository) GetReport(start, end time.Time) ([]*domain.ChatFeedbackReportRow, error) {
+	query := `
+		SELECT COALESCE(m.prompt_version, ''), COALESCE(m.model, ''),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE f.rating = 'up'),
+			COUNT(*) FILTER (WHERE f.rating = 'down')
+		FROM chat_message_feedback f
+		JOIN chat_messages m ON m.message_id = f.message_id
+		WHERE f.updated_at >= $1 AND f.updated_at < $2
+		GROUP BY 1, 2
+		ORDER BY 1 DESC, 2
+	`
+
+	rows, err := r.db.Query(query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []*domain.ChatFeedbackReportRow
+	byKey := make(map[[2]string]*domain.ChatFeedbackReportRow)
+	for rows.Next() {
+		row := &domain.ChatFeedbackReportRow{Reasons: make(map[string]int)}
+		if err := rows.Scan(&row.PromptVersion, &row.Model, &row.Ratings, &row.Up, &row.Down); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+		byKey[[2]string{row.PromptVersion, row.Model}] = row
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reasonQuery := `
+		SELECT COALESCE(m.prompt_version, ''), COALESCE(m.model, ''), reason, COUNT(*)
+		FROM chat_message_feedback f
+		JOIN chat_messages m ON m.message_id = f.message_id
+		CROSS JOIN unnest(f.reasons) AS reason
+		WHERE f.updated_at >= $1 AND f.updated_at < $2
+		GROUP BY 1, 2, 3
+	`
+
+	reasonRows, err := r.db.Query(reasonQuery, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer reasonRows.Close()
+
+	for reasonRows.Next() {
+		var promptVersion, model, reason string
+		var count int
+		if err := reasonRows.Scan(&promptVersion, &model, &reason, &count); err != nil {
+			return nil, err
+		}
+		if row, ok := byKey[[2]string{promptVersion, model}]; ok {
+			row.Reasons[reason] = count
+		}
+	}
+
+	if err = reasonRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetSharedNegative returns the thumbs down ratings given in [start, end) that the user
+// agreed to share, with the bot message and the user message before it, newest first
+func (r *chatFeedbackRepository) GetSharedNegative(start, end time.Time, limit int) ([]*domain.ChatFeedbackExchange, error) {
+	query := `
+		SELECT b.message_id, COALESCE(b.prompt_version, ''), COALESCE(b.model, ''),
+			COALESCE(q.message_content, ''), b.message_content,
+			f.reasons, f.comment, b.sent_at, f.updated_at
+		FROM chat_message_feedback f
+		JOIN chat_messages b ON b.message_id = f.message_id
+		LEFT JOIN LATERAL (
+			SELECT message_content
+			FROM chat_messages
+			WHERE session_id = b.session_id AND message_id < b.message_id AND sender_type = 'user'
+			ORDER BY message_id DESC
+			LIMIT 1
+		) q ON TRUE
+		WHERE f.rating = 'down' AND f.share_for_review
+			AND f.updated_at >= $1 AND f.updated_at < $2
+		ORDER BY f.updated_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exchanges []*domain.ChatFeedbackExchange
+	for rows.Next() {
+		var exchange domain.ChatFeedbackExchange
+		var comment sql.NullString
+		err := rows.Scan(
+			&exchange.MessageID,
+			&exchange.PromptVersion,
+			&exchange.Model,
+			&exchange.UserMessage,
+			&exchange.BotMessage,
+			pq.Array(&exchange.Reasons),
+			&comment,
+			&exchange.SentAt,
+			&exchange.RatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		exchange.Comment = comment.String
+		if exchange.Reasons == nil {
+			exchange.Reasons = []string{}
+		}
+		exchanges = append(exchanges, &exchange)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exchanges, nil
+}
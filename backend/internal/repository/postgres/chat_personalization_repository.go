@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type chatPersonalizationRepository struct {
+	db *sql.DB
+}
+
+// ChatPersonalizationRepository interface
+type ChatPersonalizationRepository interface {
+	GetSettings(userID int) (*domain.ChatPersonalizationSettings, error)
+	UpsertSettings(settings *domain.ChatPersonalizationSettings) (*domain.ChatPersonalizationSettings, error)
+	CreateLog(entry *domain.ChatContextLog) (*domain.ChatContextLog, error)
+	GetLogs(userID int, limit, offset int) ([]*domain.ChatContextLog, int, error)
+	DeleteLogs(userID int) (int, error)
+}
+
+// NewChatPersonalizationRepository creates a new chat personalization repository
+func NewChatPersonalizationRepository(db *sql.DB) ChatPersonalizationRepository {
+	return &chatPersonalizationRepository{
+		db: db,
+	}
+}
+
+const chatPersonalizationSettingsColumns = `user_id, enabled, include_journals, consented_at, created_at, updated_at`
+
+// scanChatPersonalizationSettings reads one row selected with chatPersonalizationSettingsColumns
+func scanChatPersonalizationSettings(scan func(dest ...interface{}) error) (*domain.ChatPersonalizationSettings, error) {
+	var settings domain.ChatPersonalizationSettings
+	var consentedAt sql.NullTime
+
+	err := scan(
+		&settings.UserID,
+		&settings.Enabled,
+		&settings.IncludeJournals,
+		&consentedAt,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if consentedAt.Valid {
+		settings.ConsentedAt = &consentedAt.Time
+	}
+
+	return &settings, nil
+}
+
+func (r *chatPersonalizationRepository) GetSettings(userID int) (*domain.ChatPersonalizationSettings, error) {
+	query := `SELECT ` + chatPersonalizationSettingsColumns + ` FROM chat_personalization_settings WHERE user_id = $1`
+
+	settings, err := scanChatPersonalizationSettings(r.db.QueryRow(query, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return settings, err
+}
+
+// UpsertSettings creates or replaces the user's settings. consented_at is set when consent
+// is given, kept while it stays given and cleared when it is revoked.
+func (r *chatPersonalizationRepository) UpsertSettings(settings *domain.ChatPersonalizationSettings) (*domain.ChatPersonalizationSettings, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO chat_personalization_settings (user_id, enabled, include_journals, consented_at, created_at, updated_at)
+		VALUES ($1, $2, $3, CASE WHEN $2 THEN $4::TIMESTAMPTZ END, $4, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			include_journals = EXCLUDED.include_journals,
+			consented_at = CASE
+				WHEN NOT EXCLUDED.enabled THEN NULL
+				WHEN chat_personalization_settings.enabled THEN chat_personalization_settings.consented_at
+				ELSE EXCLUDED.consented_at
+			END,
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + chatPersonalizationSettingsColumns
+
+	return scanChatPersonalizationSettings(r.db.QueryRow(
+		query,
+		settings.UserID,
+		settings.Enabled,
+		settings.IncludeJournals,
+		now,
+	).Scan)
+}
+
+const chatContextLogColumns = `log_id, user_id, session_id, context, sources, created_at`
+
+// scanChatContextLog reads one row selected with chatContextLogColumns
+func scanChatContextLog(scan func(dest ...interface{}) error) (*domain.ChatContextLog, error) {
+	var entry domain.ChatContextLog
+	var sessionID sql.NullInt64
+
+	err := scan(
+		&entry.ID,
+		&entry.UserID,
+		&sessionID,
+		&entry.Context,
+		pq.Array(&entry.Sources),
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.SessionID = nullIntPtr(sessionID)
+	if entry.Sources == nil {
+		entry.Sources = []string{}
+	}
+
+	return &entry, nil
+}
+
+func (r *chatPersonalizationRepository) CreateLog(entry *domain.ChatContextLog) (*domain.ChatContextLog, error) {
+	query := `
+		INSERT INTO chat_context_log (user_id, session_id, context, sources, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + chatContextLogColumns
+
+	return scanChatContextLog(r.db.QueryRow(
+		query,
+		entry.UserID,
+		entry.SessionID,
+		entry.Context,
+		pq.Array(entry.Sources),
+		time.Now(),
+	).Scan)
+}
+
+// GetLogs lists what was sent for the user, newest first
+func (r *chatPersonalizationRepository) GetLogs(userID int, limit, offset int) ([]*domain.ChatContextLog, int, error) {
+	var totalCount int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM chat_context_log WHERE user_id = $1`, userID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `
+		SELECT ` + chatContextLogColumns + `
+		FROM chat_context_log
+		WHERE user_id = $1
+		ORDER BY created_at DESC, log_id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.ChatContextLog
+	for rows.Next() {
+		entry, err := scanChatContextLog(rows.Scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, totalCount, nil
+}
+
+// DeleteLogs removes everything logged for the user and returns how many entries there were
+func (r *chatPersonalizationRepository) DeleteLogs(userID int) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM chat_context_log WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
+}
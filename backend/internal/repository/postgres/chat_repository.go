@@ -2,9 +2,14 @@ package postgres
 
 import (
 	"database/sql"
+	"html"
+	"strconv"
+	"strings"
 	"time"
 
 	"warasin/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 type chatRepository struct {
@@ -16,11 +21,31 @@ type ChatRepository interface {
 	CreateSession(session *domain.ChatSession) (*domain.ChatSession, error)
 	EndSession(sessionID, userID int, endTime time.Time) error
 	GetSessionByID(sessionID, userID int) (*domain.ChatSession, error)
-	GetSessionsByUserID(userID int, limit, offset int, startDate, endDate time.Time) ([]*domain.ChatSession, int, error)
+	GetSessionsByUserID(userID int, limit, offset int, filter domain.ChatSessionFilter) ([]*domain.ChatSession, int, error)
 	CreateMessage(message *domain.ChatMessage) (*domain.ChatMessage, error)
 	GetMessagesBySessionID(sessionID int, limit int, beforeID int) ([]*domain.ChatMessage, int, error)
 	DeleteSession(sessionID, userID int) error     // Tambahkan method ini
 	DeleteMessagesBySessionID(sessionID int) error // Tambahkan method ini
+	GetMessagesAfterID(sessionID, afterID, limit int) ([]*domain.ChatMessage, error)
+	GetSummary(sessionID int) (*domain.ChatSessionSummary, error)
+	UpsertSummary(summary *domain.ChatSessionSummary) (bool, error)
+	GetMessageByID(messageID int) (*domain.ChatMessage, error)
+	GetSessionForParticipant(sessionID, participantID int) (*domain.ChatSession, error)
+	GetOpenCounselorSession(userID, counselorID int) (*domain.ChatSession, error)
+	EndCounselorSessions(userID, counselorID int, endTime time.Time) ([]int, error)
+	GetSessionsByCounselorID(counselorID int, activeOnly bool, limit, offset int) ([]*domain.ChatSession, int, error)
+	MarkMessagesDelivered(sessionID int, senderType string, upToID int, at time.Time) (int, error)
+	MarkMessagesRead(sessionID int, senderType string, upToID int, at time.Time) (int, error)
+	GetFirstMessages(sessionID, limit int) ([]*domain.ChatMessage, error)
+	GetMessagesAround(sessionID, messageID, limit int) ([]*domain.ChatMessage, error)
+	SetGeneratedTitle(sessionID int, title, source string) (bool, error)
+	UpdateTitle(sessionID, userID int, title string) error
+	SetPinned(sessionID, userID int, pinnedAt *time.Time) error
+	SetArchived(sessionID, userID int, archivedAt *time.Time) error
+	AddTag(sessionID, userID int, tag string) error
+	RemoveTag(sessionID, userID int, tag string) error
+	GetTagCounts(userID int) ([]*domain.ChatTagCount, error)
+	SearchMessages(userID int, query string, limit, offset int) ([]*domain.ChatSearchResult, int, error)
 }
 
 // NewChatRepository creates a new chat repository
@@ -30,10 +55,126 @@ func NewChatRepository(db *sql.DB) ChatRepository {
 	}
 }
 
+const chatSessionColumns = `session_id, user_id, start_time, end_time, counselor_id,
+	COALESCE(title, ''), COALESCE(title_source, ''), pinned_at, archived_at, tags`
+
+// scanChatSession reads one row selected with chatSessionColumns
+func scanChatSession(scan func(dest ...interface{}) error) (*domain.ChatSession, error) {
+	var session domain.ChatSession
+	var endTime, pinnedAt, archivedAt sql.NullTime
+	var counselorID sql.NullInt64
+
+	err := scan(
+		&session.ID,
+		&session.UserID,
+		&session.StartTime,
+		&endTime,
+		&counselorID,
+		&session.Title,
+		&session.TitleSource,
+		&pinnedAt,
+		&archivedAt,
+		pq.Array(&session.Tags),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Tags == nil {
+		session.Tags = []string{}
+	}
+
+	if endTime.Valid {
+		session.EndTime = &endTime.Time
+	}
+	session.CounselorID = nullIntPtr(counselorID)
+	if pinnedAt.Valid {
+		session.PinnedAt = &pinnedAt.Time
+	}
+	if archivedAt.Valid {
+		session.ArchivedAt = &archivedAt.Time
+	}
+
+	return &session, nil
+}
+
+const chatMessageColumns = `message_id, session_id, message_content, sent_at, sender_type, delivered_at, read_at,
+	model, prompt_version`
+
+// scanChatMessage reads one row selected with chatMessageColumns
+func scanChatMessage(scan func(dest ...interface{}) error) (*domain.ChatMessage, error) {
+	var message domain.ChatMessage
+	var deliveredAt, readAt sql.NullTime
+	var model, promptVersion sql.NullString
+
+	err := scan(
+		&message.ID,
+		&message.SessionID,
+		&message.MessageContent,
+		&message.SentAt,
+		&message.SenderType,
+		&deliveredAt,
+		&readAt,
+		&model,
+		&promptVersion,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	message.Model = model.String
+	message.PromptVersion = promptVersion.String
+
+	if deliveredAt.Valid {
+		message.DeliveredAt = &deliveredAt.Time
+	}
+	if readAt.Valid {
+		message.ReadAt = &readAt.Time
+	}
+
+	return &message, nil
+}
+
+// scanChatSessions reads all rows selected with chatSessionColumns
+func scanChatSessions(rows *sql.Rows) ([]*domain.ChatSession, error) {
+	var sessions []*domain.ChatSession
+	for rows.Next() {
+		session, err := scanChatSession(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// scanChatMessages reads all rows selected with chatMessageColumns
+func scanChatMessages(rows *sql.Rows) ([]*domain.ChatMessage, error) {
+	var messages []*domain.ChatMessage
+	for rows.Next() {
+		message, err := scanChatMessage(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
 func (r *chatRepository) CreateSession(session *domain.ChatSession) (*domain.ChatSession, error) {
 	query := `
-		INSERT INTO chat_sessions (user_id, start_time)
-		VALUES ($1, $2)
+		INSERT INTO chat_sessions (user_id, start_time, counselor_id)
+		VALUES ($1, $2, $3)
 		RETURNING session_id
 	`
 
@@ -41,12 +182,16 @@ func (r *chatRepository) CreateSession(session *domain.ChatSession) (*domain.Cha
 		query,
 		session.UserID,
 		time.Now(),
+		session.CounselorID,
 	).Scan(&session.ID)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if session.Tags == nil {
+		session.Tags = []string{}
+	}
 	return session, nil
 }
 
@@ -75,49 +220,45 @@ func (r *chatRepository) EndSession(sessionID, userID int, endTime time.Time) er
 }
 
 func (r *chatRepository) GetSessionByID(sessionID, userID int) (*domain.ChatSession, error) {
-	var session domain.ChatSession
-	var endTime sql.NullTime
+	query := `SELECT ` + chatSessionColumns + ` FROM chat_sessions WHERE session_id = $1 AND user_id = $2`
 
-	query := `
-		SELECT session_id, user_id, start_time, end_time
-		FROM chat_sessions
-		WHERE session_id = $1 AND user_id = $2
-	`
-
-	err := r.db.QueryRow(query, sessionID, userID).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.StartTime,
-		&endTime,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	if endTime.Valid {
-		session.EndTime = &endTime.Time
+	session, err := scanChatSession(r.db.QueryRow(query, sessionID, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-
-	return &session, nil
+	return session, err
 }
 
-func (r *chatRepository) GetSessionsByUserID(userID int, limit, offset int, startDate, endDate time.Time) ([]*domain.ChatSession, int, error) {
-	// For simplicity, ignore date filters for now and just get all sessions for the user
-	// This fixes the parameter issue
+// GetSessionsByUserID lists the user's sessions, pinned ones first and then newest first.
+// Dates filter on the start time.
+func (r *chatRepository) GetSessionsByUserID(userID int, limit, offset int, filter domain.ChatSessionFilter) ([]*domain.ChatSession, int, error) {
+	conditions := " WHERE user_id = $1"
+	args := []interface{}{userID}
+	argIndex := 2
 
-	// Get total count
-	countQuery := `
-		SELECT COUNT(*)
-		FROM chat_sessions
-		WHERE user_id = $1
-	`
+	if filter.Archived {
+		conditions += " AND archived_at IS NOT NULL"
+	} else {
+		conditions += " AND archived_at IS NULL"
+	}
+	if !filter.StartDate.IsZero() {
+		conditions += " AND start_time >= $" + strconv.Itoa(argIndex)
+		args = append(args, filter.StartDate)
+		argIndex++
+	}
+	if !filter.EndDate.IsZero() {
+		conditions += " AND start_time <= $" + strconv.Itoa(argIndex)
+		args = append(args, filter.EndDate)
+		argIndex++
+	}
+	if filter.Tag != "" {
+		conditions += " AND tags @> ARRAY[$" + strconv.Itoa(argIndex) + "::TEXT]"
+		args = append(args, filter.Tag)
+		argIndex++
+	}
 
 	var totalCount int
-	err := r.db.QueryRow(countQuery, userID).Scan(&totalCount)
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM chat_sessions`+conditions, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -127,43 +268,18 @@ func (r *chatRepository) GetSessionsByUserID(userID int, limit, offset int, star
 		limit = 10 // Default limit
 	}
 
-	query := `
-		SELECT session_id, user_id, start_time, end_time
-		FROM chat_sessions
-		WHERE user_id = $1
-		ORDER BY start_time DESC 
-		LIMIT $2 OFFSET $3
-	`
+	query := `SELECT ` + chatSessionColumns + ` FROM chat_sessions` + conditions +
+		" ORDER BY pinned_at DESC NULLS LAST, start_time DESC LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, limit, offset)
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var sessions []*domain.ChatSession
-	for rows.Next() {
-		var session domain.ChatSession
-		var endTime sql.NullTime
-
-		err := rows.Scan(
-			&session.ID,
-			&session.UserID,
-			&session.StartTime,
-			&endTime,
-		)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		if endTime.Valid {
-			session.EndTime = &endTime.Time
-		}
-
-		sessions = append(sessions, &session)
-	}
-
-	if err = rows.Err(); err != nil {
+	sessions, err := scanChatSessions(rows)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -172,9 +288,9 @@ func (r *chatRepository) GetSessionsByUserID(userID int, limit, offset int, star
 
 func (r *chatRepository) CreateMessage(message *domain.ChatMessage) (*domain.ChatMessage, error) {
 	query := `
-		INSERT INTO chat_messages (session_id, message_content, sent_at, sender_type)
-		VALUES ($1, $2, $3, $4)
-		RETURNING message_id
+		INSERT INTO chat_messages (session_id, message_content, sent_at, sender_type, model, prompt_version)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING message_id, sent_at
 	`
 
 	err := r.db.QueryRow(
@@ -183,7 +299,9 @@ func (r *chatRepository) CreateMessage(message *domain.ChatMessage) (*domain.Cha
 		message.MessageContent,
 		time.Now(),
 		message.SenderType,
-	).Scan(&message.ID)
+		message.Model,
+		message.PromptVersion,
+	).Scan(&message.ID, &message.SentAt)
 
 	if err != nil {
 		return nil, err
@@ -222,7 +340,7 @@ func (r *chatRepository) GetMessagesBySessionID(sessionID int, limit int, before
 	}
 
 	query := `
-		SELECT message_id, session_id, message_content, sent_at, sender_type
+		SELECT ` + chatMessageColumns + `
 		FROM chat_messages
 		WHERE session_id = $1
 	`
@@ -248,23 +366,8 @@ func (r *chatRepository) GetMessagesBySessionID(sessionID int, limit int, before
 	}
 	defer rows.Close()
 
-	var messages []*domain.ChatMessage
-	for rows.Next() {
-		var message domain.ChatMessage
-		err := rows.Scan(
-			&message.ID,
-			&message.SessionID,
-			&message.MessageContent,
-			&message.SentAt,
-			&message.SenderType,
-		)
-		if err != nil {
-			return nil, 0, err
-		}
-		messages = append(messages, &message)
-	}
-
-	if err = rows.Err(); err != nil {
+	messages, err := scanChatMessages(rows)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -296,3 +399,436 @@ func (r *chatRepository) DeleteSession(sessionID, userID int) error {
 
 	return nil
 }
+
+// GetMessagesAfterID returns up to limit messages newer than afterID, newest first
+func (r *chatRepository) GetMessagesAfterID(sessionID, afterID, limit int) ([]*domain.ChatMessage, error) {
+	query := `
+		SELECT ` + chatMessageColumns + `
+		FROM chat_messages
+		WHERE session_id = $1 AND message_id > $2
+		ORDER BY message_id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, sessionID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanChatMessages(rows)
+}
+
+func (r *chatRepository) GetSummary(sessionID int) (*domain.ChatSessionSummary, error) {
+	var summary domain.ChatSessionSummary
+
+	query := `
+		SELECT session_id, summary, summarized_until_id, message_count, model, created_at, updated_at
+		FROM chat_session_summaries
+		WHERE session_id = $1
+	`
+
+	err := r.db.QueryRow(query, sessionID).Scan(
+		&summary.SessionID,
+		&summary.Summary,
+		&summary.SummarizedUntilID,
+		&summary.MessageCount,
+		&summary.Model,
+		&summary.CreatedAt,
+		&summary.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// UpsertSummary stores the summary unless a summary covering more of the session was
+// stored in the meantime. Returns whether it was stored.
+func (r *chatRepository) UpsertSummary(summary *domain.ChatSessionSummary) (bool, error) {
+	query := `
+		INSERT INTO chat_session_summaries (session_id, summary, summarized_until_id, message_count, model, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (session_id) DO UPDATE SET
+			summary = EXCLUDED.summary,
+			summarized_until_id = EXCLUDED.summarized_until_id,
+			message_count = EXCLUDED.message_count,
+			model = EXCLUDED.model,
+			updated_at = EXCLUDED.updated_at
+		WHERE chat_session_summaries.summarized_until_id < EXCLUDED.summarized_until_id
+	`
+
+	result, err := r.db.Exec(
+		query,
+		summary.SessionID,
+		summary.Summary,
+		summary.SummarizedUntilID,
+		summary.MessageCount,
+		summary.Model,
+		time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *chatRepository) GetMessageByID(messageID int) (*domain.ChatMessage, error) {
+	query := `SELECT ` + chatMessageColumns + ` FROM chat_messages WHERE message_id = $1`
+
+	message, err := scanChatMessage(r.db.QueryRow(query, messageID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return message, err
+}
+
+// GetSessionForParticipant returns a session the participant takes part in, as its user or its counselor
+func (r *chatRepository) GetSessionForParticipant(sessionID, participantID int) (*domain.ChatSession, error) {
+	query := `SELECT ` + chatSessionColumns + `
+		FROM chat_sessions
+		WHERE session_id = $1 AND (user_id = $2 OR counselor_id = $2)`
+
+	session, err := scanChatSession(r.db.QueryRow(query, sessionID, participantID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// GetOpenCounselorSession returns the user's latest session with the counselor that hasn't ended
+func (r *chatRepository) GetOpenCounselorSession(userID, counselorID int) (*domain.ChatSession, error) {
+	query := `SELECT ` + chatSessionColumns + `
+		FROM chat_sessions
+		WHERE user_id = $1 AND counselor_id = $2 AND end_time IS NULL
+		ORDER BY start_time DESC
+		LIMIT 1`
+
+	session, err := scanChatSession(r.db.QueryRow(query, userID, counselorID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// EndCounselorSessions ends the user's open sessions with the counselor and returns their IDs
+func (r *chatRepository) EndCounselorSessions(userID, counselorID int, endTime time.Time) ([]int, error) {
+	query := `
+		UPDATE chat_sessions
+		SET end_time = $3
+		WHERE user_id = $1 AND counselor_id = $2 AND end_time IS NULL
+		RETURNING session_id
+	`
+
+	rows, err := r.db.Query(query, userID, counselorID, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessionIDs []int
+	for rows.Next() {
+		var sessionID int
+		if err := rows.Scan(&sessionID); err != nil {
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+
+	return sessionIDs, rows.Err()
+}
+
+// GetSessionsByCounselorID lists the counselor's sessions, newest first
+func (r *chatRepository) GetSessionsByCounselorID(counselorID int, activeOnly bool, limit, offset int) ([]*domain.ChatSession, int, error) {
+	conditions := " WHERE counselor_id = $1"
+	if activeOnly {
+		conditions += " AND end_time IS NULL"
+	}
+
+	var totalCount int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM chat_sessions`+conditions, counselorID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `SELECT ` + chatSessionColumns + ` FROM chat_sessions` + conditions +
+		` ORDER BY start_time DESC LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, counselorID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	sessions, err := scanChatSessions(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return sessions, totalCount, nil
+}
+
+// MarkMessagesDelivered sets delivered_at on the sender's messages up to upToID that
+// don't have it yet and returns how many changed
+func (r *chatRepository) MarkMessagesDelivered(sessionID int, senderType string, upToID int, at time.Time) (int, error) {
+	query := `
+		UPDATE chat_messages
+		SET delivered_at = $4
+		WHERE session_id = $1 AND sender_type = $2 AND message_id <= $3 AND delivered_at IS NULL
+	`
+
+	return r.execCount(query, sessionID, senderType, upToID, at)
+}
+
+// MarkMessagesRead sets read_at, and delivered_at where missing, on the sender's messages
+// up to upToID and returns how many changed
+func (r *chatRepository) MarkMessagesRead(sessionID int, senderType string, upToID int, at time.Time) (int, error) {
+	query := `
+		UPDATE chat_messages
+		SET read_at = $4, delivered_at = COALESCE(delivered_at, $4)
+		WHERE session_id = $1 AND sender_type = $2 AND message_id <= $3 AND read_at IS NULL
+	`
+
+	return r.execCount(query, sessionID, senderType, upToID, at)
+}
+
+func (r *chatRepository) execCount(query string, args ...interface{}) (int, error) {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetFirstMessages returns the first messages of the session, oldest first
+func (r *chatRepository) GetFirstMessages(sessionID, limit int) ([]*domain.ChatMessage, error) {
+	query := `SELECT ` + chatMessageColumns + `
+		FROM chat_messages
+		WHERE session_id = $1
+		ORDER BY message_id
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanChatMessages(rows)
+}
+
+// GetMessagesAround returns the message with messageID and up to limit messages around it,
+// newest first like GetMessagesBySessionID. Half are older and half newer when there are enough.
+func (r *chatRepository) GetMessagesAround(sessionID, messageID, limit int) ([]*domain.ChatMessage, error) {
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	newer := limit / 2
+
+	query := `
+		(SELECT ` + chatMessageColumns + `
+			FROM chat_messages
+			WHERE session_id = $1 AND message_id > $2
+			ORDER BY message_id
+			LIMIT $3)
+		UNION ALL
+		(SELECT ` + chatMessageColumns + `
+			FROM chat_messages
+			WHERE session_id = $1 AND message_id <= $2
+			ORDER BY message_id DESC
+			LIMIT $4)
+		ORDER BY message_id DESC
+	`
+
+	rows, err := r.db.Query(query, sessionID, messageID, newer, limit-newer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanChatMessages(rows)
+}
+
+// SetGeneratedTitle stores a generated title unless the session already has one, and
+// returns whether it was stored
+func (r *chatRepository) SetGeneratedTitle(sessionID int, title, source string) (bool, error) {
+	query := `
+		UPDATE chat_sessions
+		SET title = $2, title_source = $3
+		WHERE session_id = $1 AND title IS NULL
+	`
+
+	changed, err := r.execCount(query, sessionID, title, source)
+	return changed > 0, err
+}
+
+// UpdateTitle stores a title the user chose, it is never replaced by a generated one
+func (r *chatRepository) UpdateTitle(sessionID, userID int, title string) error {
+	query := `
+		UPDATE chat_sessions
+		SET title = $3, title_source = 'user'
+		WHERE session_id = $1 AND user_id = $2
+	`
+
+	return r.execOne(query, sessionID, userID, title)
+}
+
+// SetPinned pins the session at pinnedAt, or unpins it when nil
+func (r *chatRepository) SetPinned(sessionID, userID int, pinnedAt *time.Time) error {
+	return r.execOne(`UPDATE chat_sessions SET pinned_at = $3 WHERE session_id = $1 AND user_id = $2`, sessionID, userID, pinnedAt)
+}
+
+// SetArchived archives the session at archivedAt, or restores it when nil
+func (r *chatRepository) SetArchived(sessionID, userID int, archivedAt *time.Time) error {
+	return r.execOne(`UPDATE chat_sessions SET archived_at = $3 WHERE session_id = $1 AND user_id = $2`, sessionID, userID, archivedAt)
+}
+
+// AddTag adds the tag to the session, adding one it already has changes nothing
+func (r *chatRepository) AddTag(sessionID, userID int, tag string) error {
+	query := `
+		UPDATE chat_sessions
+		SET tags = CASE WHEN $3 = ANY(tags) THEN tags ELSE array_append(tags, $3) END
+		WHERE session_id = $1 AND user_id = $2
+	`
+	return r.execOne(query, sessionID, userID, tag)
+}
+
+func (r *chatRepository) RemoveTag(sessionID, userID int, tag string) error {
+	return r.execOne(`UPDATE chat_sessions SET tags = array_remove(tags, $3) WHERE session_id = $1 AND user_id = $2`, sessionID, userID, tag)
+}
+
+// GetTagCounts lists the tags on the user's sessions, the most used first
+func (r *chatRepository) GetTagCounts(userID int) ([]*domain.ChatTagCount, error) {
+	query := `
+		SELECT tag, COUNT(*)
+		FROM chat_sessions, unnest(tags) AS tag
+		WHERE user_id = $1
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*domain.ChatTagCount{}
+	for rows.Next() {
+		var count domain.ChatTagCount
+		if err := rows.Scan(&count.Tag, &count.SessionCount); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// execOne runs an update that must change a row, sql.ErrNoRows when it didn't
+func (r *chatRepository) execOne(query string, args ...interface{}) error {
+	changed, err := r.execCount(query, args...)
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Mark the matched words in ts_headline output. Control characters can't come from a
+// user's message through the JSON API, so they survive HTML escaping as markers.
+const (
+	searchMarkStart = "\x02"
+	searchMarkStop  = "\x03"
+)
+
+// SearchMessages finds messages in all of the user's sessions matching query (web search
+// syntax: words, "phrases", or, -exclude), best match first
+func (r *chatRepository) SearchMessages(userID int, query string, limit, offset int) ([]*domain.ChatSearchResult, int, error) {
+	countQuery := `
+		SELECT COUNT(*)
+		FROM chat_messages m
+		JOIN chat_sessions s ON s.session_id = m.session_id
+		WHERE s.user_id = $1 AND m.search_vector @@ websearch_to_tsquery('simple', $2)
+	`
+
+	var totalCount int
+	if err := r.db.QueryRow(countQuery, userID, query).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 20 // Default limit
+	}
+
+	searchQuery := `
+		SELECT m.message_id, m.session_id, COALESCE(s.title, ''), m.sender_type, m.sent_at,
+			ts_headline('simple', m.message_content, q, $5),
+			ts_rank(m.search_vector, q) AS rank
+		FROM chat_messages m
+		JOIN chat_sessions s ON s.session_id = m.session_id
+		CROSS JOIN websearch_to_tsquery('simple', $2) q
+		WHERE s.user_id = $1 AND m.search_vector @@ q
+		ORDER BY rank DESC, m.sent_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	options := "StartSel=" + searchMarkStart + ", StopSel=" + searchMarkStop + ", MinWords=8, MaxWords=25, MaxFragments=2"
+
+	rows, err := r.db.Query(searchQuery, userID, query, limit, offset, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []*domain.ChatSearchResult
+	for rows.Next() {
+		var result domain.ChatSearchResult
+		err := rows.Scan(
+			&result.MessageID,
+			&result.SessionID,
+			&result.SessionTitle,
+			&result.SenderType,
+			&result.SentAt,
+			&result.Snippet,
+			&result.Rank,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result.Snippet = strings.NewReplacer(searchMarkStart, "<mark>", searchMarkStop, "</mark>").Replace(html.EscapeString(result.Snippet))
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return results, totalCount, nil
+}
@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type chatSafetyRepository struct {
+	db *sql.DB
+}
+
+// ChatSafetyRepository interface
+type ChatSafetyRepository interface {
+	GetSettings(userID int) (*domain.ChatSafetySettings, error)
+	UpsertSettings(settings *domain.ChatSafetySettings) (*domain.ChatSafetySettings, error)
+	CreateEvent(event *domain.ChatSafetyEvent) (*domain.ChatSafetyEvent, error)
+	MarkContactNotified(eventID, contactID int, notifiedAt time.Time) error
+	GetLastContactNotification(userID int) (*time.Time, error)
+	GetEvents(riskLevel string, limit, offset int) ([]*domain.ChatSafetyEvent, int, error)
+}
+
+// NewChatSafetyRepository creates a new chat safety repository
+func NewChatSafetyRepository(db *sql.DB) ChatSafetyRepository {
+	return &chatSafetyRepository{
+		db: db,
+	}
+}
+
+const chatSafetySettingsColumns = `user_id, emergency_contact_id, notify_contact, consented_at, created_at, updated_at`
+
+// scanChatSafetySettings reads one row selected with chatSafetySettingsColumns
+func scanChatSafetySettings(scan func(dest ...interface{}) error) (*domain.ChatSafetySettings, error) {
+	var settings domain.ChatSafetySettings
+	var contactID sql.NullInt64
+	var consentedAt sql.NullTime
+
+	err := scan(
+		&settings.UserID,
+		&contactID,
+		&settings.NotifyContact,
+		&consentedAt,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.EmergencyContactID = nullIntPtr(contactID)
+	if consentedAt.Valid {
+		settings.ConsentedAt = &consentedAt.Time
+	}
+
+	return &settings, nil
+}
+
+const chatSafetyEventColumns = `event_id, user_id, session_id, risk_level, matched_rules, model_risk_level,
+	language, notified_contact_id, notified_at, created_at`
+
+// scanChatSafetyEvent reads one row selected with chatSafetyEventColumns
+func scanChatSafetyEvent(scan func(dest ...interface{}) error) (*domain.ChatSafetyEvent, error) {
+	var event domain.ChatSafetyEvent
+	var sessionID, contactID sql.NullInt64
+	var modelRiskLevel sql.NullString
+	var notifiedAt sql.NullTime
+
+	err := scan(
+		&event.ID,
+		&event.UserID,
+		&sessionID,
+		&event.RiskLevel,
+		pq.Array(&event.MatchedRules),
+		&modelRiskLevel,
+		&event.Language,
+		&contactID,
+		&notifiedAt,
+		&event.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	event.SessionID = nullIntPtr(sessionID)
+	event.ModelRiskLevel = modelRiskLevel.String
+	event.NotifiedContactID = nullIntPtr(contactID)
+	if notifiedAt.Valid {
+		event.NotifiedAt = &notifiedAt.Time
+	}
+
+	return &event, nil
+}
+
+func (r *chatSafetyRepository) GetSettings(userID int) (*domain.ChatSafetySettings, error) {
+	query := `SELECT ` + chatSafetySettingsColumns + ` FROM chat_safety_settings WHERE user_id = $1`
+
+	settings, err := scanChatSafetySettings(r.db.QueryRow(query, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return settings, err
+}
+
+// UpsertSettings creates or replaces the user's settings. consented_at is set when consent
+// is given, kept while it stays given and cleared when it is withdrawn.
+func (r *chatSafetyRepository) UpsertSettings(settings *domain.ChatSafetySettings) (*domain.ChatSafetySettings, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO chat_safety_settings (user_id, emergency_contact_id, notify_contact, consented_at, created_at, updated_at)
+		VALUES ($1, $2, $3, CASE WHEN $3 THEN $4::TIMESTAMPTZ END, $4, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			emergency_contact_id = EXCLUDED.emergency_contact_id,
+			notify_contact = EXCLUDED.notify_contact,
+			consented_at = CASE
+				WHEN NOT EXCLUDED.notify_contact THEN NULL
+				WHEN chat_safety_settings.notify_contact THEN chat_safety_settings.consented_at
+				ELSE EXCLUDED.consented_at
+			END,
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + chatSafetySettingsColumns
+
+	return scanChatSafetySettings(r.db.QueryRow(
+		query,
+		settings.UserID,
+		settings.EmergencyContactID,
+		settings.NotifyContact,
+		now,
+	).Scan)
+}
+
+func (r *chatSafetyRepository) CreateEvent(event *domain.ChatSafetyEvent) (*domain.ChatSafetyEvent, error) {
+	query := `
+		INSERT INTO chat_safety_events (user_id, session_id, risk_level, matched_rules, model_risk_level, language, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		RETURNING ` + chatSafetyEventColumns
+
+	if event.MatchedRules == nil {
+		event.MatchedRules = []string{}
+	}
+
+	return scanChatSafetyEvent(r.db.QueryRow(
+		query,
+		event.UserID,
+		event.SessionID,
+		event.RiskLevel,
+		pq.Array(event.MatchedRules),
+		event.ModelRiskLevel,
+		event.Language,
+		time.Now(),
+	).Scan)
+}
+
+func (r *chatSafetyRepository) MarkContactNotified(eventID, contactID int, notifiedAt time.Time) error {
+	query := `
+		UPDATE chat_safety_events
+		SET notified_contact_id = $2, notified_at = $3
+		WHERE event_id = $1
+	`
+
+	result, err := r.db.Exec(query, eventID, contactID, notifiedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetLastContactNotification returns when the user's emergency contact was last told, or nil
+func (r *chatSafetyRepository) GetLastContactNotification(userID int) (*time.Time, error) {
+	var notifiedAt sql.NullTime
+	err := r.db.QueryRow(`SELECT MAX(notified_at) FROM chat_safety_events WHERE user_id = $1`, userID).Scan(&notifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !notifiedAt.Valid {
+		return nil, nil
+	}
+	return &notifiedAt.Time, nil
+}
+
+// GetEvents lists events of all users for review, newest first
+func (r *chatSafetyRepository) GetEvents(riskLevel string, limit, offset int) ([]*domain.ChatSafetyEvent, int, error) {
+	conditions := ""
+	var args []interface{}
+	argIndex := 1
+
+	if riskLevel != "" {
+		conditions = " WHERE risk_level = $" + strconv.Itoa(argIndex)
+		args = append(args, riskLevel)
+		argIndex++
+	}
+
+	var totalCount int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM chat_safety_events`+conditions, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `SELECT ` + chatSafetyEventColumns + `
+		FROM chat_safety_events` + conditions +
+		" ORDER BY created_at DESC LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []*domain.ChatSafetyEvent
+	for rows.Next() {
+		event, err := scanChatSafetyEvent(rows.Scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return events, totalCount, nil
+}
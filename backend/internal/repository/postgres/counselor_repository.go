@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+type counselorRepository struct {
+	db *sql.DB
+}
+
+// CounselorRepository interface
+type CounselorRepository interface {
+	GetAssignment(userID int) (*domain.CounselorAssignment, error)
+	UpsertAssignment(userID, counselorID int) (*domain.CounselorAssignment, error)
+	DeleteAssignment(userID int) error
+	Notify(channel, payload string) error
+}
+
+// NewCounselorRepository creates a new counselor repository
+func NewCounselorRepository(db *sql.DB) CounselorRepository {
+	return &counselorRepository{
+		db: db,
+	}
+}
+
+func (r *counselorRepository) GetAssignment(userID int) (*domain.CounselorAssignment, error) {
+	query := `
+		SELECT a.user_id, a.counselor_id, u.name, u.email, a.assigned_at
+		FROM counselor_assignments a
+		JOIN users u ON u.user_id = a.counselor_id
+		WHERE a.user_id = $1
+	`
+
+	var assignment domain.CounselorAssignment
+	err := r.db.QueryRow(query, userID).Scan(
+		&assignment.UserID,
+		&assignment.CounselorID,
+		&assignment.CounselorName,
+		&assignment.CounselorEmail,
+		&assignment.AssignedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// UpsertAssignment gives the user a counselor, replacing the previous one
+func (r *counselorRepository) UpsertAssignment(userID, counselorID int) (*domain.CounselorAssignment, error) {
+	query := `
+		INSERT INTO counselor_assignments (user_id, counselor_id, assigned_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			counselor_id = EXCLUDED.counselor_id,
+			assigned_at = EXCLUDED.assigned_at
+	`
+
+	if _, err := r.db.Exec(query, userID, counselorID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return r.GetAssignment(userID)
+}
+
+func (r *counselorRepository) DeleteAssignment(userID int) error {
+	result, err := r.db.Exec(`DELETE FROM counselor_assignments WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Notify sends payload to every connection listening on channel, including other API instances
+func (r *counselorRepository) Notify(channel, payload string) error {
+	_, err := r.db.Exec(`SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
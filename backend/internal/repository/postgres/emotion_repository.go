@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type emotionRepository struct {
+	db *sql.DB
+}
+
+// EmotionRepository interface
+type EmotionRepository interface {
+	GetEmotions(includeInactive bool) ([]*domain.Emotion, error)
+	GetEmotionByID(id int) (*domain.Emotion, error)
+	CreateEmotion(emotion *domain.Emotion) (*domain.Emotion, error)
+	UpdateEmotion(emotion *domain.Emotion) error
+	GetEntryTypes(includeInactive bool) ([]*domain.MoodEntryType, error)
+	CreateEntryType(entryType *domain.MoodEntryType) (*domain.MoodEntryType, error)
+	UpdateEntryType(entryType *domain.MoodEntryType) error
+}
+
+// NewEmotionRepository creates a new emotion repository
+func NewEmotionRepository(db *sql.DB) EmotionRepository {
+	return &emotionRepository{
+		db: db,
+	}
+}
+
+func (r *emotionRepository) GetEmotions(includeInactive bool) ([]*domain.Emotion, error) {
+	query := `
+		SELECT emotion_id, label, valence, arousal, name_id, name_en, COALESCE(emoji, ''), model_labels, is_active, created_at, updated_at
+		FROM emotions
+		WHERE $1 OR is_active = TRUE
+		ORDER BY valence DESC, label
+	`
+
+	rows, err := r.db.Query(query, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emotions []*domain.Emotion
+	for rows.Next() {
+		var emotion domain.Emotion
+		err := rows.Scan(
+			&emotion.ID,
+			&emotion.Label,
+			&emotion.Valence,
+			&emotion.Arousal,
+			&emotion.NameID,
+			&emotion.NameEN,
+			&emotion.Emoji,
+			pq.Array(&emotion.ModelLabels),
+			&emotion.IsActive,
+			&emotion.CreatedAt,
+			&emotion.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		emotions = append(emotions, &emotion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emotions, nil
+}
+
+func (r *emotionRepository) GetEmotionByID(id int) (*domain.Emotion, error) {
+	var emotion domain.Emotion
+
+	query := `
+		SELECT emotion_id, label, valence, arousal, name_id, name_en, COALESCE(emoji, ''), model_labels, is_active, created_at, updated_at
+		FROM emotions
+		WHERE emotion_id = $1
+	`
+
+	err := r.db.QueryRow(query, id).Scan(
+		&emotion.ID,
+		&emotion.Label,
+		&emotion.Valence,
+		&emotion.Arousal,
+		&emotion.NameID,
+		&emotion.NameEN,
+		&emotion.Emoji,
+		pq.Array(&emotion.ModelLabels),
+		&emotion.IsActive,
+		&emotion.CreatedAt,
+		&emotion.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &emotion, nil
+}
+
+func (r *emotionRepository) CreateEmotion(emotion *domain.Emotion) (*domain.Emotion, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO emotions (label, valence, arousal, name_id, name_en, emoji, model_labels, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING emotion_id
+	`
+
+	err := r.db.QueryRow(
+		query,
+		emotion.Label,
+		emotion.Valence,
+		emotion.Arousal,
+		emotion.NameID,
+		emotion.NameEN,
+		emotion.Emoji,
+		pq.Array(emotion.ModelLabels),
+		emotion.IsActive,
+		now,
+	).Scan(&emotion.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	emotion.CreatedAt = now
+	emotion.UpdatedAt = now
+	return emotion, nil
+}
+
+// UpdateEmotion saves every field except the label, which existing mood entries refer to
+func (r *emotionRepository) UpdateEmotion(emotion *domain.Emotion) error {
+	now := time.Now()
+	query := `
+		UPDATE emotions
+		SET valence = $2, arousal = $3, name_id = $4, name_en = $5, emoji = $6, model_labels = $7, is_active = $8, updated_at = $9
+		WHERE emotion_id = $1
+	`
+
+	result, err := r.db.Exec(
+		query,
+		emotion.ID,
+		emotion.Valence,
+		emotion.Arousal,
+		emotion.NameID,
+		emotion.NameEN,
+		emotion.Emoji,
+		pq.Array(emotion.ModelLabels),
+		emotion.IsActive,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	emotion.UpdatedAt = now
+	return nil
+}
+
+func (r *emotionRepository) GetEntryTypes(includeInactive bool) ([]*domain.MoodEntryType, error) {
+	query := `
+		SELECT entry_type, name_id, name_en, is_active
+		FROM mood_entry_types
+		WHERE $1 OR is_active = TRUE
+		ORDER BY entry_type
+	`
+
+	rows, err := r.db.Query(query, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entryTypes []*domain.MoodEntryType
+	for rows.Next() {
+		var entryType domain.MoodEntryType
+		if err := rows.Scan(&entryType.EntryType, &entryType.NameID, &entryType.NameEN, &entryType.IsActive); err != nil {
+			return nil, err
+		}
+		entryTypes = append(entryTypes, &entryType)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entryTypes, nil
+}
+
+func (r *emotionRepository) CreateEntryType(entryType *domain.MoodEntryType) (*domain.MoodEntryType, error) {
+	query := `
+		INSERT INTO mood_entry_types (entry_type, name_id, name_en, is_active)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(query, entryType.EntryType, entryType.NameID, entryType.NameEN, entryType.IsActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return entryType, nil
+}
+
+func (r *emotionRepository) UpdateEntryType(entryType *domain.MoodEntryType) error {
+	query := `
+		UPDATE mood_entry_types
+		SET name_id = $2, name_en = $3, is_active = $4
+		WHERE entry_type = $1
+	`
+
+	result, err := r.db.Exec(query, entryType.EntryType, entryType.NameID, entryType.NameEN, entryType.IsActive)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+type facialReadingRepository struct {
+	db *sql.DB
+}
+
+// FacialReadingRepository interface
+type FacialReadingRepository interface {
+	SaveReadings(userID int, sessionID string, readings []*domain.FacialEmotionReading) (int, error)
+	GetReadingsBySession(userID int, sessionID string) ([]*domain.FacialEmotionReading, error)
+}
+
+// NewFacialReadingRepository creates a new facial reading repository
+func NewFacialReadingRepository(db *sql.DB) FacialReadingRepository {
+	return &facialReadingRepository{
+		db: db,
+	}
+}
+
+// SaveReadings stores a batch in one transaction and returns how many were new. A reading
+// with the same timestamp as a stored one is skipped, so clients can safely resend a batch.
+func (r *facialReadingRepository) SaveReadings(userID int, sessionID string, readings []*domain.FacialEmotionReading) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO facial_emotion_readings (user_id, session_id, captured_at, scores, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, session_id, captured_at) DO NOTHING
+	`
+
+	now := time.Now()
+	inserted := 0
+	for _, reading := range readings {
+		scores, err := json.Marshal(reading.Scores)
+		if err != nil {
+			return 0, err
+		}
+
+		result, err := tx.Exec(query, userID, sessionID, reading.CapturedAt, scores, now)
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		inserted += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+// GetReadingsBySession returns every reading of a session, oldest first
+func (r *facialReadingRepository) GetReadingsBySession(userID int, sessionID string) ([]*domain.FacialEmotionReading, error) {
+	query := `
+		SELECT captured_at, scores
+		FROM facial_emotion_readings
+		WHERE user_id = $1 AND session_id = $2
+		ORDER BY captured_at
+	`
+
+	rows, err := r.db.Query(query, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var readings []*domain.FacialEmotionReading
+	for rows.Next() {
+		var reading domain.FacialEmotionReading
+		var scores []byte
+		if err := rows.Scan(&reading.CapturedAt, &scores); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(scores, &reading.Scores); err != nil {
+			return nil, err
+		}
+		readings = append(readings, &reading)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return readings, nil
+}
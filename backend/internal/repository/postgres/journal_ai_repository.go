@@ -0,0 +1,247 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type journalAIRepository struct {
+	db *sql.DB
+}
+
+// JournalAIRepository interface
+type JournalAIRepository interface {
+	GetSettings(userID int) (*domain.JournalAISettings, error)
+	UpsertSettings(settings *domain.JournalAISettings) error
+	GetUserIDsWithWeeklySummary() ([]int, error)
+	UpsertReflection(reflection *domain.JournalReflection) (*domain.JournalReflection, error)
+	GetReflectionByJournalID(journalID, userID int) (*domain.JournalReflection, error)
+	CreateSummary(summary *domain.JournalSummary) (*domain.JournalSummary, error)
+	SummaryExists(userID int, periodStart time.Time) (bool, error)
+	GetSummariesByUserID(userID int, limit, offset int) ([]*domain.JournalSummary, int, error)
+}
+
+// NewJournalAIRepository creates a new journal AI repository
+func NewJournalAIRepository(db *sql.DB) JournalAIRepository {
+	return &journalAIRepository{
+		db: db,
+	}
+}
+
+// GetSettings returns the user's settings, defaulting to everything disabled
+func (r *journalAIRepository) GetSettings(userID int) (*domain.JournalAISettings, error) {
+	settings := domain.JournalAISettings{UserID: userID}
+
+	query := `
+		SELECT reflections_enabled, weekly_summary_enabled, updated_at
+		FROM journal_ai_settings
+		WHERE user_id = $1
+	`
+
+	err := r.db.QueryRow(query, userID).Scan(
+		&settings.ReflectionsEnabled,
+		&settings.WeeklySummaryEnabled,
+		&settings.UpdatedAt,
+	)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func (r *journalAIRepository) UpsertSettings(settings *domain.JournalAISettings) error {
+	now := time.Now()
+	query := `
+		INSERT INTO journal_ai_settings (user_id, reflections_enabled, weekly_summary_enabled, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET reflections_enabled = EXCLUDED.reflections_enabled,
+			weekly_summary_enabled = EXCLUDED.weekly_summary_enabled,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.Exec(query, settings.UserID, settings.ReflectionsEnabled, settings.WeeklySummaryEnabled, now)
+	if err != nil {
+		return err
+	}
+
+	settings.UpdatedAt = now
+	return nil
+}
+
+func (r *journalAIRepository) GetUserIDsWithWeeklySummary() ([]int, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM journal_ai_settings WHERE weekly_summary_enabled = TRUE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// UpsertReflection stores a reflection, replacing any earlier one for the same journal
+func (r *journalAIRepository) UpsertReflection(reflection *domain.JournalReflection) (*domain.JournalReflection, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO journal_reflections (journal_id, user_id, content, model, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (journal_id) DO UPDATE
+		SET content = EXCLUDED.content, model = EXCLUDED.model, created_at = EXCLUDED.created_at
+		RETURNING reflection_id
+	`
+
+	err := r.db.QueryRow(
+		query,
+		reflection.JournalID,
+		reflection.UserID,
+		reflection.Content,
+		reflection.Model,
+		now,
+	).Scan(&reflection.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reflection.CreatedAt = now
+	return reflection, nil
+}
+
+func (r *journalAIRepository) GetReflectionByJournalID(journalID, userID int) (*domain.JournalReflection, error) {
+	var reflection domain.JournalReflection
+	var model sql.NullString
+
+	query := `
+		SELECT reflection_id, journal_id, user_id, content, model, created_at
+		FROM journal_reflections
+		WHERE journal_id = $1 AND user_id = $2
+	`
+
+	err := r.db.QueryRow(query, journalID, userID).Scan(
+		&reflection.ID,
+		&reflection.JournalID,
+		&reflection.UserID,
+		&reflection.Content,
+		&model,
+		&reflection.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	reflection.Model = model.String
+	return &reflection, nil
+}
+
+func (r *journalAIRepository) CreateSummary(summary *domain.JournalSummary) (*domain.JournalSummary, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO journal_summaries (user_id, period_start, period_end, entry_count, summary, themes, mood_shifts, suggested_prompts, model, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING summary_id
+	`
+
+	err := r.db.QueryRow(
+		query,
+		summary.UserID,
+		summary.PeriodStart,
+		summary.PeriodEnd,
+		summary.EntryCount,
+		summary.Summary,
+		pq.Array(summary.Themes),
+		summary.MoodShifts,
+		pq.Array(summary.SuggestedPrompts),
+		summary.Model,
+		now,
+	).Scan(&summary.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	summary.CreatedAt = now
+	return summary, nil
+}
+
+func (r *journalAIRepository) SummaryExists(userID int, periodStart time.Time) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM journal_summaries WHERE user_id = $1 AND period_start = $2)`
+	err := r.db.QueryRow(query, userID, periodStart).Scan(&exists)
+	return exists, err
+}
+
+func (r *journalAIRepository) GetSummariesByUserID(userID int, limit, offset int) ([]*domain.JournalSummary, int, error) {
+	var totalCount int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM journal_summaries WHERE user_id = $1`, userID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `
+		SELECT summary_id, user_id, period_start, period_end, entry_count, summary, themes, mood_shifts, suggested_prompts, model, created_at
+		FROM journal_summaries
+		WHERE user_id = $1
+		ORDER BY period_start DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var summaries []*domain.JournalSummary
+	for rows.Next() {
+		var summary domain.JournalSummary
+		var moodShifts, model sql.NullString
+		err := rows.Scan(
+			&summary.ID,
+			&summary.UserID,
+			&summary.PeriodStart,
+			&summary.PeriodEnd,
+			&summary.EntryCount,
+			&summary.Summary,
+			pq.Array(&summary.Themes),
+			&moodShifts,
+			pq.Array(&summary.SuggestedPrompts),
+			&model,
+			&summary.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		summary.MoodShifts = moodShifts.String
+		summary.Model = model.String
+		summaries = append(summaries, &summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return summaries, totalCount, nil
+}
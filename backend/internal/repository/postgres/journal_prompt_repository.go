@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"database/sql"
+	"strconv"
+
+	"warasin/internal/domain"
+)
+
+type journalPromptRepository struct {
+	db *sql.DB
+}
+
+// JournalPromptRepository interface
+type JournalPromptRepository interface {
+	GetPrompts(category, language string) ([]*domain.JournalPrompt, error)
+	GetByID(id int) (*domain.JournalPrompt, error)
+	GetStats(language string) ([]*domain.JournalPromptStats, error)
+}
+
+// NewJournalPromptRepository creates a new journal prompt repository
+func NewJournalPromptRepository(db *sql.DB) JournalPromptRepository {
+	return &journalPromptRepository{
+		db: db,
+	}
+}
+
+// GetPrompts returns active prompts, optionally filtered by category and language
+func (r *journalPromptRepository) GetPrompts(category, language string) ([]*domain.JournalPrompt, error) {
+	query := `
+		SELECT prompt_id, category, language, text
+		FROM journal_prompts
+		WHERE is_active = TRUE
+	`
+
+	args := []interface{}{}
+	argIndex := 1
+
+	if category != "" {
+		query += " AND category = $" + strconv.Itoa(argIndex)
+		args = append(args, category)
+		argIndex++
+	}
+	if language != "" {
+		query += " AND language = $" + strconv.Itoa(argIndex)
+		args = append(args, language)
+		argIndex++
+	}
+
+	query += " ORDER BY prompt_id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []*domain.JournalPrompt
+	for rows.Next() {
+		var prompt domain.JournalPrompt
+		err := rows.Scan(
+			&prompt.ID,
+			&prompt.Category,
+			&prompt.Language,
+			&prompt.Text,
+		)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, &prompt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prompts, nil
+}
+
+func (r *journalPromptRepository) GetByID(id int) (*domain.JournalPrompt, error) {
+	var prompt domain.JournalPrompt
+
+	query := `
+		SELECT prompt_id, category, language, text
+		FROM journal_prompts
+		WHERE prompt_id = $1 AND is_active = TRUE
+	`
+
+	err := r.db.QueryRow(query, id).Scan(
+		&prompt.ID,
+		&prompt.Category,
+		&prompt.Language,
+		&prompt.Text,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &prompt, nil
+}
+
+// GetStats reports, per prompt, how many entries answered it and how long they were.
+// Trashed journals still count: they were written in response to the prompt.
+func (r *journalPromptRepository) GetStats(language string) ([]*domain.JournalPromptStats, error) {
+	query := `
+		SELECT p.prompt_id, p.category, p.language, p.text,
+			COUNT(j.journal_id),
+			COUNT(DISTINCT j.user_id),
+			COALESCE(AVG(array_length(regexp_split_to_array(btrim(j.content), '\s+'), 1)), 0)
+		FROM journal_prompts p
+		LEFT JOIN journals j ON j.prompt_id = p.prompt_id
+		WHERE ($1 = '' OR p.language = $1)
+		GROUP BY p.prompt_id
+		ORDER BY COUNT(j.journal_id) DESC, p.prompt_id
+	`
+
+	rows, err := r.db.Query(query, language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*domain.JournalPromptStats
+	for rows.Next() {
+		var stat domain.JournalPromptStats
+		err := rows.Scan(
+			&stat.ID,
+			&stat.Category,
+			&stat.Language,
+			&stat.Text,
+			&stat.EntryCount,
+			&stat.UserCount,
+			&stat.AvgWordCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, &stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
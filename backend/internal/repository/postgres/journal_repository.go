@@ -18,10 +18,18 @@ type journalRepository struct {
 // JournalRepository interface
 type JournalRepository interface {
 	Create(journal *domain.Journal) (*domain.Journal, error)
+	BulkCreate(journals []*domain.Journal, queueMoodAnalysis bool) error
 	GetByID(id int, userID int) (*domain.Journal, error)
 	GetByUserID(userID int, limit, offset int, startDate, endDate time.Time) ([]*domain.Journal, int, error)
 	Update(journal *domain.Journal) error
 	Delete(id int, userID int) error
+	GetTrashByUserID(userID int, limit, offset int) ([]*domain.Journal, int, error)
+	Restore(id int, userID int) error
+	DeletePermanently(id int, userID int) error
+	PurgeTrash(deletedBefore time.Time) (int64, error)
+	GetQueuedMoodAnalysis(maxAttempts, limit int) ([]*domain.Journal, error)
+	FinishMoodAnalysis(id int) error
+	FailMoodAnalysis(id int) error
 }
 
 // NewJournalRepository creates a new journal repository
@@ -39,11 +47,20 @@ func logArgsWithTypes(args []interface{}) string {
 	return strings.Join(loggedArgs, ", ")
 }
 
+// nullIntPtr converts a nullable integer column to an optional int
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
 func (r *journalRepository) Create(journal *domain.Journal) (*domain.Journal, error) {
 	now := time.Now()
 	query := `
-		INSERT INTO journals (user_id, content, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO journals (user_id, content, prompt_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING journal_id
 	`
 
@@ -51,6 +68,7 @@ func (r *journalRepository) Create(journal *domain.Journal) (*domain.Journal, er
 		query,
 		journal.UserID,
 		journal.Content,
+		journal.PromptID,
 		now,
 		now,
 	).Scan(&journal.ID)
@@ -61,16 +79,59 @@ func (r *journalRepository) Create(journal *domain.Journal) (*domain.Journal, er
 
 	journal.CreatedAt = now
 	journal.UpdatedAt = now
+	journal.Version = 1
 
 	return journal, nil
 }
 
+// BulkCreate inserts all journals in a single transaction, keeping the
+// CreatedAt/UpdatedAt values already set on each journal. With queueMoodAnalysis
+// the journals are marked for the background mood analysis.
+func (r *journalRepository) BulkCreate(journals []*domain.Journal, queueMoodAnalysis bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO journals (user_id, content, prompt_id, created_at, updated_at, mood_analysis_queued_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $6 THEN NOW() END)
+		RETURNING journal_id
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, journal := range journals {
+		if journal.UpdatedAt.IsZero() {
+			journal.UpdatedAt = journal.CreatedAt
+		}
+		err := stmt.QueryRow(
+			journal.UserID,
+			journal.Content,
+			journal.PromptID,
+			journal.CreatedAt,
+			journal.UpdatedAt,
+			queueMoodAnalysis,
+		).Scan(&journal.ID)
+		if err != nil {
+			return fmt.Errorf("error inserting imported journal: %w", err)
+		}
+		journal.Version = 1
+	}
+
+	return tx.Commit()
+}
+
 func (r *journalRepository) GetByID(id int, userID int) (*domain.Journal, error) {
 	var journal domain.Journal
+	var promptID sql.NullInt64
 	query := `
-		SELECT journal_id, user_id, content, created_at, updated_at
+		SELECT journal_id, user_id, content, prompt_id, version, created_at, updated_at
 		FROM journals
-		WHERE journal_id = $1 AND user_id = $2
+		WHERE journal_id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 	args := []interface{}{id, userID}
 	log.Printf("DEBUG: GetByID Query: %s, Args: [%s]", query, logArgsWithTypes(args))
@@ -79,6 +140,8 @@ func (r *journalRepository) GetByID(id int, userID int) (*domain.Journal, error)
 		&journal.ID,
 		&journal.UserID,
 		&journal.Content,
+		&promptID,
+		&journal.Version,
 		&journal.CreatedAt,
 		&journal.UpdatedAt,
 	)
@@ -89,12 +152,13 @@ func (r *journalRepository) GetByID(id int, userID int) (*domain.Journal, error)
 		}
 		return nil, fmt.Errorf("error getting journal by id (Query: %s, Args: %v): %w", query, args, err)
 	}
+	journal.PromptID = nullIntPtr(promptID)
 	return &journal, nil
 }
 
 func (r *journalRepository) GetByUserID(userID int, limit, offset int, startDate, endDate time.Time) ([]*domain.Journal, int, error) {
 	var totalCount int
-	countQueryBase := "SELECT COUNT(*) FROM journals WHERE user_id = $1"
+	countQueryBase := "SELECT COUNT(*) FROM journals WHERE user_id = $1 AND deleted_at IS NULL"
 	countArgs := []interface{}{userID}
 	countQueryConditions := ""
 	currentArgIdx := 2 // Start from $2 for conditional params
@@ -128,7 +192,7 @@ func (r *journalRepository) GetByUserID(userID int, limit, offset int, startDate
 		offset = 0
 	}
 
-	mainQueryBase := "SELECT journal_id, user_id, content, created_at, updated_at FROM journals WHERE user_id = $1"
+	mainQueryBase := "SELECT journal_id, user_id, content, prompt_id, version, created_at, updated_at FROM journals WHERE user_id = $1 AND deleted_at IS NULL"
 	mainArgs := []interface{}{userID}
 	mainQueryConditions := ""
 	currentArgIdx = 2 // Reset for main query conditional params
@@ -163,16 +227,20 @@ func (r *journalRepository) GetByUserID(userID int, limit, offset int, startDate
 	var journals []*domain.Journal
 	for rows.Next() {
 		var journal domain.Journal
+		var promptID sql.NullInt64
 		err := rows.Scan(
 			&journal.ID,
 			&journal.UserID,
 			&journal.Content,
+			&promptID,
+			&journal.Version,
 			&journal.CreatedAt,
 			&journal.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("error scanning journal row: %w", err)
 		}
+		journal.PromptID = nullIntPtr(promptID)
 		journals = append(journals, &journal)
 	}
 
@@ -183,15 +251,17 @@ func (r *journalRepository) GetByUserID(userID int, limit, offset int, startDate
 	return journals, totalCount, nil
 }
 
+// Update saves the journal only if its stored version still equals journal.Version,
+// then bumps the version. sql.ErrNoRows means the journal is gone or was changed meanwhile.
 func (r *journalRepository) Update(journal *domain.Journal) error {
 	now := time.Now()
 	query := `
 		UPDATE journals
-		SET content = $3, updated_at = $4
-		WHERE journal_id = $1 AND user_id = $2
+		SET content = $3, updated_at = $4, version = version + 1
+		WHERE journal_id = $1 AND user_id = $2 AND version = $5 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.Exec(query, journal.ID, journal.UserID, journal.Content, now)
+	result, err := r.db.Exec(query, journal.ID, journal.UserID, journal.Content, now, journal.Version)
 	if err != nil {
 		return err
 	}
@@ -206,13 +276,125 @@ func (r *journalRepository) Update(journal *domain.Journal) error {
 	}
 
 	journal.UpdatedAt = now
+	journal.Version++
 	return nil
 }
 
+// Delete moves a journal to the trash. Linked mood entries keep their journal_id
+// until the journal is deleted permanently.
 func (r *journalRepository) Delete(id int, userID int) error {
+	query := `
+		UPDATE journals
+		SET deleted_at = $3
+		WHERE journal_id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *journalRepository) GetTrashByUserID(userID int, limit, offset int) ([]*domain.Journal, int, error) {
+	var totalCount int
+	countQuery := "SELECT COUNT(*) FROM journals WHERE user_id = $1 AND deleted_at IS NOT NULL"
+	if err := r.db.QueryRow(countQuery, userID).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("error counting trashed journals: %w", err)
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+		SELECT journal_id, user_id, content, prompt_id, version, created_at, updated_at, deleted_at
+		FROM journals
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying trashed journals: %w", err)
+	}
+	defer rows.Close()
+
+	var journals []*domain.Journal
+	for rows.Next() {
+		var journal domain.Journal
+		var promptID sql.NullInt64
+		var deletedAt sql.NullTime
+		err := rows.Scan(
+			&journal.ID,
+			&journal.UserID,
+			&journal.Content,
+			&promptID,
+			&journal.Version,
+			&journal.CreatedAt,
+			&journal.UpdatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning trashed journal row: %w", err)
+		}
+		journal.PromptID = nullIntPtr(promptID)
+		if deletedAt.Valid {
+			journal.DeletedAt = &deletedAt.Time
+		}
+		journals = append(journals, &journal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating trashed journal rows: %w", err)
+	}
+
+	return journals, totalCount, nil
+}
+
+func (r *journalRepository) Restore(id int, userID int) error {
+	query := `
+		UPDATE journals
+		SET deleted_at = NULL
+		WHERE journal_id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+	`
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeletePermanently removes a trashed journal for good
+func (r *journalRepository) DeletePermanently(id int, userID int) error {
 	query := `
 		DELETE FROM journals
-		WHERE journal_id = $1 AND user_id = $2
+		WHERE journal_id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(query, id, userID)
@@ -231,3 +413,72 @@ func (r *journalRepository) Delete(id int, userID int) error {
 
 	return nil
 }
+
+// PurgeTrash permanently deletes every journal trashed before deletedBefore
+func (r *journalRepository) PurgeTrash(deletedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM journals
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	`
+
+	result, err := r.db.Exec(query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetQueuedMoodAnalysis returns journals waiting for mood analysis that have failed fewer
+// than maxAttempts times, those with the fewest failures and queued first coming first
+func (r *journalRepository) GetQueuedMoodAnalysis(maxAttempts, limit int) ([]*domain.Journal, error) {
+	query := `
+		SELECT journal_id, user_id, content, created_at
+		FROM journals
+		WHERE mood_analysis_queued_at IS NOT NULL AND deleted_at IS NULL AND mood_analysis_attempts < $1
+		ORDER BY mood_analysis_attempts, mood_analysis_queued_at, journal_id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, maxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var journals []*domain.Journal
+	for rows.Next() {
+		var journal domain.Journal
+		if err := rows.Scan(&journal.ID, &journal.UserID, &journal.Content, &journal.CreatedAt); err != nil {
+			return nil, err
+		}
+		journals = append(journals, &journal)
+	}
+
+	return journals, rows.Err()
+}
+
+// FinishMoodAnalysis takes a journal off the mood analysis queue
+func (r *journalRepository) FinishMoodAnalysis(id int) error {
+	query := `
+		UPDATE journals
+		SET mood_analysis_queued_at = NULL
+		WHERE journal_id = $1
+	`
+
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// FailMoodAnalysis counts a failed mood analysis, the journal stays queued until it
+// runs out of attempts
+func (r *journalRepository) FailMoodAnalysis(id int) error {
+	query := `
+		UPDATE journals
+		SET mood_analysis_attempts = mood_analysis_attempts + 1
+		WHERE journal_id = $1
+	`
+
+	_, err := r.db.Exec(query, id)
+	return err
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+type llmUsageRepository struct {
+	db *sql.DB
+}
+
+// LLMUsageRepository interface
+type LLMUsageRepository interface {
+	Record(userID int, usageDate time.Time, feature, model string, promptTokens, completionTokens int) error
+	GetUserUsage(userID int, usageDate time.Time) ([]*domain.LLMUsageRow, error)
+	GetDailyUsage(startDate, endDate time.Time) ([]*domain.LLMUsageRow, error)
+	GetTopUsers(startDate, endDate time.Time, limit int) ([]*domain.LLMUserUsage, map[int][]*domain.LLMUsageRow, error)
+}
+
+// NewLLMUsageRepository creates a new LLM usage repository
+func NewLLMUsageRepository(db *sql.DB) LLMUsageRepository {
+	return &llmUsageRepository{
+		db: db,
+	}
+}
+
+// Record adds one request and its tokens to the user's row for the day
+func (r *llmUsageRepository) Record(userID int, usageDate time.Time, feature, model string, promptTokens, completionTokens int) error {
+	query := `
+		INSERT INTO llm_usage_daily (user_id, usage_date, feature, model, requests, prompt_tokens, completion_tokens, updated_at)
+		VALUES ($1, $2, $3, $4, 1, $5, $6, $7)
+		ON CONFLICT (user_id, usage_date, feature, model) DO UPDATE SET
+			requests = llm_usage_daily.requests + 1,
+			prompt_tokens = llm_usage_daily.prompt_tokens + EXCLUDED.prompt_tokens,
+			completion_tokens = llm_usage_daily.completion_tokens + EXCLUDED.completion_tokens,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.Exec(query, userID, usageDate.Format("2006-01-02"), feature, model, promptTokens, completionTokens, time.Now())
+	return err
+}
+
+// GetUserUsage returns the user's usage per feature and model on one day
+func (r *llmUsageRepository) GetUserUsage(userID int, usageDate time.Time) ([]*domain.LLMUsageRow, error) {
+	query := `
+		SELECT usage_date, feature, model, requests, prompt_tokens, completion_tokens
+		FROM llm_usage_daily
+		WHERE user_id = $1 AND usage_date = $2
+	`
+
+	rows, err := r.db.Query(query, userID, usageDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLLMUsageRows(rows)
+}
+
+// GetDailyUsage sums all users per day, feature and model between two dates, both inclusive
+func (r *llmUsageRepository) GetDailyUsage(startDate, endDate time.Time) ([]*domain.LLMUsageRow, error) {
+	query := `
+		SELECT usage_date, feature, model, SUM(requests), SUM(prompt_tokens), SUM(completion_tokens)
+		FROM llm_usage_daily
+		WHERE usage_date BETWEEN $1 AND $2
+		GROUP BY usage_date, feature, model
+		ORDER BY usage_date, feature, model
+	`
+
+	rows, err := r.db.Query(query, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLLMUsageRows(rows)
+}
+
+// scanLLMUsageRows reads rows of usage_date, feature, model, requests, prompt_tokens, completion_tokens
+func scanLLMUsageRows(rows *sql.Rows) ([]*domain.LLMUsageRow, error) {
+	var usage []*domain.LLMUsageRow
+	for rows.Next() {
+		var row domain.LLMUsageRow
+		err := rows.Scan(&row.UsageDate, &row.Feature, &row.Model, &row.Requests, &row.PromptTokens, &row.CompletionTokens)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, &row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// GetTopUsers returns the users with the most tokens between two dates, most first, and
+// their usage per model so the caller can price it
+func (r *llmUsageRepository) GetTopUsers(startDate, endDate time.Time, limit int) ([]*domain.LLMUserUsage, map[int][]*domain.LLMUsageRow, error) {
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `
+		WITH top_users AS (
+			SELECT user_id, SUM(prompt_tokens + completion_tokens) AS tokens
+			FROM llm_usage_daily
+			WHERE usage_date BETWEEN $1 AND $2
+			GROUP BY user_id
+			ORDER BY tokens DESC
+			LIMIT $3
+		)
+		SELECT t.user_id, u.email, u.user_type, t.tokens, l.model,
+			SUM(l.requests), SUM(l.prompt_tokens), SUM(l.completion_tokens)
+		FROM top_users t
+		JOIN users u ON u.user_id = t.user_id
+		JOIN llm_usage_daily l ON l.user_id = t.user_id AND l.usage_date BETWEEN $1 AND $2
+		GROUP BY t.user_id, u.email, u.user_type, t.tokens, l.model
+		ORDER BY t.tokens DESC, t.user_id, l.model
+	`
+
+	rows, err := r.db.Query(query, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.LLMUserUsage
+	byModel := make(map[int][]*domain.LLMUsageRow)
+	for rows.Next() {
+		var user domain.LLMUserUsage
+		var row domain.LLMUsageRow
+		var tokens int
+		err := rows.Scan(&user.UserID, &user.Email, &user.UserType, &tokens, &row.Model,
+			&row.Requests, &row.PromptTokens, &row.CompletionTokens)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Rows of one user arrive together
+		if len(users) == 0 || users[len(users)-1].UserID != user.UserID {
+			users = append(users, &user)
+		}
+		current := users[len(users)-1]
+		current.Requests += row.Requests
+		current.PromptTokens += row.PromptTokens
+		current.CompletionTokens += row.CompletionTokens
+		byModel[user.UserID] = append(byModel[user.UserID], &row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return users, byModel, nil
+}
@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -16,9 +18,24 @@ type moodRepository struct {
 type MoodRepository interface {
 	Create(entry *domain.MoodEntry) (*domain.MoodEntry, error)
 	GetByID(id int, userID int) (*domain.MoodEntry, error)
-	GetByUserID(userID int, limit, offset int, startDate, endDate time.Time, entryType string) ([]*domain.MoodEntry, int, error)
+	GetByUserID(userID int, limit, offset int, startDate, endDate time.Time, entryType, source string) ([]*domain.MoodEntry, int, error)
+	Update(entry *domain.MoodEntry) error
+	Delete(id int, userID int) error
+	GetAuditByEntryID(entryID, userID int) ([]*domain.MoodEntryAudit, error)
+	GetAverages(userID int, bucket, timezone string, startDate, endDate time.Time) ([]*domain.MoodAverage, error)
+	GetEmotionDistribution(userID int, startDate, endDate time.Time) ([]*domain.EmotionCount, error)
+	GetWeekdayAverages(userID int, timezone string, startDate, endDate time.Time) ([]*domain.WeekdayAverage, error)
+	GetStreak(userID int, timezone string) (*domain.MoodStreak, error)
+	GetUserIDsWithEntriesSince(since time.Time) ([]int, error)
+	HasEntriesSince(userID int, since time.Time) (bool, error)
+	GetByFacialSessionID(userID int, sessionID string) (*domain.MoodEntry, error)
+	UpdateFacialAggregate(entry *domain.MoodEntry) error
+	GetSourceAverages(userID int, timezone string, startDate, endDate time.Time) ([]*domain.MoodAverage, error)
 }
 
+// NoLimit can be passed as the limit of GetByUserID to return every matching entry
+const NoLimit = -1
+
 // NewMoodRepository creates a new mood repository
 func NewMoodRepository(db *sql.DB) MoodRepository {
 	return &moodRepository{
@@ -26,11 +43,41 @@ func NewMoodRepository(db *sql.DB) MoodRepository {
 	}
 }
 
+// moodEntryColumns reads entries without a journal as JournalID 0, and writes turn 0 back
+// into NULL with NULLIF, so entries logged without a journal can be loaded and updated
+const moodEntryColumns = `entry_id, user_id, COALESCE(journal_id, 0), entry_type, recorded_at, primary_emotion,
+	intensity_level, trigger_factor, coping_strategy, source, COALESCE(facial_session_id, '')`
+
+// scanMoodEntry reads one row selected with moodEntryColumns
+func scanMoodEntry(scan func(dest ...interface{}) error) (*domain.MoodEntry, error) {
+	var entry domain.MoodEntry
+	err := scan(
+		&entry.ID,
+		&entry.UserID,
+		&entry.JournalID,
+		&entry.EntryType,
+		&entry.RecordedAt,
+		&entry.PrimaryEmotion,
+		&entry.IntensityLevel,
+		&entry.TriggerFactor,
+		&entry.CopingStrategy,
+		&entry.Source,
+		&entry.FacialSessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
 func (r *moodRepository) Create(entry *domain.MoodEntry) (*domain.MoodEntry, error) {
 	now := time.Now()
+	if !entry.RecordedAt.IsZero() {
+		now = entry.RecordedAt // Keep the original time for backfilled entries
+	}
 	query := `
-		INSERT INTO mood_entries (user_id, journal_id, entry_type, recorded_at, primary_emotion, intensity_level, trigger_factor, coping_strategy)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO mood_entries (user_id, journal_id, entry_type, recorded_at, primary_emotion, intensity_level, trigger_factor, coping_strategy, source, facial_session_id)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
 		RETURNING entry_id
 	`
 
@@ -44,6 +91,8 @@ func (r *moodRepository) Create(entry *domain.MoodEntry) (*domain.MoodEntry, err
 		entry.IntensityLevel,
 		entry.TriggerFactor,
 		entry.CopingStrategy,
+		entry.Source,
+		entry.FacialSessionID,
 	).Scan(&entry.ID)
 
 	if err != nil {
@@ -55,27 +104,13 @@ func (r *moodRepository) Create(entry *domain.MoodEntry) (*domain.MoodEntry, err
 }
 
 func (r *moodRepository) GetByID(id int, userID int) (*domain.MoodEntry, error) {
-	var entry domain.MoodEntry
-
 	query := `
-		SELECT entry_id, user_id, journal_id, entry_type, recorded_at, primary_emotion, intensity_level, trigger_factor, coping_strategy
+		SELECT ` + moodEntryColumns + `
 		FROM mood_entries
 		WHERE entry_id = $1 AND user_id = $2
 	`
 
-	row := r.db.QueryRow(query, id, userID)
-	err := row.Scan(
-		&entry.ID,
-		&entry.UserID,
-		&entry.JournalID,
-		&entry.EntryType,
-		&entry.RecordedAt,
-		&entry.PrimaryEmotion,
-		&entry.IntensityLevel,
-		&entry.TriggerFactor,
-		&entry.CopingStrategy,
-	)
-
+	entry, err := scanMoodEntry(r.db.QueryRow(query, id, userID).Scan)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Entry not found
@@ -83,40 +118,42 @@ func (r *moodRepository) GetByID(id int, userID int) (*domain.MoodEntry, error)
 		return nil, err
 	}
 
-	return &entry, nil
+	return entry, nil
 }
 
-func (r *moodRepository) GetByUserID(userID int, limit, offset int, startDate, endDate time.Time, entryType string) ([]*domain.MoodEntry, int, error) {
-	// Get total count
-	countQuery := `
-		SELECT COUNT(*)
-		FROM mood_entries
-		WHERE user_id = $1
-	`
-
+func (r *moodRepository) GetByUserID(userID int, limit, offset int, startDate, endDate time.Time, entryType, source string) ([]*domain.MoodEntry, int, error) {
+	// Build the filters once so the count and the page use the same placeholders
+	conditions := ""
 	args := []interface{}{userID}
 	argIndex := 2
 
-	// Add filters if provided
-	hasStartDate := !startDate.IsZero()
-	hasEndDate := !endDate.IsZero()
-	hasEntryType := entryType != ""
-
-	if hasStartDate {
-		countQuery += " AND recorded_at >= $" + strconv.Itoa(argIndex)
+	if !startDate.IsZero() {
+		conditions += " AND recorded_at >= $" + strconv.Itoa(argIndex)
 		args = append(args, startDate)
 		argIndex++
 	}
-	if hasEndDate {
-		countQuery += " AND recorded_at <= $" + strconv.Itoa(argIndex)
+	if !endDate.IsZero() {
+		conditions += " AND recorded_at <= $" + strconv.Itoa(argIndex)
 		args = append(args, endDate)
 		argIndex++
 	}
-	if hasEntryType {
-		countQuery += " AND entry_type = $" + strconv.Itoa(argIndex)
+	if entryType != "" {
+		conditions += " AND entry_type = $" + strconv.Itoa(argIndex)
 		args = append(args, entryType)
 		argIndex++
 	}
+	if source != "" {
+		conditions += " AND source = $" + strconv.Itoa(argIndex)
+		args = append(args, source)
+		argIndex++
+	}
+
+	// Get total count
+	countQuery := `
+		SELECT COUNT(*)
+		FROM mood_entries
+		WHERE user_id = $1
+	` + conditions
 
 	var totalCount int
 	err := r.db.QueryRow(countQuery, args...).Scan(&totalCount)
@@ -125,29 +162,21 @@ func (r *moodRepository) GetByUserID(userID int, limit, offset int, startDate, e
 	}
 
 	// Get paginated results
-	if limit <= 0 {
+	if limit == 0 {
 		limit = 10 // Default limit
 	}
 
 	query := `
-		SELECT entry_id, user_id, journal_id, entry_type, recorded_at, primary_emotion, intensity_level, trigger_factor, coping_strategy
+		SELECT ` + moodEntryColumns + `
 		FROM mood_entries
 		WHERE user_id = $1
-	`
+	` + conditions
 
-	// Add filters if provided
-	if hasStartDate {
-		query += " AND recorded_at >= $" + strconv.Itoa(argIndex-argIndex+2)
-	}
-	if hasEndDate {
-		query += " AND recorded_at <= $" + strconv.Itoa(argIndex-argIndex+3)
+	query += " ORDER BY recorded_at DESC"
+	if limit != NoLimit {
+		query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+		args = append(args, limit, offset)
 	}
-	if hasEntryType {
-		query += " AND entry_type = $" + strconv.Itoa(argIndex-argIndex+4)
-	}
-
-	query += " ORDER BY recorded_at DESC LIMIT $" + strconv.Itoa(argIndex-argIndex+5) + " OFFSET $" + strconv.Itoa(argIndex-argIndex+6)
-	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
@@ -157,22 +186,11 @@ func (r *moodRepository) GetByUserID(userID int, limit, offset int, startDate, e
 
 	var entries []*domain.MoodEntry
 	for rows.Next() {
-		var entry domain.MoodEntry
-		err := rows.Scan(
-			&entry.ID,
-			&entry.UserID,
-			&entry.JournalID,
-			&entry.EntryType,
-			&entry.RecordedAt,
-			&entry.PrimaryEmotion,
-			&entry.IntensityLevel,
-			&entry.TriggerFactor,
-			&entry.CopingStrategy,
-		)
+		entry, err := scanMoodEntry(rows.Scan)
 		if err != nil {
 			return nil, 0, err
 		}
-		entries = append(entries, &entry)
+		entries = append(entries, entry)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -181,3 +199,393 @@ func (r *moodRepository) GetByUserID(userID int, limit, offset int, startDate, e
 
 	return entries, totalCount, nil
 }
+
+// Update saves the entry and writes its previous state to the audit trail in one transaction.
+// Returns sql.ErrNoRows when the entry doesn't exist or belongs to someone else.
+func (r *moodRepository) Update(entry *domain.MoodEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	previous, err := getMoodEntryForUpdate(tx, entry.ID, entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE mood_entries
+		SET journal_id = NULLIF($3, 0), entry_type = $4, recorded_at = $5, primary_emotion = $6,
+			intensity_level = $7, trigger_factor = $8, coping_strategy = $9
+		WHERE entry_id = $1 AND user_id = $2
+	`
+
+	_, err = tx.Exec(
+		query,
+		entry.ID,
+		entry.UserID,
+		entry.JournalID,
+		entry.EntryType,
+		entry.RecordedAt,
+		entry.PrimaryEmotion,
+		entry.IntensityLevel,
+		entry.TriggerFactor,
+		entry.CopingStrategy,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertMoodEntryAudit(tx, "update", previous, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the entry and keeps a copy of it in the audit trail
+func (r *moodRepository) Delete(id int, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	previous, err := getMoodEntryForUpdate(tx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mood_entries WHERE entry_id = $1 AND user_id = $2`, id, userID); err != nil {
+		return err
+	}
+
+	if err := insertMoodEntryAudit(tx, "delete", previous, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getMoodEntryForUpdate loads and locks an entry inside a transaction
+func getMoodEntryForUpdate(tx *sql.Tx, id int, userID int) (*domain.MoodEntry, error) {
+	query := `
+		SELECT ` + moodEntryColumns + `
+		FROM mood_entries
+		WHERE entry_id = $1 AND user_id = $2
+		FOR UPDATE
+	`
+
+	return scanMoodEntry(tx.QueryRow(query, id, userID).Scan)
+}
+
+func insertMoodEntryAudit(tx *sql.Tx, action string, previous, current *domain.MoodEntry) error {
+	oldValues, err := json.Marshal(previous)
+	if err != nil {
+		return err
+	}
+
+	var newValues interface{} // Stays NULL for deletes
+	if current != nil {
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		newValues = data
+	}
+
+	query := `
+		INSERT INTO mood_entry_audit (entry_id, user_id, action, old_values, new_values, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err = tx.Exec(query, previous.ID, previous.UserID, action, oldValues, newValues, time.Now())
+	return err
+}
+
+// GetAuditByEntryID returns an entry's edit history, oldest first
+func (r *moodRepository) GetAuditByEntryID(entryID, userID int) ([]*domain.MoodEntryAudit, error) {
+	query := `
+		SELECT audit_id, entry_id, user_id, action, old_values, new_values, created_at
+		FROM mood_entry_audit
+		WHERE entry_id = $1 AND user_id = $2
+		ORDER BY created_at, audit_id
+	`
+
+	rows, err := r.db.Query(query, entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audits []*domain.MoodEntryAudit
+	for rows.Next() {
+		var audit domain.MoodEntryAudit
+		var oldValues, newValues []byte
+		err := rows.Scan(
+			&audit.ID,
+			&audit.EntryID,
+			&audit.UserID,
+			&audit.Action,
+			&oldValues,
+			&newValues,
+			&audit.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(oldValues, &audit.OldValues); err != nil {
+			return nil, err
+		}
+		if newValues != nil {
+			if err := json.Unmarshal(newValues, &audit.NewValues); err != nil {
+				return nil, err
+			}
+		}
+		audits = append(audits, &audit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return audits, nil
+}
+
+// GetAverages averages intensity per day, week (starting Monday) or month. Buckets follow the
+// given IANA timezone; startDate is inclusive and endDate exclusive.
+func (r *moodRepository) GetAverages(userID int, bucket, timezone string, startDate, endDate time.Time) ([]*domain.MoodAverage, error) {
+	switch bucket {
+	case "day", "week", "month":
+	default:
+		return nil, fmt.Errorf("invalid bucket: %s", bucket)
+	}
+
+	query := `
+		SELECT to_char(date_trunc($2, recorded_at AT TIME ZONE $3), 'YYYY-MM-DD') AS period,
+			COALESCE(AVG(intensity_level), 0),
+			COUNT(*)
+		FROM mood_entries
+		WHERE user_id = $1 AND recorded_at >= $4 AND recorded_at < $5
+		GROUP BY period
+		ORDER BY period
+	`
+
+	rows, err := r.db.Query(query, userID, bucket, timezone, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	averages := []*domain.MoodAverage{}
+	for rows.Next() {
+		var average domain.MoodAverage
+		if err := rows.Scan(&average.Period, &average.AverageIntensity, &average.EntryCount); err != nil {
+			return nil, err
+		}
+		averages = append(averages, &average)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return averages, nil
+}
+
+// GetEmotionDistribution counts entries per primary emotion, most frequent first
+func (r *moodRepository) GetEmotionDistribution(userID int, startDate, endDate time.Time) ([]*domain.EmotionCount, error) {
+	query := `
+		SELECT LOWER(primary_emotion) AS emotion, COUNT(*), COALESCE(AVG(intensity_level), 0)
+		FROM mood_entries
+		WHERE user_id = $1 AND recorded_at >= $2 AND recorded_at < $3
+		GROUP BY emotion
+		ORDER BY COUNT(*) DESC, emotion
+	`
+
+	rows, err := r.db.Query(query, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*domain.EmotionCount{}
+	for rows.Next() {
+		var count domain.EmotionCount
+		if err := rows.Scan(&count.Emotion, &count.Count, &count.AverageIntensity); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetWeekdayAverages averages intensity per ISO weekday in the given timezone.
+// Weekdays without entries are left out.
+func (r *moodRepository) GetWeekdayAverages(userID int, timezone string, startDate, endDate time.Time) ([]*domain.WeekdayAverage, error) {
+	query := `
+		SELECT EXTRACT(ISODOW FROM recorded_at AT TIME ZONE $2)::int AS weekday,
+			COALESCE(AVG(intensity_level), 0),
+			COUNT(*)
+		FROM mood_entries
+		WHERE user_id = $1 AND recorded_at >= $3 AND recorded_at < $4
+		GROUP BY weekday
+		ORDER BY weekday
+	`
+
+	rows, err := r.db.Query(query, userID, timezone, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	averages := []*domain.WeekdayAverage{}
+	for rows.Next() {
+		var average domain.WeekdayAverage
+		if err := rows.Scan(&average.Weekday, &average.AverageIntensity, &average.EntryCount); err != nil {
+			return nil, err
+		}
+		averages = append(averages, &average)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return averages, nil
+}
+
+// GetStreak finds runs of consecutive local days with entries over the user's whole history
+func (r *moodRepository) GetStreak(userID int, timezone string) (*domain.MoodStreak, error) {
+	query := `
+		WITH days AS (
+			SELECT DISTINCT (recorded_at AT TIME ZONE $2)::date AS day
+			FROM mood_entries
+			WHERE user_id = $1
+		), runs AS (
+			SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS run
+			FROM days
+		), streaks AS (
+			SELECT MAX(day) AS last_day, COUNT(*) AS length
+			FROM runs
+			GROUP BY run
+		)
+		SELECT COALESCE(MAX(length), 0),
+			COALESCE(MAX(length) FILTER (WHERE last_day >= (now() AT TIME ZONE $2)::date - 1), 0),
+			COALESCE(to_char(MAX(last_day), 'YYYY-MM-DD'), '')
+		FROM streaks
+	`
+
+	var streak domain.MoodStreak
+	err := r.db.QueryRow(query, userID, timezone).Scan(&streak.Longest, &streak.Current, &streak.LastEntryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streak, nil
+}
+
+// GetUserIDsWithEntriesSince lists users who recorded at least one mood since the given time
+func (r *moodRepository) GetUserIDsWithEntriesSince(since time.Time) ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT user_id FROM mood_entries WHERE recorded_at >= $1 ORDER BY user_id`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// HasEntriesSince reports whether the user recorded a mood since the given time
+func (r *moodRepository) HasEntriesSince(userID int, since time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM mood_entries WHERE user_id = $1 AND recorded_at >= $2)`, userID, since).Scan(&exists)
+	return exists, err
+}
+
+func (r *moodRepository) GetByFacialSessionID(userID int, sessionID string) (*domain.MoodEntry, error) {
+	query := `SELECT ` + moodEntryColumns + ` FROM mood_entries WHERE user_id = $1 AND facial_session_id = $2`
+
+	entry, err := scanMoodEntry(r.db.QueryRow(query, userID, sessionID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// UpdateFacialAggregate stores a facial session's new aggregate. It is the system
+// re-aggregating a later batch, not a user edit, so nothing goes into the audit trail.
+func (r *moodRepository) UpdateFacialAggregate(entry *domain.MoodEntry) error {
+	query := `
+		UPDATE mood_entries
+		SET recorded_at = $3, primary_emotion = $4, intensity_level = $5
+		WHERE entry_id = $1 AND user_id = $2 AND facial_session_id IS NOT NULL
+	`
+
+	result, err := r.db.Exec(query, entry.ID, entry.UserID, entry.RecordedAt, entry.PrimaryEmotion, entry.IntensityLevel)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetSourceAverages returns daily averages per source, ordered by source and day
+func (r *moodRepository) GetSourceAverages(userID int, timezone string, startDate, endDate time.Time) ([]*domain.MoodAverage, error) {
+	query := `
+		SELECT source,
+			to_char(date_trunc('day', recorded_at AT TIME ZONE $2), 'YYYY-MM-DD') AS period,
+			COALESCE(AVG(intensity_level), 0),
+			COUNT(*)
+		FROM mood_entries
+		WHERE user_id = $1 AND recorded_at >= $3 AND recorded_at < $4
+		GROUP BY source, period
+		ORDER BY source, period
+	`
+
+	rows, err := r.db.Query(query, userID, timezone, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	averages := []*domain.MoodAverage{}
+	for rows.Next() {
+		var average domain.MoodAverage
+		if err := rows.Scan(&average.Source, &average.Period, &average.AverageIntensity, &average.EntryCount); err != nil {
+			return nil, err
+		}
+		averages = append(averages, &average)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return averages, nil
+}
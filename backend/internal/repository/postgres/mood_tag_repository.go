@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+type moodTagRepository struct {
+	db *sql.DB
+}
+
+// MoodTagRepository interface
+type MoodTagRepository interface {
+	SetEntryTags(entryID, userID int, tags []*domain.MoodTag) error
+	GetTagsByUserID(userID int, kind string) ([]*domain.MoodTag, error)
+	GetLowMoodBaseline(userID int, since time.Time, lowThreshold float64) (int, int, error)
+	GetTriggerInsights(userID int, since time.Time, lowThreshold float64, windowHours int) ([]*domain.TriggerInsight, error)
+	GetCopingInsights(userID int, since time.Time, minImprovement float64, windowHours int) ([]*domain.CopingInsight, error)
+}
+
+// NewMoodTagRepository creates a new mood tag repository
+func NewMoodTagRepository(db *sql.DB) MoodTagRepository {
+	return &moodTagRepository{
+		db: db,
+	}
+}
+
+// SetEntryTags replaces the tags of an entry, creating any tag the user doesn't have yet
+func (r *moodTagRepository) SetEntryTags(entryID, userID int, tags []*domain.MoodTag) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mood_entry_tags WHERE entry_id = $1`, entryID); err != nil {
+		return err
+	}
+
+	// The no-op update makes RETURNING work for tags that already exist
+	upsertQuery := `
+		INSERT INTO mood_tags (user_id, kind, name, display_name, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, kind, name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING tag_id
+	`
+
+	now := time.Now()
+	for _, tag := range tags {
+		err := tx.QueryRow(upsertQuery, userID, tag.Kind, tag.Name, tag.DisplayName, now).Scan(&tag.ID)
+		if err != nil {
+			return err
+		}
+		tag.UserID = userID
+
+		_, err = tx.Exec(`INSERT INTO mood_entry_tags (entry_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, entryID, tag.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTagsByUserID lists the user's tags with how many entries use them, most used first
+func (r *moodTagRepository) GetTagsByUserID(userID int, kind string) ([]*domain.MoodTag, error) {
+	query := `
+		SELECT t.tag_id, t.user_id, t.kind, t.name, t.display_name, COUNT(et.entry_id)
+		FROM mood_tags t
+		LEFT JOIN mood_entry_tags et ON et.tag_id = t.tag_id
+		WHERE t.user_id = $1 AND ($2 = '' OR t.kind = $2)
+		GROUP BY t.tag_id
+		ORDER BY COUNT(et.entry_id) DESC, t.name
+	`
+
+	rows, err := r.db.Query(query, userID, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*domain.MoodTag
+	for rows.Next() {
+		var tag domain.MoodTag
+		err := rows.Scan(
+			&tag.ID,
+			&tag.UserID,
+			&tag.Kind,
+			&tag.Name,
+			&tag.DisplayName,
+			&tag.UsageCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetLowMoodBaseline returns how many entries the user recorded since the given time and
+// how many of them were below the low mood threshold
+func (r *moodTagRepository) GetLowMoodBaseline(userID int, since time.Time, lowThreshold float64) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE intensity_level < $3)
+		FROM mood_entries
+		WHERE user_id = $1 AND recorded_at >= $2
+	`
+
+	var total, low int
+	err := r.db.QueryRow(query, userID, since, lowThreshold).Scan(&total, &low)
+	return total, low, err
+}
+
+// GetTriggerInsights takes, for every entry tagged with a trigger, the lowest mood recorded
+// from that entry until windowHours later, and aggregates those per trigger
+func (r *moodTagRepository) GetTriggerInsights(userID int, since time.Time, lowThreshold float64, windowHours int) ([]*domain.TriggerInsight, error) {
+	query := `
+		SELECT t.tag_id, t.display_name,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE outcome.lowest < $3),
+			COALESCE(AVG(e.intensity_level), 0)
+		FROM mood_tags t
+		JOIN mood_entry_tags et ON et.tag_id = t.tag_id
+		JOIN mood_entries e ON e.entry_id = et.entry_id
+		CROSS JOIN LATERAL (
+			SELECT MIN(f.intensity_level) AS lowest
+			FROM mood_entries f
+			WHERE f.user_id = e.user_id
+				AND f.recorded_at >= e.recorded_at
+				AND f.recorded_at <= e.recorded_at + $4 * INTERVAL '1 hour'
+		) outcome
+		WHERE t.user_id = $1 AND t.kind = 'trigger' AND e.recorded_at >= $2
+		GROUP BY t.tag_id, t.display_name
+		ORDER BY COUNT(*) FILTER (WHERE outcome.lowest < $3)::float / COUNT(*) DESC, COUNT(*) DESC
+	`
+
+	rows, err := r.db.Query(query, userID, since, lowThreshold, windowHours)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	insights := []*domain.TriggerInsight{}
+	for rows.Next() {
+		var insight domain.TriggerInsight
+		err := rows.Scan(
+			&insight.TagID,
+			&insight.Tag,
+			&insight.SampleCount,
+			&insight.LowMoodCount,
+			&insight.AverageIntensity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		insights = append(insights, &insight)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return insights, nil
+}
+
+// GetCopingInsights compares, for every entry tagged with a coping strategy, its intensity with
+// the average of the entries recorded in the following windowHours. A change of at least
+// minImprovement counts as improved.
+func (r *moodTagRepository) GetCopingInsights(userID int, since time.Time, minImprovement float64, windowHours int) ([]*domain.CopingInsight, error) {
+	query := `
+		SELECT t.tag_id, t.display_name,
+			COUNT(*),
+			COUNT(follow_up.average),
+			COUNT(*) FILTER (WHERE follow_up.average - e.intensity_level >= $3),
+			COALESCE(AVG(follow_up.average - e.intensity_level), 0)
+		FROM mood_tags t
+		JOIN mood_entry_tags et ON et.tag_id = t.tag_id
+		JOIN mood_entries e ON e.entry_id = et.entry_id
+		CROSS JOIN LATERAL (
+			SELECT AVG(n.intensity_level) AS average
+			FROM mood_entries n
+			WHERE n.user_id = e.user_id
+				AND n.recorded_at > e.recorded_at
+				AND n.recorded_at <= e.recorded_at + $4 * INTERVAL '1 hour'
+		) follow_up
+		WHERE t.user_id = $1 AND t.kind = 'coping' AND e.recorded_at >= $2
+		GROUP BY t.tag_id, t.display_name
+		ORDER BY COALESCE(AVG(follow_up.average - e.intensity_level), 0) DESC, COUNT(*) DESC
+	`
+
+	rows, err := r.db.Query(query, userID, since, minImprovement, windowHours)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	insights := []*domain.CopingInsight{}
+	for rows.Next() {
+		var insight domain.CopingInsight
+		err := rows.Scan(
+			&insight.TagID,
+			&insight.Tag,
+			&insight.UsageCount,
+			&insight.SampleCount,
+			&insight.ImprovedCount,
+			&insight.AverageChange,
+		)
+		if err != nil {
+			return nil, err
+		}
+		insights = append(insights, &insight)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return insights, nil
+}
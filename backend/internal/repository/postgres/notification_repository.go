@@ -0,0 +1,374 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+// NotificationRepository interface
+type NotificationRepository interface {
+	GetSchedule(userID int) (*domain.ReminderSchedule, error)
+	UpsertSchedule(schedule *domain.ReminderSchedule) (*domain.ReminderSchedule, error)
+	GetEnabledSchedules() ([]*domain.ReminderSchedule, error)
+	ClaimReminder(userID int, localDate time.Time) (bool, error)
+	CreateNotification(notification *domain.Notification) (*domain.Notification, error)
+	GetNotificationsByUserID(userID int, unreadOnly bool, limit, offset int) ([]*domain.Notification, int, int, error)
+	MarkNotificationRead(id, userID int) error
+	MarkAllNotificationsRead(userID int) (int, error)
+	SavePushSubscription(subscription *domain.PushSubscription) (*domain.PushSubscription, error)
+	GetPushSubscriptionsByUserID(userID int) ([]*domain.PushSubscription, error)
+	DeletePushSubscription(id, userID int) error
+	DeletePushSubscriptionByEndpoint(endpoint string) error
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+const reminderScheduleColumns = `user_id, enabled, timezone, to_char(reminder_time, 'HH24:MI'),
+	to_char(quiet_hours_start, 'HH24:MI'), to_char(quiet_hours_end, 'HH24:MI'), channels, last_sent_on, created_at, updated_at`
+
+// scanReminderSchedule reads one row selected with reminderScheduleColumns
+func scanReminderSchedule(scan func(dest ...interface{}) error) (*domain.ReminderSchedule, error) {
+	var schedule domain.ReminderSchedule
+	var quietStart, quietEnd sql.NullString
+	var lastSentOn sql.NullTime
+
+	err := scan(
+		&schedule.UserID,
+		&schedule.Enabled,
+		&schedule.Timezone,
+		&schedule.ReminderTime,
+		&quietStart,
+		&quietEnd,
+		pq.Array(&schedule.Channels),
+		&lastSentOn,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if quietStart.Valid && quietEnd.Valid {
+		schedule.QuietHoursStart = &quietStart.String
+		schedule.QuietHoursEnd = &quietEnd.String
+	}
+	if lastSentOn.Valid {
+		schedule.LastSentOn = &lastSentOn.Time
+	}
+
+	return &schedule, nil
+}
+
+func (r *notificationRepository) GetSchedule(userID int) (*domain.ReminderSchedule, error) {
+	query := `SELECT ` + reminderScheduleColumns + ` FROM reminder_schedules WHERE user_id = $1`
+
+	schedule, err := scanReminderSchedule(r.db.QueryRow(query, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return schedule, err
+}
+
+// UpsertSchedule creates or replaces the user's schedule. last_sent_on is kept so
+// changing the time doesn't send a second reminder on the same day.
+func (r *notificationRepository) UpsertSchedule(schedule *domain.ReminderSchedule) (*domain.ReminderSchedule, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO reminder_schedules (user_id, enabled, timezone, reminder_time, quiet_hours_start, quiet_hours_end, channels, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			timezone = EXCLUDED.timezone,
+			reminder_time = EXCLUDED.reminder_time,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			channels = EXCLUDED.channels,
+			updated_at = EXCLUDED.updated_at
+		RETURNING ` + reminderScheduleColumns
+
+	return scanReminderSchedule(r.db.QueryRow(
+		query,
+		schedule.UserID,
+		schedule.Enabled,
+		schedule.Timezone,
+		schedule.ReminderTime,
+		schedule.QuietHoursStart,
+		schedule.QuietHoursEnd,
+		pq.Array(schedule.Channels),
+		now,
+	).Scan)
+}
+
+func (r *notificationRepository) GetEnabledSchedules() ([]*domain.ReminderSchedule, error) {
+	query := `SELECT ` + reminderScheduleColumns + ` FROM reminder_schedules WHERE enabled = TRUE ORDER BY user_id`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*domain.ReminderSchedule
+	for rows.Next() {
+		schedule, err := scanReminderSchedule(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// ClaimReminder marks the reminder for the user's local date as sent. It returns false
+// when it was already claimed, so a reminder is never sent twice on the same day.
+func (r *notificationRepository) ClaimReminder(userID int, localDate time.Time) (bool, error) {
+	query := `
+		UPDATE reminder_schedules
+		SET last_sent_on = $2
+		WHERE user_id = $1 AND (last_sent_on IS NULL OR last_sent_on < $2)
+	`
+
+	result, err := r.db.Exec(query, userID, localDate.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *notificationRepository) CreateNotification(notification *domain.Notification) (*domain.Notification, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO notifications (user_id, kind, title, body, link, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING notification_id
+	`
+
+	err := r.db.QueryRow(
+		query,
+		notification.UserID,
+		notification.Kind,
+		notification.Title,
+		notification.Body,
+		notification.Link,
+		now,
+	).Scan(&notification.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notification.CreatedAt = now
+	return notification, nil
+}
+
+// GetNotificationsByUserID returns a page of the inbox, the total count and the unread count
+func (r *notificationRepository) GetNotificationsByUserID(userID int, unreadOnly bool, limit, offset int) ([]*domain.Notification, int, int, error) {
+	var total, unread int
+	countQuery := `
+		SELECT COUNT(*) FILTER (WHERE NOT $2 OR read_at IS NULL), COUNT(*) FILTER (WHERE read_at IS NULL)
+		FROM notifications
+		WHERE user_id = $1
+	`
+	if err := r.db.QueryRow(countQuery, userID, unreadOnly).Scan(&total, &unread); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `
+		SELECT notification_id, user_id, kind, title, body, COALESCE(link, ''), read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.Query(query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		var notification domain.Notification
+		var readAt sql.NullTime
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Kind,
+			&notification.Title,
+			&notification.Body,
+			&notification.Link,
+			&readAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return notifications, total, unread, nil
+}
+
+func (r *notificationRepository) MarkNotificationRead(id, userID int) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $3)
+		WHERE notification_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(query, id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) MarkAllNotificationsRead(userID int) (int, error) {
+	result, err := r.db.Exec(`UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`, userID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// SavePushSubscription stores a subscription, moving the endpoint to this user if the
+// browser was subscribed under another account before
+func (r *notificationRepository) SavePushSubscription(subscription *domain.PushSubscription) (*domain.PushSubscription, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (endpoint) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth
+		RETURNING subscription_id, created_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		subscription.UserID,
+		subscription.Endpoint,
+		subscription.P256dh,
+		subscription.Auth,
+		now,
+	).Scan(&subscription.ID, &subscription.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (r *notificationRepository) GetPushSubscriptionsByUserID(userID int) ([]*domain.PushSubscription, error) {
+	query := `
+		SELECT subscription_id, user_id, endpoint, p256dh, auth, created_at
+		FROM push_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*domain.PushSubscription
+	for rows.Next() {
+		var subscription domain.PushSubscription
+		err := rows.Scan(
+			&subscription.ID,
+			&subscription.UserID,
+			&subscription.Endpoint,
+			&subscription.P256dh,
+			&subscription.Auth,
+			&subscription.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (r *notificationRepository) DeletePushSubscription(id, userID int) error {
+	result, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE subscription_id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeletePushSubscriptionByEndpoint removes a subscription the push service reported as gone
+func (r *notificationRepository) DeletePushSubscriptionByEndpoint(endpoint string) error {
+	_, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint)
+	return err
+}
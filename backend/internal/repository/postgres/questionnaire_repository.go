@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"warasin/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type questionnaireRepository struct {
+	db *sql.DB
+}
+
+// QuestionnaireRepository interface
+type QuestionnaireRepository interface {
+	GetActive() ([]*domain.Questionnaire, error)
+	GetLatestByCode(code string) (*domain.Questionnaire, error)
+	GetByID(id int) (*domain.Questionnaire, error)
+	CreateResponse(response *domain.QuestionnaireResponse) (*domain.QuestionnaireResponse, error)
+	GetResponsesByUserID(userID int, code string, limit, offset int) ([]*domain.QuestionnaireResponse, int, error)
+	GetScoresByUserID(userID int, code string, since time.Time) ([]*domain.QuestionnaireScore, error)
+}
+
+// NewQuestionnaireRepository creates a new questionnaire repository
+func NewQuestionnaireRepository(db *sql.DB) QuestionnaireRepository {
+	return &questionnaireRepository{
+		db: db,
+	}
+}
+
+const questionnaireColumns = `questionnaire_id, code, version, title_id, title_en, instructions_id, instructions_en, definition, is_active, created_at`
+
+// scanQuestionnaire reads one row selected with questionnaireColumns
+func scanQuestionnaire(scan func(dest ...interface{}) error) (*domain.Questionnaire, error) {
+	var questionnaire domain.Questionnaire
+	var definition []byte
+
+	err := scan(
+		&questionnaire.ID,
+		&questionnaire.Code,
+		&questionnaire.Version,
+		&questionnaire.TitleID,
+		&questionnaire.TitleEN,
+		&questionnaire.InstructionsID,
+		&questionnaire.InstructionsEN,
+		&definition,
+		&questionnaire.IsActive,
+		&questionnaire.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(definition, &questionnaire.Definition); err != nil {
+		return nil, err
+	}
+
+	return &questionnaire, nil
+}
+
+// GetActive returns the latest active version of every instrument
+func (r *questionnaireRepository) GetActive() ([]*domain.Questionnaire, error) {
+	query := `
+		SELECT DISTINCT ON (code) ` + questionnaireColumns + `
+		FROM questionnaires
+		WHERE is_active = TRUE
+		ORDER BY code, version DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questionnaires []*domain.Questionnaire
+	for rows.Next() {
+		questionnaire, err := scanQuestionnaire(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		questionnaires = append(questionnaires, questionnaire)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return questionnaires, nil
+}
+
+func (r *questionnaireRepository) GetLatestByCode(code string) (*domain.Questionnaire, error) {
+	query := `
+		SELECT ` + questionnaireColumns + `
+		FROM questionnaires
+		WHERE code = $1 AND is_active = TRUE
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	questionnaire, err := scanQuestionnaire(r.db.QueryRow(query, code).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return questionnaire, err
+}
+
+// GetByID also returns inactive versions, old responses still refer to them
+func (r *questionnaireRepository) GetByID(id int) (*domain.Questionnaire, error) {
+	query := `SELECT ` + questionnaireColumns + ` FROM questionnaires WHERE questionnaire_id = $1`
+
+	questionnaire, err := scanQuestionnaire(r.db.QueryRow(query, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return questionnaire, err
+}
+
+func (r *questionnaireRepository) CreateResponse(response *domain.QuestionnaireResponse) (*domain.QuestionnaireResponse, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO questionnaire_responses (user_id, questionnaire_id, answers, total_score, severity, safety_flagged, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING response_id
+	`
+
+	answers := make([]int64, len(response.Answers))
+	for i, answer := range response.Answers {
+		answers[i] = int64(answer)
+	}
+
+	err := r.db.QueryRow(
+		query,
+		response.UserID,
+		response.QuestionnaireID,
+		pq.Array(answers),
+		response.TotalScore,
+		response.Severity,
+		response.SafetyFlagged,
+		now,
+	).Scan(&response.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response.CreatedAt = now
+	return response, nil
+}
+
+func (r *questionnaireRepository) GetResponsesByUserID(userID int, code string, limit, offset int) ([]*domain.QuestionnaireResponse, int, error) {
+	conditions := ""
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if code != "" {
+		conditions += " AND q.code = $" + strconv.Itoa(argIndex)
+		args = append(args, code)
+		argIndex++
+	}
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM questionnaire_responses r
+		JOIN questionnaires q ON q.questionnaire_id = r.questionnaire_id
+		WHERE r.user_id = $1
+	` + conditions
+
+	var totalCount int
+	if err := r.db.QueryRow(countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `
+		SELECT r.response_id, r.user_id, r.questionnaire_id, q.code, q.version, r.answers, r.total_score, r.severity, r.safety_flagged, r.created_at
+		FROM questionnaire_responses r
+		JOIN questionnaires q ON q.questionnaire_id = r.questionnaire_id
+		WHERE r.user_id = $1
+	` + conditions + " ORDER BY r.created_at DESC LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var responses []*domain.QuestionnaireResponse
+	for rows.Next() {
+		var response domain.QuestionnaireResponse
+		var answers []int64
+		err := rows.Scan(
+			&response.ID,
+			&response.UserID,
+			&response.QuestionnaireID,
+			&response.Code,
+			&response.Version,
+			pq.Array(&answers),
+			&response.TotalScore,
+			&response.Severity,
+			&response.SafetyFlagged,
+			&response.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		response.Answers = make([]int, len(answers))
+		for i, answer := range answers {
+			response.Answers[i] = int(answer)
+		}
+		responses = append(responses, &response)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return responses, totalCount, nil
+}
+
+// GetScoresByUserID returns the scores for one instrument since the given time, oldest first
+func (r *questionnaireRepository) GetScoresByUserID(userID int, code string, since time.Time) ([]*domain.QuestionnaireScore, error) {
+	query := `
+		SELECT r.response_id, q.version, r.total_score, r.severity, r.created_at
+		FROM questionnaire_responses r
+		JOIN questionnaires q ON q.questionnaire_id = r.questionnaire_id
+		WHERE r.user_id = $1 AND q.code = $2 AND r.created_at >= $3
+		ORDER BY r.created_at
+	`
+
+	rows, err := r.db.Query(query, userID, code, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := []*domain.QuestionnaireScore{}
+	for rows.Next() {
+		var score domain.QuestionnaireScore
+		err := rows.Scan(
+			&score.ResponseID,
+			&score.Version,
+			&score.TotalScore,
+			&score.Severity,
+			&score.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, &score)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return scores, nil
+}
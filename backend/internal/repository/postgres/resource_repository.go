@@ -3,8 +3,12 @@ package postgres
 import (
 	"database/sql"
 	"strconv"
+	"strings"
+	"unicode"
 
 	"warasin/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 type resourceRepository struct {
@@ -20,6 +24,7 @@ type ResourceRepository interface {
 	GetInteractionsByResourceID(resourceID int, limit, offset int) ([]*domain.ResourceInteraction, int, error)
 	GetInteractionByUserAndResource(userID, resourceID int) (*domain.ResourceInteraction, error)
 	UpdateInteraction(interaction *domain.ResourceInteraction) error
+	Search(terms []string, language string, includePremium bool, minMatchedTerms, limit int) ([]*domain.ResourceMatch, error)
 }
 
 // NewResourceRepository creates a new resource repository
@@ -289,3 +294,79 @@ func (r *resourceRepository) UpdateInteraction(interaction *domain.ResourceInter
 
 	return nil
 }
+
+// Search finds resources whose title or content contains any of the terms, also as the start
+// of a longer word. Resources matching more terms come first, then those in language, then
+// the best ranked. Premium resources are only included with includePremium.
+func (r *resourceRepository) Search(terms []string, language string, includePremium bool, minMatchedTerms, limit int) ([]*domain.ResourceMatch, error) {
+	// Terms go into tsquery syntax, so only letters and digits are kept
+	var prefixes []string
+	for _, term := range terms {
+		term = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, term)
+		if term != "" {
+			prefixes = append(prefixes, term+":*")
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		WITH q AS (SELECT to_tsquery('simple', array_to_string($1::TEXT[], ' | ')) AS query)
+		SELECT r.resource_id, r.title, COALESCE(r.content_type, ''), COALESCE(r.language, ''),
+			ts_headline('simple', COALESCE(r.content, ''), q.query, 'MaxWords=50, MinWords=20, MaxFragments=2'),
+			m.matched, ts_rank(r.search_vector, q.query, 32) AS rank
+		FROM resources r
+		CROSS JOIN q
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS matched
+			FROM unnest($1::TEXT[]) AS term
+			WHERE r.search_vector @@ to_tsquery('simple', term)
+		) m
+		WHERE r.search_vector @@ q.query
+			AND ($5 OR r.user_type IS DISTINCT FROM 'premium')
+			AND m.matched >= $2
+		ORDER BY m.matched DESC, (r.language = $3) DESC, rank DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(query, pq.Array(prefixes), minMatchedTerms, language, limit, includePremium)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// ts_headline marks the matched words with <b>, the excerpt goes into a prompt as plain text
+	unmark := strings.NewReplacer("<b>", "", "</b>", "")
+
+	var matches []*domain.ResourceMatch
+	for rows.Next() {
+		var match domain.ResourceMatch
+		err := rows.Scan(
+			&match.ResourceID,
+			&match.Title,
+			&match.ContentType,
+			&match.Language,
+			&match.Excerpt,
+			&match.MatchedTerms,
+			&match.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		match.Excerpt = unmark.Replace(match.Excerpt)
+		matches = append(matches, &match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,340 @@
+package postgres
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+type wellbeingRepository struct {
+	db *sql.DB
+}
+
+// WellbeingRepository interface
+type WellbeingRepository interface {
+	CreateAlert(alert *domain.WellbeingAlert) (*domain.WellbeingAlert, error)
+	GetLatestAlert(userID int) (*domain.WellbeingAlert, error)
+	GetAlertByID(id, userID int) (*domain.WellbeingAlert, error)
+	GetAlertsByUserID(userID int, status string, limit, offset int) ([]*domain.WellbeingAlert, int, error)
+	UpdateAlertStatus(id, userID int, status string) error
+	MarkAlertShared(id, userID, contactID int) error
+	CreateContact(contact *domain.TrustedContact) (*domain.TrustedContact, error)
+	GetContactByID(id, userID int) (*domain.TrustedContact, error)
+	GetContactsByUserID(userID int) ([]*domain.TrustedContact, error)
+	DeleteContact(id, userID int) error
+}
+
+// NewWellbeingRepository creates a new wellbeing repository
+func NewWellbeingRepository(db *sql.DB) WellbeingRepository {
+	return &wellbeingRepository{
+		db: db,
+	}
+}
+
+const wellbeingAlertColumns = `alert_id, user_id, alert_type, severity, recent_average, baseline_average,
+	window_start, window_end, status, shared_contact_id, shared_at, created_at, updated_at`
+
+// scanWellbeingAlert reads one row selected with wellbeingAlertColumns
+func scanWellbeingAlert(scan func(dest ...interface{}) error) (*domain.WellbeingAlert, error) {
+	var alert domain.WellbeingAlert
+	var baseline sql.NullFloat64
+	var sharedContactID sql.NullInt64
+	var sharedAt sql.NullTime
+
+	err := scan(
+		&alert.ID,
+		&alert.UserID,
+		&alert.AlertType,
+		&alert.Severity,
+		&alert.RecentAverage,
+		&baseline,
+		&alert.WindowStart,
+		&alert.WindowEnd,
+		&alert.Status,
+		&sharedContactID,
+		&sharedAt,
+		&alert.CreatedAt,
+		&alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseline.Valid {
+		alert.BaselineAverage = &baseline.Float64
+	}
+	alert.SharedContactID = nullIntPtr(sharedContactID)
+	if sharedAt.Valid {
+		alert.SharedAt = &sharedAt.Time
+	}
+
+	return &alert, nil
+}
+
+func (r *wellbeingRepository) CreateAlert(alert *domain.WellbeingAlert) (*domain.WellbeingAlert, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO wellbeing_alerts (user_id, alert_type, severity, recent_average, baseline_average, window_start, window_end, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'active', $8, $8)
+		RETURNING alert_id
+	`
+
+	err := r.db.QueryRow(
+		query,
+		alert.UserID,
+		alert.AlertType,
+		alert.Severity,
+		alert.RecentAverage,
+		alert.BaselineAverage,
+		alert.WindowStart,
+		alert.WindowEnd,
+		now,
+	).Scan(&alert.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	alert.Status = "active"
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+	return alert, nil
+}
+
+// GetLatestAlert returns the user's most recent alert, whatever its status
+func (r *wellbeingRepository) GetLatestAlert(userID int) (*domain.WellbeingAlert, error) {
+	query := `SELECT ` + wellbeingAlertColumns + `
+		FROM wellbeing_alerts
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	alert, err := scanWellbeingAlert(r.db.QueryRow(query, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return alert, err
+}
+
+func (r *wellbeingRepository) GetAlertByID(id, userID int) (*domain.WellbeingAlert, error) {
+	query := `SELECT ` + wellbeingAlertColumns + `
+		FROM wellbeing_alerts
+		WHERE alert_id = $1 AND user_id = $2
+	`
+
+	alert, err := scanWellbeingAlert(r.db.QueryRow(query, id, userID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return alert, err
+}
+
+func (r *wellbeingRepository) GetAlertsByUserID(userID int, status string, limit, offset int) ([]*domain.WellbeingAlert, int, error) {
+	conditions := ""
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if status != "" {
+		conditions += " AND status = $" + strconv.Itoa(argIndex)
+		args = append(args, status)
+		argIndex++
+	}
+
+	var totalCount int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM wellbeing_alerts WHERE user_id = $1`+conditions, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 10 // Default limit
+	}
+
+	query := `SELECT ` + wellbeingAlertColumns + `
+		FROM wellbeing_alerts
+		WHERE user_id = $1` + conditions +
+		" ORDER BY created_at DESC LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var alerts []*domain.WellbeingAlert
+	for rows.Next() {
+		alert, err := scanWellbeingAlert(rows.Scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return alerts, totalCount, nil
+}
+
+func (r *wellbeingRepository) UpdateAlertStatus(id, userID int, status string) error {
+	query := `
+		UPDATE wellbeing_alerts
+		SET status = $3, updated_at = $4
+		WHERE alert_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(query, id, userID, status, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *wellbeingRepository) MarkAlertShared(id, userID, contactID int) error {
+	now := time.Now()
+	query := `
+		UPDATE wellbeing_alerts
+		SET shared_contact_id = $3, shared_at = $4, updated_at = $4
+		WHERE alert_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(query, id, userID, contactID, now)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *wellbeingRepository) CreateContact(contact *domain.TrustedContact) (*domain.TrustedContact, error) {
+	now := time.Now()
+	query := `
+		INSERT INTO trusted_contacts (user_id, name, relationship, email, phone, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+		RETURNING contact_id
+	`
+
+	err := r.db.QueryRow(
+		query,
+		contact.UserID,
+		contact.Name,
+		contact.Relationship,
+		contact.Email,
+		contact.Phone,
+		now,
+	).Scan(&contact.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	contact.CreatedAt = now
+	return contact, nil
+}
+
+func (r *wellbeingRepository) GetContactByID(id, userID int) (*domain.TrustedContact, error) {
+	var contact domain.TrustedContact
+
+	query := `
+		SELECT contact_id, user_id, name, COALESCE(relationship, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at
+		FROM trusted_contacts
+		WHERE contact_id = $1 AND user_id = $2
+	`
+
+	err := r.db.QueryRow(query, id, userID).Scan(
+		&contact.ID,
+		&contact.UserID,
+		&contact.Name,
+		&contact.Relationship,
+		&contact.Email,
+		&contact.Phone,
+		&contact.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+func (r *wellbeingRepository) GetContactsByUserID(userID int) ([]*domain.TrustedContact, error) {
+	query := `
+		SELECT contact_id, user_id, name, COALESCE(relationship, ''), COALESCE(email, ''), COALESCE(phone, ''), created_at
+		FROM trusted_contacts
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []*domain.TrustedContact
+	for rows.Next() {
+		var contact domain.TrustedContact
+		err := rows.Scan(
+			&contact.ID,
+			&contact.UserID,
+			&contact.Name,
+			&contact.Relationship,
+			&contact.Email,
+			&contact.Phone,
+			&contact.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, &contact)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+func (r *wellbeingRepository) DeleteContact(id, userID int) error {
+	result, err := r.db.Exec(`DELETE FROM trusted_contacts WHERE contact_id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Ratings of a bot message
+const (
+	ChatRatingUp   = "up"
+	ChatRatingDown = "down"
+)
+
+// ErrChatFeedbackNotFound is returned when the user hasn't rated the message
+var ErrChatFeedbackNotFound = errors.New("feedback not found")
+
+// Reasons the user can pick per rating, so they can be counted in the report
+var chatFeedbackReasons = map[string]map[string]bool{
+	ChatRatingUp: {
+		"helpful":    true,
+		"empathetic": true,
+		"actionable": true,
+		"clear":      true,
+		"other":      true,
+	},
+	ChatRatingDown: {
+		"not_helpful":     true,
+		"inaccurate":      true,
+		"insensitive":     true,
+		"unsafe":          true,
+		"too_generic":     true,
+		"too_long":        true,
+		"ignored_context": true,
+		"other":           true,
+	},
+}
+
+const (
+	chatFeedbackMaxCommentChars = 1000
+	// Most exchanges in one export
+	chatFeedbackExportLimit = 1000
+)
+
+// ChatFeedbackInput is a rating of a bot message
+type ChatFeedbackInput struct {
+	Rating  string   `json:"rating" binding:"required"` // up, down
+	Reasons []string `json:"reasons"`
+	Comment string   `json:"comment"`
+	// Lets the exchange be exported for reviewing the prompt, only kept on thumbs down
+	ShareForReview bool `json:"share_for_review"`
+}
+
+type chatFeedbackUsecase struct {
+	feedbackRepo postgres.ChatFeedbackRepository
+	chatRepo     postgres.ChatRepository
+}
+
+// ChatFeedbackUsecase interface
+type ChatFeedbackUsecase interface {
+	SubmitFeedback(userID, messageID int, input ChatFeedbackInput) (*domain.ChatMessageFeedback, error)
+	DeleteFeedback(userID, messageID int) error
+	GetSessionFeedback(userID, sessionID int) ([]*domain.ChatMessageFeedback, error)
+	GetReport(startDate, endDate string) (*domain.ChatFeedbackReport, error)
+	ExportNegative(startDate, endDate string) ([]*domain.ChatFeedbackExchange, error)
+}
+
+// NewChatFeedbackUsecase creates a new chat feedback use case
+func NewChatFeedbackUsecase(feedbackRepo postgres.ChatFeedbackRepository, chatRepo postgres.ChatRepository) ChatFeedbackUsecase {
+	return &chatFeedbackUsecase{
+		feedbackRepo: feedbackRepo,
+		chatRepo:     chatRepo,
+	}
+}
+
+// SubmitFeedback rates one of the bot's messages in the user's sessions, replacing an
+// earlier rating of it
+func (u *chatFeedbackUsecase) SubmitFeedback(userID, messageID int, input ChatFeedbackInput) (*domain.ChatMessageFeedback, error) {
+	allowed, ok := chatFeedbackReasons[input.Rating]
+	if !ok {
+		return nil, errors.New("rating must be up or down")
+	}
+
+	reasons := []string{}
+	seen := make(map[string]bool)
+	for _, reason := range input.Reasons {
+		reason = strings.TrimSpace(reason)
+		if !allowed[reason] {
+			return nil, fmt.Errorf("invalid reason %q for a thumbs %s", reason, input.Rating)
+		}
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+
+	comment := strings.TrimSpace(input.Comment)
+	if utf8.RuneCountInString(comment) > chatFeedbackMaxCommentChars {
+		return nil, fmt.Errorf("comment must be at most %d characters", chatFeedbackMaxCommentChars)
+	}
+
+	if err := u.checkBotMessage(userID, messageID); err != nil {
+		return nil, err
+	}
+
+	return u.feedbackRepo.Upsert(&domain.ChatMessageFeedback{
+		MessageID:      messageID,
+		UserID:         userID,
+		Rating:         input.Rating,
+		Reasons:        reasons,
+		Comment:        comment,
+		ShareForReview: input.ShareForReview && input.Rating == ChatRatingDown,
+	})
+}
+
+// checkBotMessage makes sure the message is a bot answer in one of the user's sessions
+func (u *chatFeedbackUsecase) checkBotMessage(userID, messageID int) error {
+	message, err := u.chatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+	if message == nil {
+		return ErrChatMessageNotFound
+	}
+
+	session, err := u.chatRepo.GetSessionByID(message.SessionID, userID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return ErrChatMessageNotFound
+	}
+
+	if message.SenderType != "bot" {
+		return errors.New("only bot messages can be rated")
+	}
+	return nil
+}
+
+// DeleteFeedback removes the rating, which also withdraws consent to share the exchange
+func (u *chatFeedbackUsecase) DeleteFeedback(userID, messageID int) error {
+	err := u.feedbackRepo.Delete(messageID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrChatFeedbackNotFound
+	}
+	return err
+}
+
+// GetSessionFeedback returns the user's ratings in one session, to show them next to the messages
+func (u *chatFeedbackUsecase) GetSessionFeedback(userID, sessionID int) ([]*domain.ChatMessageFeedback, error) {
+	session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrChatSessionNotFound
+	}
+
+	feedback, err := u.feedbackRepo.GetBySessionID(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if feedback == nil {
+		feedback = []*domain.ChatMessageFeedback{}
+	}
+	return feedback, nil
+}
+
+// GetReport counts ratings per prompt version and model between two dates (YYYY-MM-DD,
+// both inclusive). Without dates it covers the last 30 days.
+func (u *chatFeedbackUsecase) GetReport(startDateStr, endDateStr string) (*domain.ChatFeedbackReport, error) {
+	startDate, endDate, err := reportDateRange(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.feedbackRepo.GetReport(startDate, endDate.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []*domain.ChatFeedbackReportRow{}
+	}
+
+	for _, row := range rows {
+		if row.Ratings > 0 {
+			row.DownRate = math.Round(float64(row.Down)/float64(row.Ratings)*1000) / 1000
+		}
+	}
+
+	return &domain.ChatFeedbackReport{
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Rows:      rows,
+	}, nil
+}
+
+// ExportNegative returns the thumbs down exchanges rated between two dates that their users
+// agreed to share, newest first and without who the users are
+func (u *chatFeedbackUsecase) ExportNegative(startDateStr, endDateStr string) ([]*domain.ChatFeedbackExchange, error) {
+	startDate, endDate, err := reportDateRange(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.feedbackRepo.GetSharedNegative(startDate, endDate.AddDate(0, 0, 1), chatFeedbackExportLimit)
+}
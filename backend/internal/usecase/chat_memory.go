@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"warasin/internal/domain"
+)
+
+const (
+	// Rough characters per token. Tokenizers differ per model and Indonesian splits into
+	// more tokens than English, so the estimate errs on the high side.
+	charsPerToken = 3
+	// Added per message for role markers and separators
+	messageTokenOverhead = 4
+	// Most unsummarized messages loaded for one prompt
+	chatHistoryMaxMessages = 200
+
+	chatSummaryMaxTokens = 400
+	chatSummaryTimeout   = 60 * time.Second
+	// Longer messages are cut when they are summarized
+	chatSummaryMessageCharLimit = 2000
+)
+
+const chatSummaryPrompt = `You keep a running summary of a conversation between a user and MindCareBot, a mental health support companion. Update the summary with the new messages below.
+- Keep what the user shared about themselves: feelings, situations, people, goals, and the coping strategies discussed and whether they helped
+- Write in the third person about "the user", in the language of the conversation, in at most 200 words
+- Don't add advice or anything that wasn't said
+
+Current summary:
+"""
+%s
+"""
+
+New messages:
+"""
+%s
+"""
+
+Reply with the updated summary only.`
+
+// estimateTokens approximates the tokens a message takes up in a prompt
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text)+charsPerToken-1)/charsPerToken + messageTokenOverhead
+}
+
+// sessionHistory returns the session summary and as many of the latest messages as fit in budget
+func (u *chatUsecase) sessionHistory(sessionID, budget int) ([]LLMMessage, error) {
+	summary, err := u.chatRepo.GetSummary(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	afterID := 0
+	var history []LLMMessage
+	used := 0
+	if summary != nil {
+		afterID = summary.SummarizedUntilID
+		content := "Summary of the earlier conversation in this session:\n" + summary.Summary
+		if tokens := estimateTokens(content); tokens <= budget {
+			history = append(history, LLMMessage{Role: LLMRoleSystem, Content: content})
+			used = tokens
+		}
+	}
+
+	recent, err := u.chatRepo.GetMessagesAfterID(sessionID, afterID, chatHistoryMaxMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages come newest first, take them until the budget runs out
+	var turns []LLMMessage
+	for _, message := range recent {
+		tokens := estimateTokens(message.MessageContent)
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+
+		role := LLMRoleUser
+		if message.SenderType == "bot" {
+			role = LLMRoleAssistant
+		}
+		turns = append(turns, LLMMessage{Role: role, Content: message.MessageContent})
+	}
+
+	for i := len(turns) - 1; i >= 0; i-- {
+		history = append(history, turns[i])
+	}
+
+	return history, nil
+}
+
+// summarizeInBackground updates the session summary after a reply, so the next message
+// has its older history folded in. Only one update per session runs at a time.
+func (u *chatUsecase) summarizeInBackground(userID, sessionID int) {
+	if sessionID <= 0 {
+		return
+	}
+	if _, running := u.summarizing.LoadOrStore(sessionID, true); running {
+		return
+	}
+
+	go func() {
+		defer u.summarizing.Delete(sessionID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), chatSummaryTimeout)
+		defer cancel()
+
+		if err := u.updateSummary(ctx, userID, sessionID); err != nil {
+			log.Printf("ERROR: Failed to summarize chat session %d: %v", sessionID, err)
+		}
+	}()
+}
+
+// updateSummary folds the oldest unsummarized messages into the summary once the history
+// no longer fits the budget. The newest messages filling half the budget stay verbatim.
+func (u *chatUsecase) updateSummary(ctx context.Context, userID, sessionID int) error {
+	summary, err := u.chatRepo.GetSummary(sessionID)
+	if err != nil {
+		return err
+	}
+
+	previous := "(none yet)"
+	afterID, messageCount, used := 0, 0, 0
+	if summary != nil {
+		previous = summary.Summary
+		afterID = summary.SummarizedUntilID
+		messageCount = summary.MessageCount
+		used = estimateTokens(summary.Summary)
+	}
+
+	recent, err := u.chatRepo.GetMessagesAfterID(sessionID, afterID, chatHistoryMaxMessages)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range recent {
+		used += estimateTokens(message.MessageContent)
+	}
+	if used <= u.historyTokenBudget {
+		return nil
+	}
+
+	keep, kept := 0, 0
+	for _, message := range recent {
+		tokens := estimateTokens(message.MessageContent)
+		if kept+tokens > u.historyTokenBudget/2 {
+			break
+		}
+		kept += tokens
+		keep++
+	}
+	fold := recent[keep:]
+	if len(fold) == 0 {
+		return nil
+	}
+
+	// Oldest first, and only as much as fits in one summarization prompt. Anything left
+	// over is folded in after the next reply.
+	available := u.contextTokens - chatSummaryMaxTokens - estimateTokens(chatSummaryPrompt) - estimateTokens(previous)
+	var transcript strings.Builder
+	folded, untilID := 0, 0
+	for i := len(fold) - 1; i >= 0; i-- {
+		speaker := "User"
+		if fold[i].SenderType == "bot" {
+			speaker = "MindCareBot"
+		}
+		line := fmt.Sprintf("%s: %s\n", speaker, truncateRunes(fold[i].MessageContent, chatSummaryMessageCharLimit, "..."))
+
+		tokens := estimateTokens(line)
+		if tokens > available && folded > 0 {
+			break
+		}
+		available -= tokens
+		transcript.WriteString(line)
+		folded++
+		untilID = fold[i].ID
+	}
+
+	resp, err := generateFromPrompt(ctx, u.llm, fmt.Sprintf(chatSummaryPrompt, previous, transcript.String()), chatSummaryMaxTokens, false)
+	if err != nil {
+		return err
+	}
+	u.usage.Record(userID, LLMFeatureChatSummary, resp)
+
+	_, err = u.chatRepo.UpsertSummary(&domain.ChatSessionSummary{
+		SessionID:         sessionID,
+		Summary:           resp.Text,
+		SummarizedUntilID: untilID,
+		MessageCount:      messageCount + folded,
+		Model:             resp.Model,
+	})
+	return err
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Parts of the personal context, stored with each log entry
+const (
+	ChatContextMoodTrend      = "mood_trend"
+	ChatContextTriggers       = "triggers"
+	ChatContextCoping         = "coping"
+	ChatContextJournalSummary = "journal_summary"
+)
+
+const (
+	// The trend compares the last window of days with the window before it
+	chatContextTrendDays    = 14
+	chatContextTrendMinDays = 3
+	// Average change between the windows that counts as improving or declining
+	chatContextTrendDelta = 0.05
+	chatContextTagLimit   = 3
+	chatContextTagChars   = 40
+	// Coping strategies are only mentioned when they helped at least this often
+	chatContextMinImprovementRate = 0.5
+	// Journal summaries older than this are left out
+	chatContextJournalMaxAge   = 30 * 24 * time.Hour
+	chatContextJournalChars    = 600
+	chatContextLogDefaultLimit = 20
+)
+
+const chatContextHeader = `Personal context the user chose to share from their WarasIn records. Use it only when it is relevant to what they say, bring it up gently, don't recite it, and never treat it as a diagnosis. Text in quotes was written by the user.`
+
+type chatPersonalizationUsecase struct {
+	personalizationRepo postgres.ChatPersonalizationRepository
+	moodRepo            postgres.MoodRepository
+	journalAIRepo       postgres.JournalAIRepository
+	notificationRepo    postgres.NotificationRepository
+	moodUsecase         MoodUsecase
+}
+
+// ChatPersonalizationUsecase interface
+type ChatPersonalizationUsecase interface {
+	GetSettings(userID int) (*domain.ChatPersonalizationSettings, error)
+	UpdateSettings(userID int, enabled, includeJournals bool) (*domain.ChatPersonalizationSettings, error)
+	Revoke(userID int, deleteLog bool) (*domain.ChatPersonalizationSettings, error)
+	Preview(userID int, includeJournals bool) (*domain.ChatContextLog, error)
+	BuildContext(userID int) (*domain.ChatContextLog, error)
+	LogContext(entry *domain.ChatContextLog)
+	GetLog(userID int, limit, offset int) ([]*domain.ChatContextLog, int, error)
+	DeleteLog(userID int) (int, error)
+}
+
+// NewChatPersonalizationUsecase creates a new chat personalization use case
+func NewChatPersonalizationUsecase(personalizationRepo postgres.ChatPersonalizationRepository, moodRepo postgres.MoodRepository, journalAIRepo postgres.JournalAIRepository, notificationRepo postgres.NotificationRepository, moodUsecase MoodUsecase) ChatPersonalizationUsecase {
+	return &chatPersonalizationUsecase{
+		personalizationRepo: personalizationRepo,
+		moodRepo:            moodRepo,
+		journalAIRepo:       journalAIRepo,
+		notificationRepo:    notificationRepo,
+		moodUsecase:         moodUsecase,
+	}
+}
+
+// GetSettings returns the user's consent, off until they turn it on
+func (u *chatPersonalizationUsecase) GetSettings(userID int) (*domain.ChatPersonalizationSettings, error) {
+	settings, err := u.personalizationRepo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return &domain.ChatPersonalizationSettings{UserID: userID}, nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings gives or revokes consent. Journal summaries are only shared on top of
+// the mood context, never on their own.
+func (u *chatPersonalizationUsecase) UpdateSettings(userID int, enabled, includeJournals bool) (*domain.ChatPersonalizationSettings, error) {
+	return u.personalizationRepo.UpsertSettings(&domain.ChatPersonalizationSettings{
+		UserID:          userID,
+		Enabled:         enabled,
+		IncludeJournals: enabled && includeJournals,
+	})
+}
+
+// Revoke turns personalization off, and with deleteLog also removes the record of what was sent
+func (u *chatPersonalizationUsecase) Revoke(userID int, deleteLog bool) (*domain.ChatPersonalizationSettings, error) {
+	settings, err := u.UpdateSettings(userID, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleteLog {
+		if _, err := u.personalizationRepo.DeleteLogs(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+// Preview builds the context from the user's current records without sending or logging it,
+// so they can see what they would share before consenting
+func (u *chatPersonalizationUsecase) Preview(userID int, includeJournals bool) (*domain.ChatContextLog, error) {
+	entry, err := u.build(userID, includeJournals)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return &domain.ChatContextLog{UserID: userID, Sources: []string{}}, nil
+	}
+	return entry, nil
+}
+
+// BuildContext returns the context block for the user's next prompt, nil without consent
+// or without anything to share
+func (u *chatPersonalizationUsecase) BuildContext(userID int) (*domain.ChatContextLog, error) {
+	settings, err := u.personalizationRepo.GetSettings(userID)
+	if err != nil || settings == nil || !settings.Enabled {
+		return nil, err
+	}
+
+	return u.build(userID, settings.IncludeJournals)
+}
+
+// LogContext records a block that was sent to the model. A failed write is only logged,
+// the chat goes on.
+func (u *chatPersonalizationUsecase) LogContext(entry *domain.ChatContextLog) {
+	if _, err := u.personalizationRepo.CreateLog(entry); err != nil {
+		log.Printf("ERROR: Failed to log chat context for user %d: %v", entry.UserID, err)
+	}
+}
+
+func (u *chatPersonalizationUsecase) GetLog(userID int, limit, offset int) ([]*domain.ChatContextLog, int, error) {
+	if limit <= 0 || limit > 100 {
+		limit = chatContextLogDefaultLimit
+	}
+	return u.personalizationRepo.GetLogs(userID, limit, offset)
+}
+
+func (u *chatPersonalizationUsecase) DeleteLog(userID int) (int, error) {
+	return u.personalizationRepo.DeleteLogs(userID)
+}
+
+// build puts together the mood trend, the triggers that come with low moods, the coping
+// strategies that helped and optionally the latest journal summary
+func (u *chatPersonalizationUsecase) build(userID int, includeJournals bool) (*domain.ChatContextLog, error) {
+	var lines, sources []string
+
+	trend, err := u.moodTrend(userID)
+	if err != nil {
+		return nil, err
+	}
+	if trend != "" {
+		lines = append(lines, trend)
+		sources = append(sources, ChatContextMoodTrend)
+	}
+
+	insights, err := u.moodUsecase.GetTagInsights(userID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var triggers []string
+	for _, trigger := range insights.Triggers {
+		if len(triggers) == chatContextTagLimit {
+			break
+		}
+		if trigger.LowMoodCount == 0 || trigger.SampleCount < 2 {
+			continue
+		}
+		triggers = append(triggers, fmt.Sprintf("%s (low mood in %d of %d entries)", quoteContextText(trigger.Tag, chatContextTagChars), trigger.LowMoodCount, trigger.SampleCount))
+	}
+	if len(triggers) > 0 {
+		lines = append(lines, "Triggers that often come with a low mood: "+strings.Join(triggers, ", "))
+		sources = append(sources, ChatContextTriggers)
+	}
+
+	var coping []string
+	for _, strategy := range insights.CopingStrategies {
+		if len(coping) == chatContextTagLimit {
+			break
+		}
+		if strategy.ImprovedCount == 0 || strategy.ImprovementRate < chatContextMinImprovementRate {
+			continue
+		}
+		coping = append(coping, fmt.Sprintf("%s (mood improved after %d of %d uses)", quoteContextText(strategy.Tag, chatContextTagChars), strategy.ImprovedCount, strategy.SampleCount))
+	}
+	if len(coping) > 0 {
+		lines = append(lines, "Coping strategies that have helped: "+strings.Join(coping, ", "))
+		sources = append(sources, ChatContextCoping)
+	}
+
+	if includeJournals {
+		summaries, _, err := u.journalAIRepo.GetSummariesByUserID(userID, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(summaries) > 0 && time.Since(summaries[0].PeriodEnd) <= chatContextJournalMaxAge {
+			summary := summaries[0]
+			lines = append(lines, fmt.Sprintf("Summary of their journal for %s to %s: %s",
+				summary.PeriodStart.Format("2 Jan"), summary.PeriodEnd.Format("2 Jan 2006"),
+				truncateRunes(strings.Join(strings.Fields(summary.Summary), " "), chatContextJournalChars, "...")))
+			sources = append(sources, ChatContextJournalSummary)
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	return &domain.ChatContextLog{
+		UserID:  userID,
+		Context: chatContextHeader + "\n- " + strings.Join(lines, "\n- "),
+		Sources: sources,
+	}, nil
+}
+
+// moodTrend describes the daily average mood of the last two weeks against the two before,
+// by the user's local days. Moods are 0 (very negative) to 1 (very positive).
+func (u *chatPersonalizationUsecase) moodTrend(userID int) (string, error) {
+	periods, err := dailyMoodPeriods(u.moodRepo, u.notificationRepo, userID, time.Now(), chatContextTrendDays, chatContextTrendDays)
+	if err != nil {
+		return "", err
+	}
+	recent, previous := periods.Recent, periods.Previous
+
+	if len(recent) < chatContextTrendMinDays {
+		return "", nil
+	}
+
+	recentAverage := mean(recent)
+	trend := fmt.Sprintf("Mood over the last %d days: average %.2f on a 0 (very negative) to 1 (very positive) scale, logged on %d days",
+		chatContextTrendDays, recentAverage, len(recent))
+	if len(previous) < chatContextTrendMinDays {
+		return trend, nil
+	}
+
+	previousAverage := mean(previous)
+	switch change := recentAverage - previousAverage; {
+	case change >= chatContextTrendDelta:
+		trend += fmt.Sprintf(", improving from %.2f the %d days before", previousAverage, chatContextTrendDays)
+	case change <= -chatContextTrendDelta:
+		trend += fmt.Sprintf(", declining from %.2f the %d days before", previousAverage, chatContextTrendDays)
+	default:
+		trend += fmt.Sprintf(", about the same as the %d days before", chatContextTrendDays)
+	}
+	return trend, nil
+}
+
+// quoteContextText quotes text the user wrote on one line, so it reads as data in the prompt
+func quoteContextText(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return `"` + strings.ReplaceAll(truncateRunes(text, limit, "..."), `"`, "'") + `"`
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"warasin/internal/domain"
+)
+
+// Longest excerpt of a resource put into the prompt
+const chatRetrievalExcerptChars = 700
+
+const chatRetrievalHeader = `Resources from the WarasIn library that match the user's message. When one fits what they need, you may suggest it by its exact title so they can open it in the app. Only suggest resources from this list, never invent titles or links, and don't copy them at length.`
+
+// retrieveResources finds library resources for the message that the user has access to
+// and returns the prompt block listing them with their citations. Resources are dropped
+// from the end until the block fits in budget tokens. Failures are logged and the answer
+// goes on without resources.
+func (u *chatUsecase) retrieveResources(message, userType string, budget int) (string, []domain.ResourceCitation) {
+	if u.retrievalLimit <= 0 {
+		return "", nil
+	}
+
+	matches, err := u.resources.Retrieve(message, userType, u.retrievalLimit)
+	if err != nil {
+		log.Printf("ERROR: Failed to retrieve resources for a chat message: %v", err)
+		return "", nil
+	}
+
+	for len(matches) > 0 {
+		var block strings.Builder
+		block.WriteString(chatRetrievalHeader)
+		for i, match := range matches {
+			contentType := match.ContentType
+			if contentType == "" {
+				contentType = "resource"
+			}
+			excerpt := truncateRunes(strings.Join(strings.Fields(match.Excerpt), " "), chatRetrievalExcerptChars, "...")
+			fmt.Fprintf(&block, "\n\n[%d] %q (%s)\n%s", i+1, match.Title, contentType, excerpt)
+		}
+
+		if estimateTokens(block.String()) <= budget {
+			citations := make([]domain.ResourceCitation, 0, len(matches))
+			for _, match := range matches {
+				citations = append(citations, domain.ResourceCitation{
+					ResourceID:  match.ResourceID,
+					Title:       match.Title,
+					ContentType: match.ContentType,
+				})
+			}
+			return block.String(), citations
+		}
+		matches = matches[:len(matches)-1]
+	}
+
+	return "", nil
+}
@@ -0,0 +1,414 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Risk levels of a chat message
+const (
+	ChatRiskNone   = "none"
+	ChatRiskMedium = "medium"
+	ChatRiskHigh   = "high"
+)
+
+const (
+	// The emergency contact is told at most once within this period
+	contactNotificationCooldown = 24 * time.Hour
+	// The model check must not hold up the reply for long, the rules still apply without it
+	safetyModelTimeout   = 5 * time.Second
+	safetyModelMaxTokens = 50
+	// Shown as the model of crisis replies, which are written by us and not generated
+	crisisResponseModel = "safety-protocol"
+)
+
+// chatSafetyRule flags messages matching pattern. Patterns run on the lowercased message
+// with whitespace collapsed, and are meant to over-match rather than miss someone.
+type chatSafetyRule struct {
+	id       string
+	language string
+	level    string
+	pattern  *regexp.Regexp
+}
+
+const (
+	idWant   = `(ingin|ingen|pengen|pengin|pingin|mau|mo|akan|niat|berniat|rencana|berencana|kepikiran|memikirkan)`
+	idSelf   = `(aku|saya|gue|gw|gua|ku)`
+	idNot    = `(tidak|tak|nggak|ngga|enggak|gak|ga)`
+	enIntend = `(want|wanna|going|gonna|plan|planning|intend|ready|decided)`
+)
+
+var chatSafetyRules = []chatSafetyRule{
+	// Indonesian
+	{"id_suicide_intent", "id", ChatRiskHigh, regexp.MustCompile(idWant + `\s+(untuk\s+)?(bunuh\s+diri|mengakhiri\s+(hidup|nyawa)|mati\s+(aja|saja)|menghilang\s+selamanya)`)},
+	{"id_want_to_die", "id", ChatRiskHigh, regexp.MustCompile(idSelf + `\s+(cuma\s+|hanya\s+)?` + idWant + `\s+mati\b`)},
+	{"id_end_life", "id", ChatRiskHigh, regexp.MustCompile(`\bmengakhiri\s+(hidup|nyawa)(ku|\s+` + idSelf + `)?\b`)},
+	{"id_self_harm", "id", ChatRiskHigh, regexp.MustCompile(`\b(melukai|menyakiti|nyakitin|ngelukain|menyiksa)\s+diri\s*(ku|sendiri)?\b`)},
+	{"id_cutting", "id", ChatRiskHigh, regexp.MustCompile(`\b(menyayat|nyayat|sayat|silet|menyilet)\s+(tangan|lengan|nadi|pergelangan|kulit)`)},
+	{"id_method", "id", ChatRiskHigh, regexp.MustCompile(`\b(gantung\s+diri|minum\s+racun|lompat\s+dari\s+(gedung|jembatan|atap|lantai))`)},
+	{"id_better_dead", "id", ChatRiskHigh, regexp.MustCompile(`\blebih\s+baik\s+` + idSelf + `\s+(mati|` + idNot + `\s+ada)\b`)},
+	{"id_no_reason_to_live", "id", ChatRiskHigh, regexp.MustCompile(`\b` + idNot + `\s+(ada\s+)?(alasan|gunanya)\s+(untuk\s+|buat\s+)?(hidup|tetap\s+hidup)\b`)},
+	{"id_suicide_mention", "id", ChatRiskMedium, regexp.MustCompile(`\bbunuh\s+diri\b`)},
+	{"id_hopeless", "id", ChatRiskMedium, regexp.MustCompile(`\b(putus\s+asa|` + idNot + `\s+ada\s+harapan(\s+lagi)?)\b`)},
+	{"id_tired_of_living", "id", ChatRiskMedium, regexp.MustCompile(`\b(capek|capai|lelah|muak)\s+(dengan\s+|sama\s+)?hidup\b`)},
+	{"id_disappear", "id", ChatRiskMedium, regexp.MustCompile(idWant + `\s+(menghilang|hilang\s+dari\s+dunia)`)},
+	{"id_overdose", "id", ChatRiskMedium, regexp.MustCompile(`\boverdosis\b`)},
+
+	// English
+	{"en_self_harm", "en", ChatRiskHigh, regexp.MustCompile(`\b(kill|killing|hurt|hurting|harm|harming|cut|cutting|burn|burning)\s+myself\b`)},
+	{"en_end_life", "en", ChatRiskHigh, regexp.MustCompile(`\b(end|ending|take|taking)\s+my\s+(own\s+)?life\b`)},
+	{"en_want_to_die", "en", ChatRiskHigh, regexp.MustCompile(`\b(want|wanna|plan|planning|intend|ready|decided)\s+(to\s+)?die\b`)},
+	{"en_suicide_intent", "en", ChatRiskHigh, regexp.MustCompile(`\b(commit(ting)?\s+suicide|suicidal|` + enIntend + `\s+(to\s+)?(commit\s+)?suicide)\b`)},
+	{"en_better_dead", "en", ChatRiskHigh, regexp.MustCompile(`\b(better\s+off\s+dead|better\s+off\s+without\s+me)\b`)},
+	{"en_no_reason_to_live", "en", ChatRiskHigh, regexp.MustCompile(`\bno\s+(reason|point)\s+(to|in)\s+(live|living|going\s+on)\b`)},
+	{"en_suicide_mention", "en", ChatRiskMedium, regexp.MustCompile(`\b(suicide|self[\s-]?harm)\b`)},
+	{"en_hopeless", "en", ChatRiskMedium, regexp.MustCompile(`\b(hopeless|no\s+hope\s+left)\b`)},
+	{"en_tired_of_living", "en", ChatRiskMedium, regexp.MustCompile(`\b(tired|sick)\s+of\s+(living|life|being\s+alive)\b`)},
+	{"en_overdose", "en", ChatRiskMedium, regexp.MustCompile(`\boverdos(e|ing)\b`)},
+}
+
+// Common words used to guess the language of a message when no rule says it
+var (
+	indonesianWords = map[string]bool{"aku": true, "saya": true, "yang": true, "tidak": true, "gak": true, "ga": true, "mau": true, "ingin": true, "dan": true, "ini": true, "itu": true, "sudah": true, "kamu": true, "banget": true, "lagi": true}
+	englishWords    = map[string]bool{"i": true, "the": true, "and": true, "to": true, "my": true, "me": true, "is": true, "am": true, "you": true, "it": true, "feel": true, "don't": true, "i'm": true, "want": true, "of": true}
+)
+
+const safetyClassifierPrompt = `You are a safety classifier for a mental health support chat. Classify the risk that the author of the message below is at risk of suicide or self-harm. The message may be in Indonesian or English.
+
+Respond with a JSON object with exactly one field "risk":
+- "high": they express intent, a plan or a wish to die or to hurt themselves, or are hurting themselves now
+- "medium": hopelessness, passive thoughts about death or self-harm, or questions about suicide or self-harm without stated intent
+- "none": anything else
+
+Message:
+"""
+%s
+"""`
+
+// Told to the model for medium risk messages, which still get a model answer
+const mediumRiskInstruction = `The user's latest message suggests they may be struggling with hopelessness or thoughts of self-harm. Respond with extra care: validate their feelings, gently ask whether they are safe right now, and mention that professional help is available in Indonesia (Layanan SEJIWA 119 ext. 8, emergency 112).`
+
+type chatSafetyUsecase struct {
+	safetyRepo    postgres.ChatSafetyRepository
+	wellbeingRepo postgres.WellbeingRepository
+	userRepo      postgres.UserRepository
+	llm           LLMProvider
+	usage         LLMUsageUsecase
+	notifiers     []Notifier
+}
+
+// ChatSafetyUsecase interface
+type ChatSafetyUsecase interface {
+	Screen(ctx context.Context, userID, sessionID int, message string) *domain.ChatSafety
+	GetSettings(userID int) (*domain.ChatSafetySettings, error)
+	UpdateSettings(userID int, emergencyContactID *int, notifyContact bool) (*domain.ChatSafetySettings, error)
+	GetEvents(riskLevel string, limit, offset int) ([]*domain.ChatSafetyEvent, int, error)
+}
+
+// NewChatSafetyUsecase creates a new chat safety use case. A nil llm disables the model
+// check and leaves classification to the keyword rules.
+func NewChatSafetyUsecase(safetyRepo postgres.ChatSafetyRepository, wellbeingRepo postgres.WellbeingRepository, userRepo postgres.UserRepository, llm LLMProvider, usage LLMUsageUsecase, notifiers []Notifier) ChatSafetyUsecase {
+	return &chatSafetyUsecase{
+		safetyRepo:    safetyRepo,
+		wellbeingRepo: wellbeingRepo,
+		userRepo:      userRepo,
+		llm:           llm,
+		usage:         usage,
+		notifiers:     notifiers,
+	}
+}
+
+// Screen classifies a chat message and returns nil when it carries no risk. Risky messages
+// are recorded as a safety event. On high risk the result holds the crisis response, and
+// the emergency contact is told when the user consented to it. Failures to record or
+// notify are logged, they never keep the crisis response from the user.
+func (u *chatSafetyUsecase) Screen(ctx context.Context, userID, sessionID int, message string) *domain.ChatSafety {
+	level, language, matched := classifyChatMessage(message)
+
+	modelLevel := ""
+	if u.llm != nil {
+		modelLevel = u.classifyWithModel(ctx, userID, message)
+		if chatRiskRank(modelLevel) > chatRiskRank(level) {
+			level = modelLevel
+		}
+	}
+
+	if level == ChatRiskNone {
+		return nil
+	}
+
+	safety := &domain.ChatSafety{
+		RiskLevel: level,
+		Hotlines:  IndonesianHotlines,
+	}
+
+	event := &domain.ChatSafetyEvent{
+		UserID:         userID,
+		RiskLevel:      level,
+		MatchedRules:   matched,
+		ModelRiskLevel: modelLevel,
+		Language:       language,
+	}
+	if sessionID > 0 {
+		event.SessionID = &sessionID
+	}
+	event, err := u.safetyRepo.CreateEvent(event)
+	if err != nil {
+		log.Printf("ERROR: Failed to record chat safety event for user %d: %v", userID, err)
+	} else {
+		safety.EventID = event.ID
+	}
+
+	if level == ChatRiskHigh {
+		contactName := ""
+		if event != nil {
+			contactName = u.notifyContact(ctx, userID, event.ID)
+		}
+		safety.ContactNotified = contactName != ""
+		safety.CrisisResponse = crisisResponse(language, contactName)
+	}
+
+	return safety
+}
+
+// classifyChatMessage runs the keyword rules and returns the highest risk level, the
+// message language and the IDs of the rules that matched
+func classifyChatMessage(message string) (string, string, []string) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(message)), " ")
+
+	level := ChatRiskNone
+	language := ""
+	var matched []string
+	for _, rule := range chatSafetyRules {
+		if !rule.pattern.MatchString(normalized) {
+			continue
+		}
+		matched = append(matched, rule.id)
+		if chatRiskRank(rule.level) > chatRiskRank(level) {
+			level = rule.level
+			language = rule.language
+		}
+	}
+
+	if language == "" {
+		language = guessLanguage(normalized)
+	}
+	return level, language, matched
+}
+
+// guessLanguage tells Indonesian from English by counting common words, Indonesian wins ties
+func guessLanguage(normalized string) string {
+	indonesian, english := 0, 0
+	for _, word := range strings.Fields(normalized) {
+		word = strings.Trim(word, ".,!?\"'()")
+		if indonesianWords[word] {
+			indonesian++
+		}
+		if englishWords[word] {
+			english++
+		}
+	}
+	if english > indonesian {
+		return "en"
+	}
+	return "id"
+}
+
+func chatRiskRank(level string) int {
+	switch level {
+	case ChatRiskHigh:
+		return 2
+	case ChatRiskMedium:
+		return 1
+	}
+	return 0
+}
+
+// classifyWithModel asks the language model for a risk level, or returns "" when it fails
+func (u *chatSafetyUsecase) classifyWithModel(ctx context.Context, userID int, message string) string {
+	ctx, cancel := context.WithTimeout(ctx, safetyModelTimeout)
+	defer cancel()
+
+	resp, err := generateFromPrompt(ctx, u.llm, fmt.Sprintf(safetyClassifierPrompt, message), safetyModelMaxTokens, true)
+	if err != nil {
+		log.Printf("WARN: Chat safety model check failed, using the rules only: %v", err)
+		return ""
+	}
+	u.usage.Record(userID, LLMFeatureSafetyCheck, resp)
+
+	var output struct {
+		Risk string `json:"risk"`
+	}
+	if err := json.Unmarshal([]byte(resp.Text), &output); err != nil {
+		log.Printf("WARN: Chat safety model returned invalid JSON: %v", err)
+		return ""
+	}
+
+	switch output.Risk {
+	case ChatRiskNone, ChatRiskMedium, ChatRiskHigh:
+		return output.Risk
+	}
+	return ""
+}
+
+// notifyContact emails the user's emergency contact when they consented to it, and returns
+// the contact's name when the email was sent
+func (u *chatSafetyUsecase) notifyContact(ctx context.Context, userID, eventID int) string {
+	settings, err := u.safetyRepo.GetSettings(userID)
+	if err != nil {
+		log.Printf("ERROR: Failed to load chat safety settings for user %d: %v", userID, err)
+		return ""
+	}
+	if settings == nil || !settings.NotifyContact || settings.EmergencyContactID == nil {
+		return ""
+	}
+
+	lastNotified, err := u.safetyRepo.GetLastContactNotification(userID)
+	if err != nil {
+		log.Printf("ERROR: Failed to check the last contact notification for user %d: %v", userID, err)
+		return ""
+	}
+	if lastNotified != nil && time.Since(*lastNotified) < contactNotificationCooldown {
+		return ""
+	}
+
+	contact, err := u.wellbeingRepo.GetContactByID(*settings.EmergencyContactID, userID)
+	if err != nil || contact == nil || contact.Email == "" {
+		log.Printf("WARN: Emergency contact of user %d can't be emailed: %v", userID, err)
+		return ""
+	}
+
+	var emailNotifier Notifier
+	for _, notifier := range u.notifiers {
+		if notifier.Channel() == NotificationChannelEmail {
+			emailNotifier = notifier
+		}
+	}
+	if emailNotifier == nil {
+		log.Printf("WARN: Emergency contact of user %d not notified, SMTP is not configured", userID)
+		return ""
+	}
+
+	user, err := u.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		log.Printf("ERROR: Failed to load user %d for the contact notification: %v", userID, err)
+		return ""
+	}
+
+	// The contact isn't a user, the email notifier only needs a name and an address
+	recipient := &domain.User{Name: contact.Name, Email: contact.Email}
+	notification := &domain.Notification{
+		Kind:  "crisis_contact",
+		Title: fmt.Sprintf("%s may need your support", user.Name),
+		Body:  contactNotificationBody(contact.Name, user.Name),
+	}
+	if err := emailNotifier.Notify(ctx, recipient, notification); err != nil {
+		log.Printf("ERROR: Failed to email the emergency contact of user %d: %v", userID, err)
+		return ""
+	}
+
+	if err := u.safetyRepo.MarkContactNotified(eventID, contact.ID, time.Now()); err != nil {
+		log.Printf("ERROR: Failed to record the contact notification of event %d: %v", eventID, err)
+	}
+
+	return contact.Name
+}
+
+// contactNotificationBody doesn't quote the user, the contact only learns that they should reach out
+func contactNotificationBody(contactName, userName string) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\n", contactName)
+	fmt.Fprintf(&body, "%s chose you as their emergency contact on WarasIn. Something they wrote to our support chat today suggests they may be going through a very difficult time.\n\n", userName)
+	body.WriteString("Please reach out to them as soon as you can. If you believe they are in immediate danger, call 112.\n\n")
+	body.WriteString("Professional support in Indonesia:\n")
+	for _, hotline := range IndonesianHotlines {
+		fmt.Fprintf(&body, "- %s: %s\n", hotline.Name, hotline.Number)
+	}
+	body.WriteString("\nThe WarasIn team")
+	return body.String()
+}
+
+// crisisResponse is the fixed reply to a high risk message, in Indonesian unless the message was English
+func crisisResponse(language, contactName string) string {
+	var response strings.Builder
+	if language == "en" {
+		response.WriteString("Thank you for telling me. I can hear how much pain you are in right now, and your safety matters more than anything. 💙\n\n")
+		response.WriteString("You don't have to face this alone. Please reach out to one of these services now:\n")
+	} else {
+		response.WriteString("Terima kasih sudah mau bercerita. Aku bisa merasakan betapa beratnya yang kamu alami sekarang, dan keselamatanmu adalah yang paling penting. 💙\n\n")
+		response.WriteString("Kamu tidak harus menghadapi ini sendirian. Tolong hubungi salah satu layanan ini sekarang:\n")
+	}
+
+	for _, hotline := range IndonesianHotlines {
+		fmt.Fprintf(&response, "- %s: %s\n", hotline.Name, hotline.Number)
+	}
+	response.WriteString("\n")
+
+	if language == "en" {
+		if contactName != "" {
+			fmt.Fprintf(&response, "As you asked, we've also let %s, your emergency contact, know that you may need support.\n\n", contactName)
+		}
+		response.WriteString("If you are in immediate danger, call 112 or ask someone near you to stay with you. Are you safe right now?")
+	} else {
+		if contactName != "" {
+			fmt.Fprintf(&response, "Sesuai persetujuanmu, kami juga sudah mengabari %s, kontak daruratmu, bahwa kamu mungkin butuh dukungan.\n\n", contactName)
+		}
+		response.WriteString("Jika kamu dalam bahaya saat ini, hubungi 112 atau minta orang di dekatmu untuk menemanimu. Apakah kamu aman sekarang?")
+	}
+
+	return response.String()
+}
+
+func (u *chatSafetyUsecase) GetSettings(userID int) (*domain.ChatSafetySettings, error) {
+	settings, err := u.safetyRepo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return &domain.ChatSafetySettings{UserID: userID}, nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings picks the emergency contact and gives or withdraws consent to notify them.
+// Notifications are sent by email, so the contact needs an email address.
+func (u *chatSafetyUsecase) UpdateSettings(userID int, emergencyContactID *int, notifyContact bool) (*domain.ChatSafetySettings, error) {
+	if notifyContact && emergencyContactID == nil {
+		return nil, errors.New("emergency_contact_id is required to notify a contact")
+	}
+
+	if emergencyContactID != nil {
+		contact, err := u.wellbeingRepo.GetContactByID(*emergencyContactID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if contact == nil {
+			return nil, ErrTrustedContactNotFound
+		}
+		if notifyContact && contact.Email == "" {
+			return nil, errors.New("the emergency contact needs an email address to be notified")
+		}
+	}
+
+	return u.safetyRepo.UpsertSettings(&domain.ChatSafetySettings{
+		UserID:             userID,
+		EmergencyContactID: emergencyContactID,
+		NotifyContact:      notifyContact,
+	})
+}
+
+func (u *chatSafetyUsecase) GetEvents(riskLevel string, limit, offset int) ([]*domain.ChatSafetyEvent, int, error) {
+	if riskLevel != "" && riskLevel != ChatRiskMedium && riskLevel != ChatRiskHigh {
+		return nil, 0, errors.New("risk_level must be medium or high")
+	}
+	return u.safetyRepo.GetEvents(riskLevel, limit, offset)
+}
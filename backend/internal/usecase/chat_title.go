@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Where a session title came from
+const (
+	ChatTitleSourceModel     = "model"
+	ChatTitleSourceHeuristic = "heuristic"
+	ChatTitleSourceUser      = "user"
+)
+
+const (
+	chatTitleMaxChars     = 80
+	chatTitleMaxTokens    = 30
+	chatTitleTimeout      = 20 * time.Second
+	chatTitleExcerptChars = 1000
+	// The heuristic title is the start of the first message
+	chatTitleHeuristicWords = 6
+	chatTitleHeuristicChars = 60
+)
+
+const chatTitlePrompt = `Write a short title for a conversation between a user and MindCareBot, a mental health support companion, based on its first exchange below.
+- At most 6 words, in the language of the user's message
+- Describe the topic neutrally, without quoting private details such as names
+- No quotes, emojis or ending punctuation
+
+User: %s
+MindCareBot: %s
+
+Reply with the title only.`
+
+// titleInBackground gives the session a title after its first exchange. Sessions that
+// already have one, including those the user renamed, are left alone.
+func (u *chatUsecase) titleInBackground(userID, sessionID int) {
+	if sessionID <= 0 {
+		return
+	}
+	if _, running := u.titling.LoadOrStore(sessionID, true); running {
+		return
+	}
+
+	go func() {
+		defer u.titling.Delete(sessionID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), chatTitleTimeout)
+		defer cancel()
+
+		if err := u.generateTitle(ctx, userID, sessionID); err != nil {
+			log.Printf("ERROR: Failed to title chat session %d: %v", sessionID, err)
+		}
+	}()
+}
+
+// generateTitle asks the model for a title and falls back to the start of the first
+// message when the model fails, answers with nothing usable or the quota is used up
+func (u *chatUsecase) generateTitle(ctx context.Context, userID, sessionID int) error {
+	session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+	if err != nil || session == nil || session.Title != "" {
+		return err
+	}
+
+	first, err := u.chatRepo.GetFirstMessages(sessionID, 10)
+	if err != nil {
+		return err
+	}
+
+	var userMessage, botMessage string
+	for _, message := range first {
+		if message.SenderType == "user" && userMessage == "" {
+			userMessage = message.MessageContent
+		}
+		if message.SenderType == "bot" && userMessage != "" {
+			botMessage = message.MessageContent
+			break
+		}
+	}
+	if userMessage == "" {
+		return nil
+	}
+
+	title, source := "", ChatTitleSourceModel
+	if err := u.usage.CheckQuota(userID); err == nil {
+		prompt := fmt.Sprintf(chatTitlePrompt, truncateRunes(userMessage, chatTitleExcerptChars, "..."), truncateRunes(botMessage, chatTitleExcerptChars, "..."))
+		resp, err := generateFromPrompt(ctx, u.llm, prompt, chatTitleMaxTokens, false)
+		if err != nil {
+			log.Printf("WARN: Model title for chat session %d failed, using the first message: %v", sessionID, err)
+		} else {
+			u.usage.Record(userID, LLMFeatureChatTitle, resp)
+			title = cleanChatTitle(resp.Text)
+		}
+	}
+	if title == "" {
+		title, source = heuristicChatTitle(userMessage), ChatTitleSourceHeuristic
+	}
+	if title == "" {
+		return nil
+	}
+
+	_, err = u.chatRepo.SetGeneratedTitle(sessionID, title, source)
+	return err
+}
+
+// cleanChatTitle keeps the first line of a model answer without labels, quotes or markdown
+func cleanChatTitle(text string) string {
+	title := strings.TrimSpace(text)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = title[:i]
+	}
+
+	for _, prefix := range []string{"title:", "judul:"} {
+		if len(title) >= len(prefix) && strings.EqualFold(title[:len(prefix)], prefix) {
+			title = title[len(prefix):]
+		}
+	}
+	title = strings.Trim(title, " \t\"'`*#“”‘’.")
+
+	return normalizeChatTitle(title)
+}
+
+// heuristicChatTitle takes the first words of the message, cut at a word boundary
+func heuristicChatTitle(message string) string {
+	words := strings.Fields(message)
+	if len(words) == 0 {
+		return ""
+	}
+
+	title, used := "", 0
+	for _, word := range words {
+		if used == chatTitleHeuristicWords || utf8.RuneCountInString(title)+1+utf8.RuneCountInString(word) > chatTitleHeuristicChars {
+			break
+		}
+		if title != "" {
+			title += " "
+		}
+		title += word
+		used++
+	}
+	if used == 0 {
+		// A single word longer than the limit
+		title = truncateRunes(words[0], chatTitleHeuristicChars, "")
+	}
+
+	if used < len(words) {
+		title = strings.TrimRightFunc(title, unicode.IsPunct) + "…"
+	}
+
+	return normalizeChatTitle(title)
+}
+
+// normalizeChatTitle collapses whitespace and limits the length of any title, also those
+// set by the user. An empty result means no title.
+func normalizeChatTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.TrimSpace(truncateRunes(title, chatTitleMaxChars, ""))
+}
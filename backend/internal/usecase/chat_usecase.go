@@ -1,31 +1,136 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"warasin/internal/config"
 	"warasin/internal/domain"
 	"warasin/internal/repository/postgres"
 )
 
+var (
+	// ErrChatSessionNotFound is returned when a session doesn't exist or belongs to another user
+	ErrChatSessionNotFound = errors.New("session not found")
+	// ErrChatMessageTooLong is returned when a message leaves no room in the model's context
+	ErrChatMessageTooLong = errors.New("message is too long")
+	// ErrChatSessionIsLive is returned when the bot endpoints are used on a counselor session
+	ErrChatSessionIsLive = errors.New("session is a live session with a counselor")
+	// ErrChatMessageNotFound is returned when a message isn't part of the session
+	ErrChatMessageNotFound = errors.New("message not found")
+)
+
+const (
+	// Sent when the model returns nothing, e.g. after its safety filter blocked the answer
+	chatFallbackReply  = "I'm here to support you. How can I help you today? 🌿"
+	chatSearchMaxChars = 200
+	// Session tags are short labels, a session carries only a few
+	chatTagMaxChars    = 30
+	chatMaxSessionTags = 10
+)
+
+// ChatPromptVersion is stored with every bot message so feedback can be compared across
+// prompt changes. Bump it whenever mentalHealthSystemPrompt or the instructions added to
+// it change.
+const ChatPromptVersion = "mindcarebot-v1"
+
+// Mental health system prompt for MindCareBot
+const mentalHealthSystemPrompt = `You are MindCareBot 🌿, a compassionate and professional mental health companion created to provide emotional support and guidance.
+
+## Your Core Purpose:
+- Provide empathetic emotional support and validation
+- Offer evidence-based coping strategies and wellness techniques
+- Encourage healthy mental health practices
+- Be a caring, non-judgmental listening companion
+
+## Your Communication Style:
+- Use warm, supportive, and encouraging tone
+- Be concise but meaningful in your responses
+- Include gentle emojis occasionally (🌿, 💙, 🤗, ✨)
+- Validate feelings before offering suggestions
+- Ask thoughtful follow-up questions when appropriate
+
+## Mental Health Strategies You Can Suggest:
+- Breathing exercises (4-7-8 technique, box breathing)
+- Mindfulness and grounding techniques (5-4-3-2-1 method)
+- Journaling prompts for self-reflection
+- Progressive muscle relaxation
+- Positive affirmations and self-compassion practices
+- Healthy routine and sleep hygiene tips
+- Light physical activity suggestions
+
+## Important Guidelines:
+- Always validate the person's feelings first
+- Never diagnose or provide medical advice
+- Encourage professional help for serious concerns
+- If someone expresses suicidal thoughts or self-harm, gently but firmly encourage immediate professional help
+- Stay within your role as a supportive companion, not a therapist
+
+## Response Format:
+Keep responses focused, practical, and hopeful. End with gentle encouragement or a supportive question when appropriate.
+
+Remember: You are here to support and guide, not to replace professional mental health care.`
+
 type chatUsecase struct {
 	chatRepo postgres.ChatRepository
+	llm      LLMProvider
+	safety   ChatSafetyUsecase
+	usage    LLMUsageUsecase
+	// Adds the user's mood context to the prompt when they consented to it
+	personalization ChatPersonalizationUsecase
+	// Library resources the answers are grounded in
+	resources      ResourceUsecase
+	retrievalLimit int
+
+	contextTokens      int
+	maxOutputTokens    int
+	historyTokenBudget int
+	// Sessions with a summary update running
+	summarizing sync.Map
+	// Sessions with a title being generated
+	titling sync.Map
 }
 
 // ChatUsecase interface - tambahkan DeleteSession
 type ChatUsecase interface {
 	StartSession(userID int) (*domain.ChatSession, error)
 	EndSession(sessionID, userID int) (*domain.ChatSession, error)
-	GetSessions(userID int, limit, offset int, startDate, endDate string) ([]*domain.ChatSession, int, error)
+	GetSessions(userID int, limit, offset int, startDate, endDate, tag string, archived bool) ([]*domain.ChatSession, int, error)
 	SendMessage(sessionID, userID int, content, senderType string) (*domain.ChatMessage, error)
 	GetMessages(sessionID, userID int, limit, beforeID int) ([]*domain.ChatMessage, int, error)
+	GetMessagesAround(sessionID, userID int, messageID, limit int) ([]*domain.ChatMessage, int, error)
 	DeleteSession(sessionID, userID int) error // Tambahkan method ini
+	RenameSession(sessionID, userID int, title string) (*domain.ChatSession, error)
+	PinSession(sessionID, userID int, pinned bool) (*domain.ChatSession, error)
+	ArchiveSession(sessionID, userID int, archived bool) (*domain.ChatSession, error)
+	AddSessionTag(sessionID, userID int, tag string) (*domain.ChatSession, error)
+	RemoveSessionTag(sessionID, userID int, tag string) (*domain.ChatSession, error)
+	GetTags(userID int) ([]*domain.ChatTagCount, error)
+	SearchMessages(userID int, query string, limit, offset int) ([]*domain.ChatSearchResult, int, error)
+	Reply(ctx context.Context, userID, sessionID int, userType, message string) (*domain.ChatReply, error)
+	StreamReply(ctx context.Context, userID, sessionID int, userType, message string, onChunk func(text string) error) (*domain.ChatReply, error)
 }
 
 // NewChatUsecase creates a new chat use case
-func NewChatUsecase(chatRepo postgres.ChatRepository) ChatUsecase {
+func NewChatUsecase(chatRepo postgres.ChatRepository, llm LLMProvider, safety ChatSafetyUsecase, usage LLMUsageUsecase, personalization ChatPersonalizationUsecase, resources ResourceUsecase, cfg *config.Config) ChatUsecase {
 	return &chatUsecase{
-		chatRepo: chatRepo,
+		chatRepo:           chatRepo,
+		llm:                llm,
+		safety:             safety,
+		usage:              usage,
+		personalization:    personalization,
+		resources:          resources,
+		retrievalLimit:     cfg.ChatRetrievalResources,
+		contextTokens:      cfg.LLMContextTokens,
+		maxOutputTokens:    cfg.LLMMaxOutputTokens,
+		historyTokenBudget: cfg.ChatHistoryTokenBudget,
 	}
 }
 
@@ -46,7 +151,7 @@ func (u *chatUsecase) EndSession(sessionID, userID int) (*domain.ChatSession, er
 	}
 
 	if session == nil {
-		return nil, errors.New("session not found")
+		return nil, ErrChatSessionNotFound
 	}
 
 	if session.EndTime != nil {
@@ -64,12 +169,15 @@ func (u *chatUsecase) EndSession(sessionID, userID int) (*domain.ChatSession, er
 	return session, nil
 }
 
-func (u *chatUsecase) GetSessions(userID int, limit, offset int, startDateStr, endDateStr string) ([]*domain.ChatSession, int, error) {
-	var startDate, endDate time.Time
+// GetSessions lists the user's sessions, pinned first. Dates are RFC3339 and filter on the
+// start time, tag keeps the sessions carrying it and archived lists the archived sessions
+// instead of the others.
+func (u *chatUsecase) GetSessions(userID int, limit, offset int, startDateStr, endDateStr, tag string, archived bool) ([]*domain.ChatSession, int, error) {
+	filter := domain.ChatSessionFilter{Archived: archived, Tag: normalizeChatTag(tag)}
 
 	if startDateStr != "" {
 		var err error
-		startDate, err = time.Parse(time.RFC3339, startDateStr)
+		filter.StartDate, err = time.Parse(time.RFC3339, startDateStr)
 		if err != nil {
 			return nil, 0, errors.New("invalid start_date format")
 		}
@@ -77,13 +185,17 @@ func (u *chatUsecase) GetSessions(userID int, limit, offset int, startDateStr, e
 
 	if endDateStr != "" {
 		var err error
-		endDate, err = time.Parse(time.RFC3339, endDateStr)
+		filter.EndDate, err = time.Parse(time.RFC3339, endDateStr)
 		if err != nil {
 			return nil, 0, errors.New("invalid end_date format")
 		}
 	}
 
-	return u.chatRepo.GetSessionsByUserID(userID, limit, offset, startDate, endDate)
+	if !filter.StartDate.IsZero() && !filter.EndDate.IsZero() && filter.EndDate.Before(filter.StartDate) {
+		return nil, 0, errors.New("end_date must not be before start_date")
+	}
+
+	return u.chatRepo.GetSessionsByUserID(userID, limit, offset, filter)
 }
 
 func (u *chatUsecase) SendMessage(sessionID, userID int, content, senderType string) (*domain.ChatMessage, error) {
@@ -92,14 +204,27 @@ func (u *chatUsecase) SendMessage(sessionID, userID int, content, senderType str
 		return nil, errors.New("invalid sender type")
 	}
 
+	return u.saveMessage(userID, &domain.ChatMessage{
+		SessionID:      sessionID,
+		MessageContent: content,
+		SentAt:         time.Now(),
+		SenderType:     senderType,
+	})
+}
+
+// saveMessage stores message in one of the user's active bot sessions
+func (u *chatUsecase) saveMessage(userID int, message *domain.ChatMessage) (*domain.ChatMessage, error) {
 	// Check if session exists and belongs to user
-	session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+	session, err := u.chatRepo.GetSessionByID(message.SessionID, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	if session == nil {
-		return nil, errors.New("session not found")
+		return nil, ErrChatSessionNotFound
+	}
+	if session.CounselorID != nil {
+		return nil, ErrChatSessionIsLive
 	}
 
 	// Check if session is still active
@@ -107,13 +232,6 @@ func (u *chatUsecase) SendMessage(sessionID, userID int, content, senderType str
 		return nil, errors.New("cannot send message to ended session")
 	}
 
-	message := &domain.ChatMessage{
-		SessionID:      sessionID,
-		MessageContent: content,
-		SentAt:         time.Now(),
-		SenderType:     senderType,
-	}
-
 	return u.chatRepo.CreateMessage(message)
 }
 
@@ -125,7 +243,7 @@ func (u *chatUsecase) GetMessages(sessionID, userID int, limit, beforeID int) ([
 	}
 
 	if session == nil {
-		return nil, 0, errors.New("session not found")
+		return nil, 0, ErrChatSessionNotFound
 	}
 
 	return u.chatRepo.GetMessagesBySessionID(sessionID, limit, beforeID)
@@ -138,7 +256,7 @@ func (u *chatUsecase) DeleteSession(sessionID, userID int) error {
 		return err
 	}
 	if session == nil {
-		return errors.New("session not found")
+		return ErrChatSessionNotFound
 	}
 
 	// Delete all messages in the session first
@@ -150,3 +268,380 @@ func (u *chatUsecase) DeleteSession(sessionID, userID int) error {
 	// Delete the session
 	return u.chatRepo.DeleteSession(sessionID, userID)
 }
+
+// GetMessagesAround returns the page of the session around messageID, newest first, for
+// jumping to a search result. Older pages load from there with GetMessages and before_id.
+func (u *chatUsecase) GetMessagesAround(sessionID, userID int, messageID, limit int) ([]*domain.ChatMessage, int, error) {
+	session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if session == nil {
+		return nil, 0, ErrChatSessionNotFound
+	}
+
+	message, err := u.chatRepo.GetMessageByID(messageID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if message == nil || message.SessionID != sessionID {
+		return nil, 0, ErrChatMessageNotFound
+	}
+
+	messages, err := u.chatRepo.GetMessagesAround(sessionID, messageID, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	_, total, err := u.chatRepo.GetMessagesBySessionID(sessionID, 1, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// RenameSession sets a title chosen by the user, an empty title is rejected
+func (u *chatUsecase) RenameSession(sessionID, userID int, title string) (*domain.ChatSession, error) {
+	title = normalizeChatTitle(title)
+	if title == "" {
+		return nil, errors.New("title is required")
+	}
+
+	return u.updateSession(sessionID, userID, u.chatRepo.UpdateTitle(sessionID, userID, title))
+}
+
+func (u *chatUsecase) PinSession(sessionID, userID int, pinned bool) (*domain.ChatSession, error) {
+	var pinnedAt *time.Time
+	if pinned {
+		now := time.Now()
+		pinnedAt = &now
+	}
+
+	return u.updateSession(sessionID, userID, u.chatRepo.SetPinned(sessionID, userID, pinnedAt))
+}
+
+// ArchiveSession hides the session from the default list, archived sessions stay searchable
+func (u *chatUsecase) ArchiveSession(sessionID, userID int, archived bool) (*domain.ChatSession, error) {
+	var archivedAt *time.Time
+	if archived {
+		now := time.Now()
+		archivedAt = &now
+	}
+
+	return u.updateSession(sessionID, userID, u.chatRepo.SetArchived(sessionID, userID, archivedAt))
+}
+
+// AddSessionTag labels the session with tag. Tags are lowercased, so "Work" and "work"
+// are the same tag.
+func (u *chatUsecase) AddSessionTag(sessionID, userID int, tag string) (*domain.ChatSession, error) {
+	tag = normalizeChatTag(tag)
+	if tag == "" {
+		return nil, errors.New("tag is required")
+	}
+	if utf8.RuneCountInString(tag) > chatTagMaxChars {
+		return nil, fmt.Errorf("tag must be at most %d characters", chatTagMaxChars)
+	}
+
+	session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrChatSessionNotFound
+	}
+
+	hasTag := false
+	for _, existing := range session.Tags {
+		if existing == tag {
+			hasTag = true
+			break
+		}
+	}
+	if !hasTag && len(session.Tags) >= chatMaxSessionTags {
+		return nil, fmt.Errorf("a session can have at most %d tags", chatMaxSessionTags)
+	}
+
+	return u.updateSession(sessionID, userID, u.chatRepo.AddTag(sessionID, userID, tag))
+}
+
+func (u *chatUsecase) RemoveSessionTag(sessionID, userID int, tag string) (*domain.ChatSession, error) {
+	return u.updateSession(sessionID, userID, u.chatRepo.RemoveTag(sessionID, userID, normalizeChatTag(tag)))
+}
+
+// GetTags lists the tags on the user's sessions with how many sessions carry each
+func (u *chatUsecase) GetTags(userID int) ([]*domain.ChatTagCount, error) {
+	return u.chatRepo.GetTagCounts(userID)
+}
+
+// normalizeChatTag lowercases the tag and collapses its whitespace
+func normalizeChatTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// updateSession returns the session after an update, err is the update's result
+func (u *chatUsecase) updateSession(sessionID, userID int, err error) (*domain.ChatSession, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChatSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrChatSessionNotFound
+	}
+	return session, nil
+}
+
+// SearchMessages searches the messages of all the user's sessions, archived ones included
+func (u *chatUsecase) SearchMessages(userID int, query string, limit, offset int) ([]*domain.ChatSearchResult, int, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, errors.New("search query is required")
+	}
+	if utf8.RuneCountInString(query) > chatSearchMaxChars {
+		return nil, 0, errors.New("search query is too long")
+	}
+
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+
+	return u.chatRepo.SearchMessages(userID, query, limit, offset)
+}
+
+// Reply asks the model to answer message and saves the answer as a bot message when
+// sessionID is set. Without a session the bot answers without any history. Messages
+// screened as high risk get the fixed crisis response instead of a model answer, even
+// when the user's daily quota is used up.
+func (u *chatUsecase) Reply(ctx context.Context, userID, sessionID int, userType, message string) (*domain.ChatReply, error) {
+	turn, err := u.prepare(ctx, userID, sessionID, userType, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if turn.safety != nil && turn.safety.CrisisResponse != "" {
+		return u.crisisReply(userID, sessionID, turn.safety, nil), nil
+	}
+
+	if err := u.usage.CheckQuota(userID); err != nil {
+		return nil, err
+	}
+
+	u.logPersonalContext(turn)
+	resp, err := u.llm.Generate(ctx, turn.req)
+	if err != nil {
+		return nil, fmt.Errorf("AI service error: %w", err)
+	}
+	u.usage.Record(userID, LLMFeatureChat, resp)
+
+	reply := u.saveReply(userID, sessionID, resp, nil)
+	reply.Safety = turn.safety
+	if turn.citations != nil {
+		reply.Citations = turn.citations
+	}
+	return reply, nil
+}
+
+// StreamReply is Reply with the answer passed to onChunk while it is generated. The
+// answer is only saved once the model finished, a cancelled ctx saves nothing.
+func (u *chatUsecase) StreamReply(ctx context.Context, userID, sessionID int, userType, message string, onChunk func(text string) error) (*domain.ChatReply, error) {
+	turn, err := u.prepare(ctx, userID, sessionID, userType, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if turn.safety != nil && turn.safety.CrisisResponse != "" {
+		return u.crisisReply(userID, sessionID, turn.safety, onChunk), nil
+	}
+
+	if err := u.usage.CheckQuota(userID); err != nil {
+		return nil, err
+	}
+
+	u.logPersonalContext(turn)
+	resp, err := u.llm.Stream(ctx, turn.req, onChunk)
+	if err != nil {
+		return nil, fmt.Errorf("AI service error: %w", err)
+	}
+	u.usage.Record(userID, LLMFeatureChat, resp)
+
+	reply := u.saveReply(userID, sessionID, resp, onChunk)
+	reply.Safety = turn.safety
+	if turn.citations != nil {
+		reply.Citations = turn.citations
+	}
+	return reply, nil
+}
+
+// chatTurn is a model request ready to be sent
+type chatTurn struct {
+	req    *LLMRequest
+	safety *domain.ChatSafety
+	// The user's personal context in req, logged once it is sent
+	personal *domain.ChatContextLog
+	// Resources put into req, returned with the answer
+	citations []domain.ResourceCitation
+}
+
+// prepare screens the message and builds the model request. The message is screened
+// first so a high risk message always gets the crisis response, even when building the
+// request would fail. Medium risk messages get an extra instruction so the model answers
+// with care.
+func (u *chatUsecase) prepare(ctx context.Context, userID, sessionID int, userType, message string) (*chatTurn, error) {
+	safety := u.safety.Screen(ctx, userID, sessionID, message)
+	if safety != nil && safety.CrisisResponse != "" {
+		return &chatTurn{safety: safety}, nil
+	}
+
+	turn, err := u.buildRequest(userID, sessionID, userType, message)
+	if err != nil {
+		return nil, err
+	}
+
+	turn.safety = safety
+	if turn.safety != nil && turn.safety.RiskLevel == ChatRiskMedium {
+		req := turn.req
+		messages := make([]LLMMessage, 0, len(req.Messages)+1)
+		messages = append(messages, req.Messages[0], LLMMessage{Role: LLMRoleSystem, Content: mediumRiskInstruction})
+		req.Messages = append(messages, req.Messages[1:]...)
+	}
+
+	return turn, nil
+}
+
+// logPersonalContext records the personal context of a request about to be sent
+func (u *chatUsecase) logPersonalContext(turn *chatTurn) {
+	if turn.personal != nil {
+		u.personalization.LogContext(turn.personal)
+	}
+}
+
+// crisisReply sends and saves the crisis response without calling the model
+func (u *chatUsecase) crisisReply(userID, sessionID int, safety *domain.ChatSafety, onChunk func(text string) error) *domain.ChatReply {
+	if onChunk != nil {
+		_ = onChunk(safety.CrisisResponse)
+	}
+
+	reply := u.saveReply(userID, sessionID, &LLMResponse{Text: safety.CrisisResponse, Model: crisisResponseModel}, nil)
+	reply.Safety = safety
+	return reply
+}
+
+// buildRequest puts the system prompt, the user's personal context when they consented to
+// it, the library resources matching the message, the session summary, the recent messages
+// and the new message together. History gets the configured budget or whatever room the
+// context window has left after the output and the fixed parts, whichever is smaller.
+// Resources get at most half of what is left after the personal context.
+func (u *chatUsecase) buildRequest(userID, sessionID int, userType, message string) (*chatTurn, error) {
+	available := u.contextTokens - u.maxOutputTokens - estimateTokens(mentalHealthSystemPrompt) -
+		estimateTokens(mediumRiskInstruction) - estimateTokens(message)
+	if available < 0 {
+		return nil, ErrChatMessageTooLong
+	}
+
+	turn := &chatTurn{}
+	messages := []LLMMessage{{Role: LLMRoleSystem, Content: mentalHealthSystemPrompt}}
+
+	if sessionID > 0 {
+		session, err := u.chatRepo.GetSessionByID(sessionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			return nil, ErrChatSessionNotFound
+		}
+		if session.CounselorID != nil {
+			return nil, ErrChatSessionIsLive
+		}
+	}
+
+	// The chat still works when the context can't be loaded, just without it
+	personal, err := u.personalization.BuildContext(userID)
+	if err != nil {
+		log.Printf("ERROR: Failed to build personal chat context for user %d: %v", userID, err)
+	}
+	if personal != nil {
+		if tokens := estimateTokens(personal.Context); tokens <= available {
+			available -= tokens
+			messages = append(messages, LLMMessage{Role: LLMRoleSystem, Content: personal.Context})
+			if sessionID > 0 {
+				personal.SessionID = &sessionID
+			}
+			turn.personal = personal
+		}
+	}
+
+	if block, citations := u.retrieveResources(message, userType, available/2); block != "" {
+		available -= estimateTokens(block)
+		messages = append(messages, LLMMessage{Role: LLMRoleSystem, Content: block})
+		turn.citations = citations
+	}
+
+	if sessionID > 0 {
+		budget := u.historyTokenBudget
+		if available < budget {
+			budget = available
+		}
+
+		history, err := u.sessionHistory(sessionID, budget)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, history...)
+	}
+
+	messages = append(messages, LLMMessage{Role: LLMRoleUser, Content: message})
+	turn.req = &LLMRequest{Messages: messages}
+	return turn, nil
+}
+
+// saveReply stores the answer as a bot message. A failed save is logged, the user still gets the answer.
+func (u *chatUsecase) saveReply(userID, sessionID int, resp *LLMResponse, onChunk func(text string) error) *domain.ChatReply {
+	text := strings.TrimSpace(resp.Text)
+	if text == "" {
+		text = chatFallbackReply
+		if onChunk != nil {
+			_ = onChunk(text)
+		}
+	}
+
+	reply := &domain.ChatReply{
+		Response:   text,
+		SessionID:  sessionID,
+		TokensUsed: resp.TotalTokens(),
+		Model:      resp.Model,
+		Citations:  []domain.ResourceCitation{},
+	}
+
+	if sessionID > 0 {
+		// The crisis response is fixed text, no prompt was involved
+		promptVersion := ChatPromptVersion
+		if resp.Model == crisisResponseModel {
+			promptVersion = ""
+		}
+
+		botMessage, err := u.saveMessage(userID, &domain.ChatMessage{
+			SessionID:      sessionID,
+			MessageContent: text,
+			SentAt:         time.Now(),
+			SenderType:     "bot",
+			Model:          resp.Model,
+			PromptVersion:  promptVersion,
+		})
+		if err != nil {
+			log.Printf("Failed to save bot message: %v", err)
+		} else {
+			reply.MessageID = botMessage.ID
+			u.summarizeInBackground(userID, sessionID)
+			u.titleInBackground(userID, sessionID)
+		}
+	}
+
+	return reply
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+// CounselorChatChannel is the Postgres NOTIFY channel live chat events travel on. Every API
+// instance listens on it and passes events to its own connections, so the two sides of a
+// session can be connected to different instances.
+const CounselorChatChannel = "counselor_chat"
+
+// Events queued for a connection before it counts as too slow and is dropped. The client
+// reconnects and catches up with a backfill.
+const liveChatBufferSize = 64
+
+// LiveChatSubscription is one connection to a live session
+type LiveChatSubscription struct {
+	Session *domain.ChatSession
+	UserID  int
+	Role    string
+	// Closed when the subscription ends, also when the hub dropped a slow connection
+	Events <-chan *domain.LiveChatEvent
+
+	events chan *domain.LiveChatEvent
+	closed bool
+}
+
+// liveChatNotification is the NOTIFY payload. Messages travel by ID and are loaded by
+// every instance, payloads are limited to 8000 bytes.
+type liveChatNotification struct {
+	Event *domain.LiveChatEvent `json:"event"`
+	// Asks connections of the other side to announce that they are online
+	Probe bool `json:"probe,omitempty"`
+}
+
+// liveChatHub keeps this instance's connections by session
+type liveChatHub struct {
+	mu       sync.Mutex
+	sessions map[int]map[*LiveChatSubscription]bool
+}
+
+func newLiveChatHub() *liveChatHub {
+	return &liveChatHub{
+		sessions: make(map[int]map[*LiveChatSubscription]bool),
+	}
+}
+
+func (h *liveChatHub) add(sub *LiveChatSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.sessions[sub.Session.ID]
+	if !ok {
+		subs = make(map[*LiveChatSubscription]bool)
+		h.sessions[sub.Session.ID] = subs
+	}
+	subs[sub] = true
+}
+
+// remove ends the subscription and reports whether the same user still has another
+// connection to the session on this instance
+func (h *liveChatHub) remove(sub *LiveChatSubscription) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closeLocked(sub)
+
+	for other := range h.sessions[sub.Session.ID] {
+		if other.UserID == sub.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+// closeLocked drops the subscription, h.mu must be held
+func (h *liveChatHub) closeLocked(sub *LiveChatSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+
+	subs := h.sessions[sub.Session.ID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.sessions, sub.Session.ID)
+	}
+}
+
+// deliver queues event for the session's connections. Typing and presence only go to the
+// other side, the rest also to the sender's other devices.
+func (h *liveChatHub) deliver(event *domain.LiveChatEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ownSideOnly := event.Type == "typing" || event.Type == "presence"
+	for sub := range h.sessions[event.SessionID] {
+		if ownSideOnly && sub.UserID == event.UserID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("WARN: Dropping slow live chat connection of user %d in session %d", sub.UserID, sub.Session.ID)
+			h.closeLocked(sub)
+		}
+	}
+}
+
+// localUsers returns the users connected to the session here with their role, except excludeUserID
+func (h *liveChatHub) localUsers(sessionID, excludeUserID int) map[int]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	users := make(map[int]string)
+	for sub := range h.sessions[sessionID] {
+		if sub.UserID != excludeUserID {
+			users[sub.UserID] = sub.Role
+		}
+	}
+	return users
+}
+
+// closeSession ends every subscription to the session on this instance
+func (h *liveChatHub) closeSession(sessionID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.sessions[sessionID] {
+		h.closeLocked(sub)
+	}
+}
+
+// all returns every subscription on this instance
+func (h *liveChatHub) all() []*LiveChatSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var subs []*LiveChatSubscription
+	for _, sessionSubs := range h.sessions {
+		for sub := range sessionSubs {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// publish sends event to all instances. When NOTIFY fails the event still reaches this
+// instance's connections, the other side catches up on its next backfill.
+func (u *counselorChatUsecase) publish(event *domain.LiveChatEvent, probe bool) {
+	event.At = time.Now()
+
+	payload, err := json.Marshal(&liveChatNotification{Event: event, Probe: probe})
+	if err == nil {
+		err = u.counselorRepo.Notify(CounselorChatChannel, string(payload))
+		if err == nil {
+			return
+		}
+	}
+
+	log.Printf("ERROR: Failed to publish live chat %s event for session %d: %v", event.Type, event.SessionID, err)
+	u.dispatch(event, probe)
+}
+
+// Dispatch handles a payload received on CounselorChatChannel
+func (u *counselorChatUsecase) Dispatch(payload string) {
+	var notification liveChatNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil || notification.Event == nil {
+		log.Printf("WARN: Ignoring invalid live chat notification: %v", err)
+		return
+	}
+	u.dispatch(notification.Event, notification.Probe)
+}
+
+func (u *counselorChatUsecase) dispatch(event *domain.LiveChatEvent, probe bool) {
+	switch event.Type {
+	case "message":
+		if event.Message == nil {
+			message, err := u.chatRepo.GetMessageByID(event.MessageID)
+			if err != nil || message == nil {
+				log.Printf("ERROR: Failed to load live chat message %d: %v", event.MessageID, err)
+				return
+			}
+			event.Message = message
+		}
+	case "presence":
+		u.answerPresence(event, probe)
+	}
+
+	u.hub.deliver(event)
+
+	// The ended event is the last one the session's connections get
+	if event.Type == "ended" {
+		u.hub.closeSession(event.SessionID)
+	}
+}
+
+// answerPresence keeps presence right across instances. A probe from a new connection
+// is answered with the other side's connections here. When a user goes offline on one
+// instance but still has a connection here, that one announces the user again.
+func (u *counselorChatUsecase) answerPresence(event *domain.LiveChatEvent, probe bool) {
+	if probe {
+		for userID, role := range u.hub.localUsers(event.SessionID, event.UserID) {
+			u.publishPresence(event.SessionID, userID, role, true, false)
+		}
+		return
+	}
+
+	if event.Online != nil && !*event.Online {
+		if role, ok := u.hub.localUsers(event.SessionID, 0)[event.UserID]; ok {
+			u.publishPresence(event.SessionID, event.UserID, role, true, false)
+		}
+	}
+}
+
+func (u *counselorChatUsecase) publishPresence(sessionID, userID int, role string, online, probe bool) {
+	// Published from the listener's goroutine, so NOTIFY mustn't wait on it
+	go u.publish(&domain.LiveChatEvent{
+		Type:      "presence",
+		SessionID: sessionID,
+		UserID:    userID,
+		Role:      role,
+		Online:    &online,
+	}, probe)
+}
+
+// Resync is called after the listener reconnected. Connections are told to backfill what
+// they missed and presence is announced again.
+func (u *counselorChatUsecase) Resync() {
+	told := make(map[int]bool)
+	announced := make(map[[2]int]bool)
+	for _, sub := range u.hub.all() {
+		if !told[sub.Session.ID] {
+			told[sub.Session.ID] = true
+			u.hub.deliver(&domain.LiveChatEvent{Type: "resync", SessionID: sub.Session.ID, At: time.Now()})
+		}
+
+		key := [2]int{sub.Session.ID, sub.UserID}
+		if !announced[key] {
+			announced[key] = true
+			u.publishPresence(sub.Session.ID, sub.UserID, sub.Role, true, true)
+		}
+	}
+}
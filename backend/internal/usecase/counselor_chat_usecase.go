@@ -0,0 +1,360 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+var (
+	// ErrNotACounselor is returned when a user without the counselor type is assigned as one
+	ErrNotACounselor = errors.New("user is not a counselor")
+	// ErrCounselorNotAssigned is returned when the user has no counselor to chat with
+	ErrCounselorNotAssigned = errors.New("no counselor is assigned to this user")
+	// ErrChatSessionEnded is returned when sending to a session that has ended
+	ErrChatSessionEnded = errors.New("session has ended")
+)
+
+// Sides of a live session, stored as the messages' sender_type
+const (
+	LiveChatRoleUser      = "user"
+	LiveChatRoleCounselor = "therapist"
+)
+
+// CounselorUserType is the user type of counselors, set by an admin
+const CounselorUserType = "counselor"
+
+const (
+	liveChatMaxMessageChars = 4000
+	// Most messages sent in one backfill, older ones are paged in over the REST endpoint
+	liveChatBackfillLimit = 200
+)
+
+type counselorChatUsecase struct {
+	counselorRepo postgres.CounselorRepository
+	chatRepo      postgres.ChatRepository
+	userRepo      postgres.UserRepository
+	hub           *liveChatHub
+}
+
+// CounselorChatUsecase interface
+type CounselorChatUsecase interface {
+	AssignCounselor(userID, counselorID int) (*domain.CounselorAssignment, error)
+	UnassignCounselor(userID int) error
+	GetAssignment(userID int) (*domain.CounselorAssignment, error)
+	StartSession(userID int) (*domain.ChatSession, error)
+	GetCounselorSessions(counselorID int, activeOnly bool, limit, offset int) ([]*domain.ChatSession, int, error)
+	GetMessages(sessionID, participantID int, limit, beforeID int) ([]*domain.ChatMessage, int, error)
+
+	Join(sessionID, participantID int) (*LiveChatSubscription, error)
+	Leave(sub *LiveChatSubscription)
+	Backfill(sub *LiveChatSubscription, afterID int) (*domain.LiveChatEvent, error)
+	Send(sub *LiveChatSubscription, content, clientID string) (*domain.ChatMessage, error)
+	SetTyping(sub *LiveChatSubscription, typing bool)
+	MarkDelivered(sub *LiveChatSubscription, upToID int) error
+	MarkRead(sub *LiveChatSubscription, upToID int) error
+
+	// Dispatch and Resync are fed by the listener on CounselorChatChannel
+	Dispatch(payload string)
+	Resync()
+}
+
+// NewCounselorChatUsecase creates a new counselor chat use case
+func NewCounselorChatUsecase(counselorRepo postgres.CounselorRepository, chatRepo postgres.ChatRepository, userRepo postgres.UserRepository) CounselorChatUsecase {
+	return &counselorChatUsecase{
+		counselorRepo: counselorRepo,
+		chatRepo:      chatRepo,
+		userRepo:      userRepo,
+		hub:           newLiveChatHub(),
+	}
+}
+
+// AssignCounselor gives the user a counselor, replacing any previous one
+func (u *counselorChatUsecase) AssignCounselor(userID, counselorID int) (*domain.CounselorAssignment, error) {
+	if userID == counselorID {
+		return nil, errors.New("a user can't be their own counselor")
+	}
+
+	user, err := u.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	counselor, err := u.userRepo.GetByID(counselorID)
+	if err != nil {
+		return nil, err
+	}
+	if counselor == nil || counselor.UserType != CounselorUserType {
+		return nil, ErrNotACounselor
+	}
+
+	previous, err := u.counselorRepo.GetAssignment(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment, err := u.counselorRepo.UpsertAssignment(userID, counselorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil && previous.CounselorID != counselorID {
+		if err := u.endCounselorSessions(userID, previous.CounselorID); err != nil {
+			return nil, err
+		}
+	}
+	return assignment, nil
+}
+
+// UnassignCounselor removes the user's counselor and ends their open sessions with them
+func (u *counselorChatUsecase) UnassignCounselor(userID int) error {
+	assignment, err := u.GetAssignment(userID)
+	if err != nil {
+		return err
+	}
+
+	err = u.counselorRepo.DeleteAssignment(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCounselorNotAssigned
+	}
+	if err != nil {
+		return err
+	}
+
+	return u.endCounselorSessions(userID, assignment.CounselorID)
+}
+
+// endCounselorSessions ends the user's open sessions with a counselor that is no longer
+// theirs and closes every connection to them
+func (u *counselorChatUsecase) endCounselorSessions(userID, counselorID int) error {
+	sessionIDs, err := u.chatRepo.EndCounselorSessions(userID, counselorID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		u.publish(&domain.LiveChatEvent{Type: "ended", SessionID: sessionID}, false)
+	}
+	return nil
+}
+
+func (u *counselorChatUsecase) GetAssignment(userID int) (*domain.CounselorAssignment, error) {
+	assignment, err := u.counselorRepo.GetAssignment(userID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, ErrCounselorNotAssigned
+	}
+	return assignment, nil
+}
+
+// StartSession opens a live session with the user's counselor, or returns the one still open
+func (u *counselorChatUsecase) StartSession(userID int) (*domain.ChatSession, error) {
+	assignment, err := u.GetAssignment(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := u.chatRepo.GetOpenCounselorSession(userID, assignment.CounselorID)
+	if err != nil || session != nil {
+		return session, err
+	}
+
+	return u.chatRepo.CreateSession(&domain.ChatSession{
+		UserID:      userID,
+		StartTime:   time.Now(),
+		CounselorID: &assignment.CounselorID,
+	})
+}
+
+func (u *counselorChatUsecase) GetCounselorSessions(counselorID int, activeOnly bool, limit, offset int) ([]*domain.ChatSession, int, error) {
+	return u.chatRepo.GetSessionsByCounselorID(counselorID, activeOnly, limit, offset)
+}
+
+// liveSession returns a counselor session the participant takes part in and their role in it.
+// A counselor only keeps access while they are a counselor and still assigned to the user.
+func (u *counselorChatUsecase) liveSession(sessionID, participantID int) (*domain.ChatSession, string, error) {
+	session, err := u.chatRepo.GetSessionForParticipant(sessionID, participantID)
+	if err != nil {
+		return nil, "", err
+	}
+	if session == nil || session.CounselorID == nil {
+		return nil, "", ErrChatSessionNotFound
+	}
+
+	if session.UserID == participantID {
+		return session, LiveChatRoleUser, nil
+	}
+
+	assignment, err := u.counselorRepo.GetAssignment(session.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if assignment == nil || assignment.CounselorID != participantID {
+		return nil, "", ErrChatSessionNotFound
+	}
+
+	counselor, err := u.userRepo.GetByID(participantID)
+	if err != nil {
+		return nil, "", err
+	}
+	if counselor == nil || counselor.UserType != CounselorUserType {
+		return nil, "", ErrChatSessionNotFound
+	}
+
+	return session, LiveChatRoleCounselor, nil
+}
+
+func (u *counselorChatUsecase) GetMessages(sessionID, participantID int, limit, beforeID int) ([]*domain.ChatMessage, int, error) {
+	if _, _, err := u.liveSession(sessionID, participantID); err != nil {
+		return nil, 0, err
+	}
+	return u.chatRepo.GetMessagesBySessionID(sessionID, limit, beforeID)
+}
+
+// Join subscribes a connection to the session's events and announces the participant online
+func (u *counselorChatUsecase) Join(sessionID, participantID int) (*LiveChatSubscription, error) {
+	session, role, err := u.liveSession(sessionID, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan *domain.LiveChatEvent, liveChatBufferSize)
+	sub := &LiveChatSubscription{
+		Session: session,
+		UserID:  participantID,
+		Role:    role,
+		Events:  events,
+		events:  events,
+	}
+	u.hub.add(sub)
+
+	online := true
+	u.publish(&domain.LiveChatEvent{Type: "presence", SessionID: sessionID, UserID: participantID, Role: role, Online: &online}, true)
+
+	return sub, nil
+}
+
+// Leave ends the subscription. The participant goes offline unless they are still
+// connected from another device.
+func (u *counselorChatUsecase) Leave(sub *LiveChatSubscription) {
+	if u.hub.remove(sub) {
+		return
+	}
+
+	online := false
+	u.publish(&domain.LiveChatEvent{Type: "presence", SessionID: sub.Session.ID, UserID: sub.UserID, Role: sub.Role, Online: &online}, false)
+}
+
+// Backfill returns the messages after afterID, oldest first. When there are more than fit
+// in one backfill only the newest are sent and Truncated is set.
+func (u *counselorChatUsecase) Backfill(sub *LiveChatSubscription, afterID int) (*domain.LiveChatEvent, error) {
+	messages, err := u.chatRepo.GetMessagesAfterID(sub.Session.ID, afterID, liveChatBackfillLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first from the repository
+	ordered := make([]*domain.ChatMessage, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		ordered = append(ordered, messages[i])
+	}
+
+	return &domain.LiveChatEvent{
+		Type:      "backfill",
+		SessionID: sub.Session.ID,
+		AfterID:   afterID,
+		Messages:  ordered,
+		Truncated: len(messages) == liveChatBackfillLimit,
+		At:        time.Now(),
+	}, nil
+}
+
+// Send stores a message from the participant and publishes it to the session
+func (u *counselorChatUsecase) Send(sub *LiveChatSubscription, content, clientID string) (*domain.ChatMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("message content is required")
+	}
+	if utf8.RuneCountInString(content) > liveChatMaxMessageChars {
+		return nil, ErrChatMessageTooLong
+	}
+
+	// Loaded again so a session ended after joining stops taking messages
+	session, _, err := u.liveSession(sub.Session.ID, sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if session.EndTime != nil {
+		return nil, ErrChatSessionEnded
+	}
+
+	message, err := u.chatRepo.CreateMessage(&domain.ChatMessage{
+		SessionID:      session.ID,
+		MessageContent: content,
+		SenderType:     sub.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.publish(&domain.LiveChatEvent{
+		Type:      "message",
+		SessionID: session.ID,
+		UserID:    sub.UserID,
+		Role:      sub.Role,
+		MessageID: message.ID,
+		ClientID:  clientID,
+	}, false)
+
+	return message, nil
+}
+
+func (u *counselorChatUsecase) SetTyping(sub *LiveChatSubscription, typing bool) {
+	u.publish(&domain.LiveChatEvent{
+		Type:      "typing",
+		SessionID: sub.Session.ID,
+		UserID:    sub.UserID,
+		Role:      sub.Role,
+		Typing:    &typing,
+	}, false)
+}
+
+// otherRole is the sender type of the messages the participant receives
+func otherRole(role string) string {
+	if role == LiveChatRoleUser {
+		return LiveChatRoleCounselor
+	}
+	return LiveChatRoleUser
+}
+
+// MarkDelivered records that the other side's messages up to upToID reached the participant
+func (u *counselorChatUsecase) MarkDelivered(sub *LiveChatSubscription, upToID int) error {
+	changed, err := u.chatRepo.MarkMessagesDelivered(sub.Session.ID, otherRole(sub.Role), upToID, time.Now())
+	if err != nil || changed == 0 {
+		return err
+	}
+
+	u.publish(&domain.LiveChatEvent{Type: "delivered", SessionID: sub.Session.ID, UserID: sub.UserID, Role: sub.Role, MessageID: upToID}, false)
+	return nil
+}
+
+// MarkRead records that the participant read the other side's messages up to upToID
+func (u *counselorChatUsecase) MarkRead(sub *LiveChatSubscription, upToID int) error {
+	changed, err := u.chatRepo.MarkMessagesRead(sub.Session.ID, otherRole(sub.Role), upToID, time.Now())
+	if err != nil || changed == 0 {
+		return err
+	}
+
+	u.publish(&domain.LiveChatEvent{Type: "read", SessionID: sub.Session.ID, UserID: sub.UserID, Role: sub.Role, MessageID: upToID}, false)
+	return nil
+}
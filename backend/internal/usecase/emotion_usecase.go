@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+var (
+	ErrEmotionNotFound   = errors.New("emotion not found")
+	ErrEntryTypeNotFound = errors.New("mood entry type not found")
+)
+
+// emotionCacheTTL bounds how long another replica's admin changes take to show up
+const emotionCacheTTL = 5 * time.Minute
+
+var taxonomyLabelPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// emotionTaxonomy is the in-memory copy of the active emotions and entry types
+type emotionTaxonomy struct {
+	byLabel      map[string]*domain.Emotion
+	byModelLabel map[string]*domain.Emotion
+	entryTypes   map[string]bool
+	loadedAt     time.Time
+}
+
+type emotionUsecase struct {
+	emotionRepo postgres.EmotionRepository
+
+	mu       sync.RWMutex
+	taxonomy *emotionTaxonomy
+}
+
+// EmotionUsecase interface
+type EmotionUsecase interface {
+	GetEmotions(includeInactive bool) ([]*domain.Emotion, error)
+	GetEntryTypes(includeInactive bool) ([]*domain.MoodEntryType, error)
+	CreateEmotion(emotion *domain.Emotion) (*domain.Emotion, error)
+	UpdateEmotion(emotion *domain.Emotion) (*domain.Emotion, error)
+	DeactivateEmotion(id int) error
+	CreateEntryType(entryType *domain.MoodEntryType) (*domain.MoodEntryType, error)
+	UpdateEntryType(entryType *domain.MoodEntryType) (*domain.MoodEntryType, error)
+	ResolveEmotion(label string) (*domain.Emotion, error)
+	MapModelLabel(modelLabel string) (*domain.Emotion, error)
+	IsValidEntryType(entryType string) (bool, error)
+}
+
+// NewEmotionUsecase creates a new emotion use case
+func NewEmotionUsecase(emotionRepo postgres.EmotionRepository) EmotionUsecase {
+	return &emotionUsecase{
+		emotionRepo: emotionRepo,
+	}
+}
+
+func (u *emotionUsecase) GetEmotions(includeInactive bool) ([]*domain.Emotion, error) {
+	return u.emotionRepo.GetEmotions(includeInactive)
+}
+
+func (u *emotionUsecase) GetEntryTypes(includeInactive bool) ([]*domain.MoodEntryType, error) {
+	return u.emotionRepo.GetEntryTypes(includeInactive)
+}
+
+func (u *emotionUsecase) CreateEmotion(emotion *domain.Emotion) (*domain.Emotion, error) {
+	emotion.Label = strings.ToLower(strings.TrimSpace(emotion.Label))
+	if !taxonomyLabelPattern.MatchString(emotion.Label) {
+		return nil, errors.New("label must be lowercase letters, digits or underscores")
+	}
+	if err := u.validateEmotion(emotion); err != nil {
+		return nil, err
+	}
+
+	created, err := u.emotionRepo.CreateEmotion(emotion)
+	if err != nil {
+		return nil, err
+	}
+
+	u.invalidate()
+	return created, nil
+}
+
+// UpdateEmotion changes everything but the label, which stored mood entries refer to
+func (u *emotionUsecase) UpdateEmotion(emotion *domain.Emotion) (*domain.Emotion, error) {
+	existing, err := u.emotionRepo.GetEmotionByID(emotion.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrEmotionNotFound
+	}
+
+	emotion.Label = existing.Label
+	emotion.CreatedAt = existing.CreatedAt
+	if err := u.validateEmotion(emotion); err != nil {
+		return nil, err
+	}
+
+	if err := u.emotionRepo.UpdateEmotion(emotion); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEmotionNotFound
+		}
+		return nil, err
+	}
+
+	u.invalidate()
+	return emotion, nil
+}
+
+// DeactivateEmotion hides an emotion from new entries. It isn't deleted because
+// existing mood entries still carry its label.
+func (u *emotionUsecase) DeactivateEmotion(id int) error {
+	emotion, err := u.emotionRepo.GetEmotionByID(id)
+	if err != nil {
+		return err
+	}
+	if emotion == nil {
+		return ErrEmotionNotFound
+	}
+
+	emotion.IsActive = false
+	if err := u.emotionRepo.UpdateEmotion(emotion); err != nil {
+		return err
+	}
+
+	u.invalidate()
+	return nil
+}
+
+func (u *emotionUsecase) validateEmotion(emotion *domain.Emotion) error {
+	if emotion.Valence < 0 || emotion.Valence > 1 {
+		return errors.New("valence must be between 0.0 and 1.0")
+	}
+	if emotion.Arousal < 0 || emotion.Arousal > 1 {
+		return errors.New("arousal must be between 0.0 and 1.0")
+	}
+
+	emotion.NameID = strings.TrimSpace(emotion.NameID)
+	emotion.NameEN = strings.TrimSpace(emotion.NameEN)
+	if emotion.NameID == "" || emotion.NameEN == "" {
+		return errors.New("name_id and name_en are required")
+	}
+
+	// Model labels are matched case-insensitively and may only point at one emotion
+	taxonomy, err := u.load()
+	if err != nil {
+		return err
+	}
+
+	labels := []string{}
+	seen := map[string]bool{}
+	for _, label := range emotion.ModelLabels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || seen[label] {
+			continue
+		}
+		if other, ok := taxonomy.byModelLabel[label]; ok && other.ID != emotion.ID {
+			return fmt.Errorf("model label %q is already mapped to %s", label, other.Label)
+		}
+		seen[label] = true
+		labels = append(labels, label)
+	}
+	emotion.ModelLabels = labels
+
+	return nil
+}
+
+func (u *emotionUsecase) CreateEntryType(entryType *domain.MoodEntryType) (*domain.MoodEntryType, error) {
+	entryType.EntryType = strings.ToLower(strings.TrimSpace(entryType.EntryType))
+	if !taxonomyLabelPattern.MatchString(entryType.EntryType) {
+		return nil, errors.New("entry_type must be lowercase letters, digits or underscores")
+	}
+	if strings.TrimSpace(entryType.NameID) == "" || strings.TrimSpace(entryType.NameEN) == "" {
+		return nil, errors.New("name_id and name_en are required")
+	}
+
+	created, err := u.emotionRepo.CreateEntryType(entryType)
+	if err != nil {
+		return nil, err
+	}
+
+	u.invalidate()
+	return created, nil
+}
+
+func (u *emotionUsecase) UpdateEntryType(entryType *domain.MoodEntryType) (*domain.MoodEntryType, error) {
+	if strings.TrimSpace(entryType.NameID) == "" || strings.TrimSpace(entryType.NameEN) == "" {
+		return nil, errors.New("name_id and name_en are required")
+	}
+
+	if err := u.emotionRepo.UpdateEntryType(entryType); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEntryTypeNotFound
+		}
+		return nil, err
+	}
+
+	u.invalidate()
+	return entryType, nil
+}
+
+// ResolveEmotion finds the active emotion for a label a user submitted. Model labels
+// are accepted too, so "happy" resolves to joy. Returns nil when nothing matches.
+func (u *emotionUsecase) ResolveEmotion(label string) (*domain.Emotion, error) {
+	taxonomy, err := u.load()
+	if err != nil {
+		return nil, err
+	}
+
+	label = strings.ToLower(strings.TrimSpace(label))
+	if emotion, ok := taxonomy.byLabel[label]; ok {
+		return emotion, nil
+	}
+	return taxonomy.byModelLabel[label], nil
+}
+
+// MapModelLabel maps a label predicted by the mood model to an emotion, or nil when unmapped
+func (u *emotionUsecase) MapModelLabel(modelLabel string) (*domain.Emotion, error) {
+	taxonomy, err := u.load()
+	if err != nil {
+		return nil, err
+	}
+	return taxonomy.byModelLabel[strings.ToLower(strings.TrimSpace(modelLabel))], nil
+}
+
+func (u *emotionUsecase) IsValidEntryType(entryType string) (bool, error) {
+	taxonomy, err := u.load()
+	if err != nil {
+		return false, err
+	}
+	return taxonomy.entryTypes[entryType], nil
+}
+
+// load returns the cached taxonomy, reading it from the database when it is missing or stale
+func (u *emotionUsecase) load() (*emotionTaxonomy, error) {
+	u.mu.RLock()
+	taxonomy := u.taxonomy
+	u.mu.RUnlock()
+
+	if taxonomy != nil && time.Since(taxonomy.loadedAt) < emotionCacheTTL {
+		return taxonomy, nil
+	}
+
+	emotions, err := u.emotionRepo.GetEmotions(false)
+	if err != nil {
+		return nil, err
+	}
+	entryTypes, err := u.emotionRepo.GetEntryTypes(false)
+	if err != nil {
+		return nil, err
+	}
+
+	taxonomy = &emotionTaxonomy{
+		byLabel:      make(map[string]*domain.Emotion, len(emotions)),
+		byModelLabel: map[string]*domain.Emotion{},
+		entryTypes:   make(map[string]bool, len(entryTypes)),
+		loadedAt:     time.Now(),
+	}
+	for _, emotion := range emotions {
+		taxonomy.byLabel[emotion.Label] = emotion
+		for _, modelLabel := range emotion.ModelLabels {
+			taxonomy.byModelLabel[modelLabel] = emotion
+		}
+	}
+	for _, entryType := range entryTypes {
+		taxonomy.entryTypes[entryType.EntryType] = true
+	}
+
+	u.mu.Lock()
+	u.taxonomy = taxonomy
+	u.mu.Unlock()
+
+	return taxonomy, nil
+}
+
+func (u *emotionUsecase) invalidate() {
+	u.mu.Lock()
+	u.taxonomy = nil
+	u.mu.Unlock()
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Mood entry sources
+const (
+	MoodSourceJournal = "journal"
+	MoodSourceManual  = "manual"
+	MoodSourceFacial  = "facial"
+)
+
+const (
+	maxFacialReadingsPerBatch = 500
+	// Browsers may queue readings while offline, older ones are rejected
+	maxFacialReadingAge = 7 * 24 * time.Hour
+	// Allowed clock skew between the browser and the server
+	facialClockSkew = 5 * time.Minute
+	// Facial check-ins are single moments rather than a daily summary
+	facialEntryType = "event"
+)
+
+var facialSessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+var (
+	// ErrInvalidFacialReadings is wrapped by every error about a malformed batch
+	ErrInvalidFacialReadings = errors.New("invalid facial readings")
+	// ErrNoRecognisedEmotions is returned when no score in a session maps to a taxonomy emotion
+	ErrNoRecognisedEmotions = errors.New("no recognised emotions in the readings")
+)
+
+type facialUsecase struct {
+	facialRepo     postgres.FacialReadingRepository
+	moodRepo       postgres.MoodRepository
+	emotionUsecase EmotionUsecase
+}
+
+// FacialUsecase interface
+type FacialUsecase interface {
+	IngestReadings(userID int, sessionID string, readings []*domain.FacialEmotionReading) (*domain.FacialSessionResult, error)
+}
+
+// NewFacialUsecase creates a new facial reading use case
+func NewFacialUsecase(facialRepo postgres.FacialReadingRepository, moodRepo postgres.MoodRepository, emotionUsecase EmotionUsecase) FacialUsecase {
+	return &facialUsecase{
+		facialRepo:     facialRepo,
+		moodRepo:       moodRepo,
+		emotionUsecase: emotionUsecase,
+	}
+}
+
+// IngestReadings stores a batch of expression scores and re-aggregates the whole session
+// into one mood entry. The dominant emotion becomes the primary emotion and the
+// score-weighted valence becomes the intensity, the same scale the journal model uses.
+func (u *facialUsecase) IngestReadings(userID int, sessionID string, readings []*domain.FacialEmotionReading) (*domain.FacialSessionResult, error) {
+	if !facialSessionIDPattern.MatchString(sessionID) {
+		return nil, fmt.Errorf("%w: session_id must be 8 to 64 letters, digits, dashes or underscores", ErrInvalidFacialReadings)
+	}
+	if len(readings) == 0 {
+		return nil, fmt.Errorf("%w: at least one reading is required", ErrInvalidFacialReadings)
+	}
+	if len(readings) > maxFacialReadingsPerBatch {
+		return nil, fmt.Errorf("%w: at most %d readings can be sent at once", ErrInvalidFacialReadings, maxFacialReadingsPerBatch)
+	}
+
+	now := time.Now()
+	for i, reading := range readings {
+		if reading == nil || reading.CapturedAt.IsZero() {
+			return nil, fmt.Errorf("%w: reading %d has no captured_at", ErrInvalidFacialReadings, i)
+		}
+		if reading.CapturedAt.After(now.Add(facialClockSkew)) {
+			return nil, fmt.Errorf("%w: reading %d is in the future", ErrInvalidFacialReadings, i)
+		}
+		if reading.CapturedAt.Before(now.Add(-maxFacialReadingAge)) {
+			return nil, fmt.Errorf("%w: reading %d is older than 7 days", ErrInvalidFacialReadings, i)
+		}
+
+		scores := make(map[string]float64, len(reading.Scores))
+		for label, score := range reading.Scores {
+			if score < 0 || score > 1 || math.IsNaN(score) {
+				return nil, fmt.Errorf("%w: reading %d: score for %q must be between 0.0 and 1.0", ErrInvalidFacialReadings, i, label)
+			}
+			scores[strings.ToLower(strings.TrimSpace(label))] = score
+		}
+		if len(scores) == 0 {
+			return nil, fmt.Errorf("%w: reading %d has no scores", ErrInvalidFacialReadings, i)
+		}
+		reading.Scores = scores
+	}
+
+	accepted, err := u.facialRepo.SaveReadings(userID, sessionID, readings)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionReadings, err := u.facialRepo.GetReadingsBySession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, dominant, intensity, err := u.aggregate(sessionReadings)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := u.saveEntry(userID, sessionID, dominant, intensity, sessionReadings[0].CapturedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.FacialSessionResult{
+		SessionID:    sessionID,
+		ReadingCount: len(sessionReadings),
+		Accepted:     accepted,
+		Scores:       scores,
+		Entry:        entry,
+	}, nil
+}
+
+// aggregate averages the scores per taxonomy emotion over all readings of a session.
+// Expression labels the taxonomy doesn't know are ignored.
+func (u *facialUsecase) aggregate(readings []*domain.FacialEmotionReading) (map[string]float64, string, float64, error) {
+	sums := map[string]float64{}
+	valences := map[string]float64{}
+
+	for _, reading := range readings {
+		for label, score := range reading.Scores {
+			emotion, err := u.emotionUsecase.MapModelLabel(label)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			if emotion == nil {
+				continue
+			}
+			sums[emotion.Label] += score
+			valences[emotion.Label] = emotion.Valence
+		}
+	}
+
+	if len(sums) == 0 {
+		return nil, "", 0, ErrNoRecognisedEmotions
+	}
+
+	scores := make(map[string]float64, len(sums))
+	dominant := ""
+	var total, weighted float64
+	for label, sum := range sums {
+		average := sum / float64(len(readings))
+		scores[label] = math.Round(average*10000) / 10000
+		total += average
+		weighted += average * valences[label]
+		if dominant == "" || average > sums[dominant]/float64(len(readings)) {
+			dominant = label
+		}
+	}
+
+	intensity := valences[dominant]
+	if total > 0 {
+		intensity = weighted / total
+	}
+
+	return scores, dominant, math.Round(intensity*100) / 100, nil
+}
+
+// saveEntry creates the session's mood entry or updates it when a later batch arrives
+func (u *facialUsecase) saveEntry(userID int, sessionID, emotion string, intensity float64, recordedAt time.Time) (*domain.MoodEntry, error) {
+	entry, err := u.moodRepo.GetByFacialSessionID(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		created, err := u.moodRepo.Create(&domain.MoodEntry{
+			UserID:          userID,
+			EntryType:       facialEntryType,
+			RecordedAt:      recordedAt,
+			PrimaryEmotion:  emotion,
+			IntensityLevel:  intensity,
+			Source:          MoodSourceFacial,
+			FacialSessionID: sessionID,
+		})
+		if err == nil {
+			return created, nil
+		}
+
+		// Another batch of the same session may have created it first
+		entry, lookupErr := u.moodRepo.GetByFacialSessionID(userID, sessionID)
+		if lookupErr != nil || entry == nil {
+			return nil, err
+		}
+		log.Printf("Facial session %s was created concurrently, updating it instead", sessionID)
+		return u.updateEntry(entry, emotion, intensity, recordedAt)
+	}
+
+	return u.updateEntry(entry, emotion, intensity, recordedAt)
+}
+
+func (u *facialUsecase) updateEntry(entry *domain.MoodEntry, emotion string, intensity float64, recordedAt time.Time) (*domain.MoodEntry, error) {
+	if entry.PrimaryEmotion == emotion && entry.IntensityLevel == intensity && entry.RecordedAt.Equal(recordedAt) {
+		return entry, nil
+	}
+
+	entry.PrimaryEmotion = emotion
+	entry.IntensityLevel = intensity
+	entry.RecordedAt = recordedAt
+	if err := u.moodRepo.UpdateFacialAggregate(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// ErrJournalAIDisabled is returned when the user hasn't opted in to the requested AI feature
+var ErrJournalAIDisabled = errors.New("AI reflections are not enabled for this user")
+
+const (
+	reflectionMaxTokens = 300
+	summaryMaxTokens    = 800
+	// summaryEntryCharLimit keeps long entries from blowing up the weekly prompt
+	summaryEntryCharLimit = 1500
+)
+
+const reflectionPrompt = `You are MindCareBot 🌿, a warm and empathetic journaling companion.
+Read the journal entry below and write a short reflection (3-5 sentences) for its author.
+- Acknowledge and validate the feelings they describe
+- Gently point out one strength, insight or pattern you notice
+- End with one open question they could reflect on next
+- Never diagnose, and don't give medical advice
+- Reply in the same language the entry is written in
+
+Journal entry:
+"""
+%s
+"""`
+
+const weeklySummaryPrompt = `You are MindCareBot 🌿, a warm and empathetic journaling companion.
+Below are a user's journal entries and recorded moods from %s to %s.
+Write a weekly summary addressed to the user. Reply in the language most entries are written in,
+never diagnose, and keep a supportive tone.
+
+Respond with a JSON object with exactly these fields:
+- "summary": 3-5 sentences summarizing their week
+- "themes": up to 5 short recurring themes
+- "mood_shifts": 1-3 sentences describing how their mood changed over the week
+- "suggested_prompts": 3 journaling prompts for the coming week
+
+Journal entries:
+%s
+Recorded moods:
+%s`
+
+type journalAIUsecase struct {
+	journalRepo postgres.JournalRepository
+	moodRepo    postgres.MoodRepository
+	aiRepo      postgres.JournalAIRepository
+	llm         LLMProvider
+	usage       LLMUsageUsecase
+}
+
+// JournalAIUsecase interface
+type JournalAIUsecase interface {
+	GetSettings(userID int) (*domain.JournalAISettings, error)
+	UpdateSettings(userID int, reflectionsEnabled, weeklySummaryEnabled bool) (*domain.JournalAISettings, error)
+	GenerateReflection(ctx context.Context, journalID, userID int) (*domain.JournalReflection, error)
+	GetReflection(journalID, userID int) (*domain.JournalReflection, error)
+	GetSummaries(userID int, limit, offset int) ([]*domain.JournalSummary, int, error)
+	GenerateWeeklySummaries(ctx context.Context, now time.Time) (int, error)
+}
+
+// NewJournalAIUsecase creates a new journal AI use case
+func NewJournalAIUsecase(journalRepo postgres.JournalRepository, moodRepo postgres.MoodRepository, aiRepo postgres.JournalAIRepository, llm LLMProvider, usage LLMUsageUsecase) JournalAIUsecase {
+	return &journalAIUsecase{
+		journalRepo: journalRepo,
+		moodRepo:    moodRepo,
+		aiRepo:      aiRepo,
+		llm:         llm,
+		usage:       usage,
+	}
+}
+
+func (u *journalAIUsecase) GetSettings(userID int) (*domain.JournalAISettings, error) {
+	return u.aiRepo.GetSettings(userID)
+}
+
+func (u *journalAIUsecase) UpdateSettings(userID int, reflectionsEnabled, weeklySummaryEnabled bool) (*domain.JournalAISettings, error) {
+	settings := &domain.JournalAISettings{
+		UserID:               userID,
+		ReflectionsEnabled:   reflectionsEnabled,
+		WeeklySummaryEnabled: weeklySummaryEnabled,
+	}
+	if err := u.aiRepo.UpsertSettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// GenerateReflection asks the model for a short empathetic reflection on one journal entry
+func (u *journalAIUsecase) GenerateReflection(ctx context.Context, journalID, userID int) (*domain.JournalReflection, error) {
+	settings, err := u.aiRepo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.ReflectionsEnabled {
+		return nil, ErrJournalAIDisabled
+	}
+
+	journal, err := u.journalRepo.GetByID(journalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if journal == nil {
+		return nil, errors.New("journal entry not found or does not belong to user")
+	}
+	if strings.TrimSpace(journal.Content) == "" {
+		return nil, errors.New("journal entry is empty")
+	}
+
+	if err := u.usage.CheckQuota(userID); err != nil {
+		return nil, err
+	}
+
+	resp, err := generateFromPrompt(ctx, u.llm, fmt.Sprintf(reflectionPrompt, journal.Content), reflectionMaxTokens, false)
+	if err != nil {
+		return nil, fmt.Errorf("AI service error: %w", err)
+	}
+	u.usage.Record(userID, LLMFeatureJournalReflection, resp)
+
+	return u.aiRepo.UpsertReflection(&domain.JournalReflection{
+		JournalID: journal.ID,
+		UserID:    userID,
+		Content:   resp.Text,
+		Model:     resp.Model,
+	})
+}
+
+func (u *journalAIUsecase) GetReflection(journalID, userID int) (*domain.JournalReflection, error) {
+	return u.aiRepo.GetReflectionByJournalID(journalID, userID)
+}
+
+func (u *journalAIUsecase) GetSummaries(userID int, limit, offset int) ([]*domain.JournalSummary, int, error) {
+	return u.aiRepo.GetSummariesByUserID(userID, limit, offset)
+}
+
+// GenerateWeeklySummaries writes last week's summary for every opted-in user that doesn't have
+// one yet. Weeks run Monday to Sunday in UTC. Returns the number of summaries created.
+func (u *journalAIUsecase) GenerateWeeklySummaries(ctx context.Context, now time.Time) (int, error) {
+	userIDs, err := u.aiRepo.GetUserIDsWithWeeklySummary()
+	if err != nil {
+		return 0, err
+	}
+
+	periodStart, periodEnd := previousWeek(now)
+	created := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return created, ctx.Err()
+		}
+
+		exists, err := u.aiRepo.SummaryExists(userID, periodStart)
+		if err != nil {
+			return created, err
+		}
+		if exists {
+			continue
+		}
+
+		summary, err := u.generateWeeklySummary(ctx, userID, periodStart, periodEnd)
+		if err != nil {
+			log.Printf("ERROR: Weekly journal summary for user %d failed: %v", userID, err)
+			continue
+		}
+		if summary != nil {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// weeklySummaryOutput is the JSON shape the model is asked to produce
+type weeklySummaryOutput struct {
+	Summary          string   `json:"summary"`
+	Themes           []string `json:"themes"`
+	MoodShifts       string   `json:"mood_shifts"`
+	SuggestedPrompts []string `json:"suggested_prompts"`
+}
+
+func (u *journalAIUsecase) generateWeeklySummary(ctx context.Context, userID int, periodStart, periodEnd time.Time) (*domain.JournalSummary, error) {
+	// periodEnd is the last day of the week, include all of it
+	rangeEnd := periodEnd.Add(24*time.Hour - time.Nanosecond)
+
+	journals, _, err := u.journalRepo.GetByUserID(userID, 100, 0, periodStart, rangeEnd)
+	if err != nil {
+		return nil, err
+	}
+	if len(journals) == 0 {
+		return nil, nil // Nothing to summarize this week
+	}
+
+	moods, _, err := u.moodRepo.GetByUserID(userID, 200, 0, periodStart, rangeEnd, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var entriesText strings.Builder
+	// Repositories return newest first, the model reads the week in order
+	for i := len(journals) - 1; i >= 0; i-- {
+		content := truncateRunes(journals[i].Content, summaryEntryCharLimit, "...")
+		fmt.Fprintf(&entriesText, "[%s] %s\n", journals[i].CreatedAt.Format("Mon 2006-01-02"), content)
+	}
+
+	var moodsText strings.Builder
+	for i := len(moods) - 1; i >= 0; i-- {
+		fmt.Fprintf(&moodsText, "[%s] %s (intensity %.2f)\n", moods[i].RecordedAt.Format("Mon 2006-01-02"), moods[i].PrimaryEmotion, moods[i].IntensityLevel)
+	}
+	if moodsText.Len() == 0 {
+		moodsText.WriteString("(none recorded)\n")
+	}
+
+	prompt := fmt.Sprintf(weeklySummaryPrompt, periodStart.Format("2006-01-02"), periodEnd.Format("2006-01-02"), entriesText.String(), moodsText.String())
+	resp, err := generateFromPrompt(ctx, u.llm, prompt, summaryMaxTokens, true)
+	if err != nil {
+		return nil, fmt.Errorf("AI service error: %w", err)
+	}
+	u.usage.Record(userID, LLMFeatureJournalSummary, resp)
+
+	var output weeklySummaryOutput
+	if err := json.Unmarshal([]byte(resp.Text), &output); err != nil || output.Summary == "" {
+		// Keep whatever the model wrote rather than losing the week
+		log.Printf("WARN: Weekly summary for user %d was not valid JSON, storing raw text", userID)
+		output = weeklySummaryOutput{Summary: resp.Text}
+	}
+
+	return u.aiRepo.CreateSummary(&domain.JournalSummary{
+		UserID:           userID,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		EntryCount:       len(journals),
+		Summary:          output.Summary,
+		Themes:           output.Themes,
+		MoodShifts:       output.MoodShifts,
+		SuggestedPrompts: output.SuggestedPrompts,
+		Model:            resp.Model,
+	})
+}
+
+// previousWeek returns the Monday and Sunday (UTC dates) of the last complete week before now
+func previousWeek(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	daysSinceMonday := (int(today.Weekday()) + 6) % 7
+	thisMonday := today.AddDate(0, 0, -daysSinceMonday)
+	return thisMonday.AddDate(0, 0, -7), thisMonday.AddDate(0, 0, -1)
+}
+
+// truncateRunes shortens s to at most limit characters without splitting a UTF-8 sequence,
+// adding suffix when anything was cut
+func truncateRunes(s string, limit int, suffix string) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + suffix
+}
@@ -0,0 +1,521 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+)
+
+const (
+	// MaxJournalImportSize is the largest import file accepted (20 MB)
+	MaxJournalImportSize = 20 << 20
+	// maxJournalImportUnzipped caps the bytes read from all files of a ZIP import together,
+	// so a small archive of highly compressible files can't fill the memory
+	maxJournalImportUnzipped = 4 * MaxJournalImportSize
+	// maxJournalImportEntries caps the number of entries in one import
+	maxJournalImportEntries = 5000
+	// moodAnalysisBatchSize is how many queued journals one run of the mood analysis job takes
+	moodAnalysisBatchSize = 100
+	// maxMoodAnalysisAttempts is how often a journal's mood analysis may fail before it is left out
+	maxMoodAnalysisAttempts = 5
+)
+
+// JournalImportOptions holds the uploaded file and the import flags
+type JournalImportOptions struct {
+	Format      string // markdown, dayone, csv; detected from the file name when empty
+	FileName    string
+	Data        []byte
+	Timezone    string // Used for dates without an explicit offset, defaults to UTC
+	DryRun      bool
+	AnalyzeMood bool
+}
+
+// Date layouts accepted in front matter and CSV columns
+var importDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+var fileNameDatePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// Import parses a Markdown ZIP, Day One JSON export or CSV file and saves the entries
+// with their original timestamps. With DryRun nothing is saved and the parsed entries are returned.
+func (u *journalUsecase) Import(userID int, opts JournalImportOptions) (*domain.JournalImportResult, error) {
+	if len(opts.Data) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+
+	loc := time.UTC
+	if opts.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(opts.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %s", opts.Timezone)
+		}
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = detectImportFormat(opts.FileName, opts.Data)
+	}
+
+	var (
+		entries []*domain.JournalImportEntry
+		issues  []*domain.JournalImportIssue
+		err     error
+	)
+	switch format {
+	case "markdown":
+		entries, issues, err = parseMarkdownZip(opts.Data, loc)
+	case "dayone":
+		entries, issues, err = parseDayOneExport(opts.Data)
+	case "csv":
+		entries, issues, err = parseJournalCSV(opts.Data, loc)
+	default:
+		return nil, errors.New("unsupported import format, expected markdown, dayone or csv")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkImportEntries(len(entries)); err != nil {
+		return nil, err
+	}
+
+	result := &domain.JournalImportResult{
+		Format:  format,
+		DryRun:  opts.DryRun,
+		Parsed:  len(entries),
+		Skipped: issues,
+	}
+	if result.Skipped == nil {
+		result.Skipped = []*domain.JournalImportIssue{}
+	}
+
+	if opts.DryRun {
+		result.Entries = entries
+		return result, nil
+	}
+
+	if len(entries) == 0 {
+		return result, nil
+	}
+
+	journals := make([]*domain.Journal, 0, len(entries))
+	for _, entry := range entries {
+		journals = append(journals, &domain.Journal{
+			UserID:    userID,
+			Content:   entry.Content,
+			CreatedAt: entry.CreatedAt,
+			UpdatedAt: entry.CreatedAt,
+		})
+	}
+
+	if err := u.journalRepo.BulkCreate(journals, opts.AnalyzeMood); err != nil {
+		return nil, fmt.Errorf("failed to save imported journals: %w", err)
+	}
+	result.Imported = len(journals)
+	result.Journals = journals
+
+	if opts.AnalyzeMood {
+		result.MoodAnalysisQueued = len(journals)
+	}
+
+	return result, nil
+}
+
+// AnalyzeQueuedMoods runs the mood analysis of imported journals that are still queued,
+// one at a time so the mood model isn't flooded. The queue lives on the journals, so
+// nothing is lost on a restart. A failed journal stays queued for the next run until it
+// runs out of attempts.
+func (u *journalUsecase) AnalyzeQueuedMoods(ctx context.Context) (int, error) {
+	journals, err := u.journalRepo.GetQueuedMoodAnalysis(maxMoodAnalysisAttempts, moodAnalysisBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	analyzed := 0
+	for _, journal := range journals {
+		if ctx.Err() != nil {
+			return analyzed, ctx.Err()
+		}
+
+		if err := u.analyzeQueuedMood(journal); err != nil {
+			log.Printf("ERROR: Mood analysis for imported journal %d failed: %v", journal.ID, err)
+			if err := u.journalRepo.FailMoodAnalysis(journal.ID); err != nil {
+				return analyzed, err
+			}
+			continue
+		}
+
+		if err := u.journalRepo.FinishMoodAnalysis(journal.ID); err != nil {
+			return analyzed, err
+		}
+		analyzed++
+	}
+
+	return analyzed, nil
+}
+
+// analyzeQueuedMood predicts the mood of an imported journal and saves it at the journal's date
+func (u *journalUsecase) analyzeQueuedMood(journal *domain.Journal) error {
+	predictedMood, intensity, err := u.predictMood(journal.Content)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.moodUsecase.CreateWithRecordedAt(
+		journal.UserID,
+		journal.ID,
+		"journal",
+		predictedMood,
+		intensity,
+		"", "",
+		journal.CreatedAt,
+	)
+	return err
+}
+
+// detectImportFormat guesses the format from the file name, looking inside ZIPs when needed
+func detectImportFormat(fileName string, data []byte) string {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".json":
+		return "dayone"
+	case ".csv":
+		return "csv"
+	case ".zip":
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return ""
+		}
+		for _, f := range zr.File {
+			if strings.EqualFold(path.Ext(f.Name), ".json") {
+				return "dayone"
+			}
+		}
+		return "markdown"
+	}
+	return ""
+}
+
+// parseMarkdownZip reads every Markdown file in a ZIP archive. The date comes from the
+// front matter (date, created, created_at) or, failing that, a YYYY-MM-DD in the file name.
+func parseMarkdownZip(data []byte, loc *time.Location) ([]*domain.JournalImportEntry, []*domain.JournalImportIssue, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid ZIP file: %w", err)
+	}
+
+	var entries []*domain.JournalImportEntry
+	var issues []*domain.JournalImportIssue
+	budget := int64(maxJournalImportUnzipped)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".md" && ext != ".markdown" && ext != ".txt" {
+			continue
+		}
+		if err := checkImportEntries(len(entries)); err != nil {
+			return nil, nil, err
+		}
+
+		raw, err := readZipFile(f, &budget)
+		if errors.Is(err, errJournalImportTooLarge) {
+			return nil, nil, err
+		}
+		if err != nil {
+			issues = append(issues, &domain.JournalImportIssue{Source: f.Name, Reason: err.Error()})
+			continue
+		}
+
+		meta, body := splitFrontMatter(string(raw))
+		createdAt, err := frontMatterDate(meta, loc)
+		if err != nil {
+			issues = append(issues, &domain.JournalImportIssue{Source: f.Name, Reason: err.Error()})
+			continue
+		}
+		if createdAt.IsZero() {
+			if match := fileNameDatePattern.FindString(path.Base(f.Name)); match != "" {
+				createdAt, _ = parseImportDate(match, loc)
+			}
+		}
+		if createdAt.IsZero() {
+			issues = append(issues, &domain.JournalImportIssue{Source: f.Name, Reason: "no date in front matter or file name"})
+			continue
+		}
+
+		if title := meta["title"]; title != "" {
+			body = "# " + title + "\n\n" + body
+		}
+		body = strings.TrimSpace(body)
+		if body == "" {
+			issues = append(issues, &domain.JournalImportIssue{Source: f.Name, Reason: "empty entry"})
+			continue
+		}
+
+		entries = append(entries, &domain.JournalImportEntry{
+			Source:    f.Name,
+			Content:   body,
+			CreatedAt: createdAt,
+		})
+	}
+
+	return entries, issues, nil
+}
+
+// frontMatterDate returns the entry date from the front matter, or a zero time when there is none
+func frontMatterDate(meta map[string]string, loc *time.Location) (time.Time, error) {
+	for _, key := range []string{"date", "created", "created_at"} {
+		if value, ok := meta[key]; ok {
+			return parseImportDate(value, loc)
+		}
+	}
+	return time.Time{}, nil
+}
+
+// splitFrontMatter separates a leading "---" delimited key: value block from the Markdown body
+func splitFrontMatter(content string) (map[string]string, string) {
+	meta := map[string]string{}
+	content = strings.ReplaceAll(strings.TrimPrefix(content, "\ufeff"), "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return meta, content
+	}
+
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return meta, content // No closing delimiter, treat the whole file as body
+	}
+
+	for _, line := range strings.Split(rest[:end], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		meta[key] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	body := rest[end+len("\n---"):]
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		return meta, body[i+1:]
+	}
+	return meta, ""
+}
+
+// dayOneExport is the subset of the Day One JSON export we read
+type dayOneExport struct {
+	Entries []struct {
+		UUID         string `json:"uuid"`
+		CreationDate string `json:"creationDate"`
+		Text         string `json:"text"`
+	} `json:"entries"`
+}
+
+// parseDayOneExport reads a Day One JSON export, either the bare JSON or the ZIP Day One produces
+func parseDayOneExport(data []byte) ([]*domain.JournalImportEntry, []*domain.JournalImportIssue, error) {
+	var files [][]byte
+	var names []string
+	if zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		budget := int64(maxJournalImportUnzipped)
+		for _, f := range zr.File {
+			if !strings.EqualFold(path.Ext(f.Name), ".json") || strings.HasPrefix(f.Name, "__MACOSX/") {
+				continue
+			}
+			raw, err := readZipFile(f, &budget)
+			if errors.Is(err, errJournalImportTooLarge) {
+				return nil, nil, err
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+			}
+			files = append(files, raw)
+			names = append(names, f.Name)
+		}
+		if len(files) == 0 {
+			return nil, nil, errors.New("no Day One JSON file found in ZIP")
+		}
+	} else {
+		files = append(files, data)
+		names = append(names, "export.json")
+	}
+
+	var entries []*domain.JournalImportEntry
+	var issues []*domain.JournalImportIssue
+	for i, raw := range files {
+		var export dayOneExport
+		if err := json.Unmarshal(raw, &export); err != nil {
+			return nil, nil, fmt.Errorf("invalid Day One export %s: %w", names[i], err)
+		}
+		if err := checkImportEntries(len(entries) + len(export.Entries)); err != nil {
+			return nil, nil, err
+		}
+
+		for j, e := range export.Entries {
+			source := fmt.Sprintf("%s#%d", names[i], j+1)
+			if e.UUID != "" {
+				source = names[i] + "#" + e.UUID
+			}
+
+			createdAt, err := time.Parse(time.RFC3339, e.CreationDate)
+			if err != nil {
+				issues = append(issues, &domain.JournalImportIssue{Source: source, Reason: "invalid creationDate"})
+				continue
+			}
+			text := strings.TrimSpace(e.Text)
+			if text == "" {
+				issues = append(issues, &domain.JournalImportIssue{Source: source, Reason: "empty entry"})
+				continue
+			}
+
+			entries = append(entries, &domain.JournalImportEntry{
+				Source:    source,
+				Content:   text,
+				CreatedAt: createdAt,
+			})
+		}
+	}
+
+	return entries, issues, nil
+}
+
+// parseJournalCSV reads a CSV file with a header row. The date column may be named
+// date, created_at, creation_date or timestamp and the text column content, text, entry or body.
+func parseJournalCSV(data []byte, loc *time.Location) ([]*domain.JournalImportEntry, []*domain.JournalImportIssue, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+
+	dateCol, contentCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "date", "created_at", "creation_date", "timestamp":
+			if dateCol < 0 {
+				dateCol = i
+			}
+		case "content", "text", "entry", "body":
+			if contentCol < 0 {
+				contentCol = i
+			}
+		}
+	}
+	if dateCol < 0 || contentCol < 0 {
+		return nil, nil, errors.New("CSV header must contain a date and a content column")
+	}
+
+	var entries []*domain.JournalImportEntry
+	var issues []*domain.JournalImportIssue
+	row := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err := checkImportEntries(len(entries)); err != nil {
+			return nil, nil, err
+		}
+		row++
+		source := fmt.Sprintf("row %d", row)
+		if err != nil {
+			issues = append(issues, &domain.JournalImportIssue{Source: source, Reason: err.Error()})
+			continue
+		}
+		if dateCol >= len(record) || contentCol >= len(record) {
+			issues = append(issues, &domain.JournalImportIssue{Source: source, Reason: "missing columns"})
+			continue
+		}
+
+		createdAt, err := parseImportDate(record[dateCol], loc)
+		if err != nil {
+			issues = append(issues, &domain.JournalImportIssue{Source: source, Reason: err.Error()})
+			continue
+		}
+		content := strings.TrimSpace(record[contentCol])
+		if content == "" {
+			issues = append(issues, &domain.JournalImportIssue{Source: source, Reason: "empty entry"})
+			continue
+		}
+
+		entries = append(entries, &domain.JournalImportEntry{
+			Source:    source,
+			Content:   content,
+			CreatedAt: createdAt,
+		})
+	}
+
+	return entries, issues, nil
+}
+
+func parseImportDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+var errJournalImportTooLarge = fmt.Errorf("ZIP contents are larger than %d MB uncompressed", maxJournalImportUnzipped>>20)
+
+// checkImportEntries fails once an import has more than maxJournalImportEntries entries
+func checkImportEntries(count int) error {
+	if count > maxJournalImportEntries {
+		return fmt.Errorf("import contains more than %d entries", maxJournalImportEntries)
+	}
+	return nil
+}
+
+// readZipFile reads one file of an archive and takes its size from budget, the bytes left
+// for the whole archive. Running out of budget returns errJournalImportTooLarge.
+func readZipFile(f *zip.File, budget *int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	limit := int64(MaxJournalImportSize)
+	if *budget < limit {
+		limit = *budget
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > limit {
+		if limit == *budget {
+			return nil, errJournalImportTooLarge
+		}
+		return nil, fmt.Errorf("file is larger than %d MB uncompressed", MaxJournalImportSize>>20)
+	}
+
+	*budget -= int64(len(raw))
+	return raw, nil
+}
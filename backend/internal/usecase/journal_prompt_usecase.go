@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Prompt categories in the curated library
+var journalPromptCategories = []string{"gratitude", "cbt", "anxiety", "sleep"}
+
+// sleepKeywords in a trigger factor suggest the user is struggling with sleep
+var sleepKeywords = []string{"sleep", "insomnia", "tired", "tidur", "begadang", "lelah", "ngantuk"}
+
+const (
+	// promptMoodLookback is how far back recent moods are considered when choosing a prompt
+	promptMoodLookback = 7 * 24 * time.Hour
+	// lowMoodThreshold is the average intensity below which CBT prompts are offered
+	lowMoodThreshold = 0.4
+)
+
+type journalPromptUsecase struct {
+	promptRepo postgres.JournalPromptRepository
+	moodRepo   postgres.MoodRepository
+}
+
+// JournalPromptUsecase interface
+type JournalPromptUsecase interface {
+	GetPrompts(category, language string) ([]*domain.JournalPrompt, error)
+	GetTodayPrompt(userID int, language string, now time.Time) (*domain.DailyJournalPrompt, error)
+	GetStats(language string) ([]*domain.JournalPromptStats, error)
+}
+
+// NewJournalPromptUsecase creates a new journal prompt use case
+func NewJournalPromptUsecase(promptRepo postgres.JournalPromptRepository, moodRepo postgres.MoodRepository) JournalPromptUsecase {
+	return &journalPromptUsecase{
+		promptRepo: promptRepo,
+		moodRepo:   moodRepo,
+	}
+}
+
+func (u *journalPromptUsecase) GetPrompts(category, language string) ([]*domain.JournalPrompt, error) {
+	if category != "" && !isJournalPromptCategory(category) {
+		return nil, errors.New("invalid prompt category")
+	}
+	return u.promptRepo.GetPrompts(category, language)
+}
+
+// GetTodayPrompt picks a prompt category from the user's moods over the last week and then
+// a prompt within it. The pick is stable for the day so refreshing the page doesn't change it.
+func (u *journalPromptUsecase) GetTodayPrompt(userID int, language string, now time.Time) (*domain.DailyJournalPrompt, error) {
+	if language == "" {
+		language = "id"
+	}
+
+	entries, _, err := u.moodRepo.GetByUserID(userID, 50, 0, now.Add(-promptMoodLookback), now, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	category, reason := choosePromptCategory(entries)
+
+	prompts, err := u.promptRepo.GetPrompts(category, language)
+	if err != nil {
+		return nil, err
+	}
+	if len(prompts) == 0 {
+		// Fall back to any category in the requested language
+		prompts, err = u.promptRepo.GetPrompts("", language)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(prompts) == 0 {
+		return nil, errors.New("no prompts available for this language")
+	}
+
+	index := (userID + now.YearDay()) % len(prompts)
+	return &domain.DailyJournalPrompt{
+		Prompt:   prompts[index],
+		Category: prompts[index].Category,
+		Reason:   reason,
+	}, nil
+}
+
+func (u *journalPromptUsecase) GetStats(language string) ([]*domain.JournalPromptStats, error) {
+	return u.promptRepo.GetStats(language)
+}
+
+// choosePromptCategory maps recent mood entries to a prompt category
+func choosePromptCategory(entries []*domain.MoodEntry) (string, string) {
+	if len(entries) == 0 {
+		return "gratitude", "no_recent_moods"
+	}
+
+	emotionCounts := map[string]int{}
+	var totalIntensity float64
+	sleepMentions := 0
+	for _, entry := range entries {
+		emotionCounts[entry.PrimaryEmotion]++
+		totalIntensity += entry.IntensityLevel
+
+		trigger := strings.ToLower(entry.TriggerFactor)
+		for _, keyword := range sleepKeywords {
+			if strings.Contains(trigger, keyword) {
+				sleepMentions++
+				break
+			}
+		}
+	}
+
+	dominant, dominantCount := "", 0
+	for emotion, count := range emotionCounts {
+		if count > dominantCount || (count == dominantCount && emotion < dominant) {
+			dominant, dominantCount = emotion, count
+		}
+	}
+
+	switch {
+	case sleepMentions*3 >= len(entries):
+		return "sleep", "sleep_mentioned_in_triggers"
+	case dominant == "fear":
+		return "anxiety", "mostly_anxious"
+	case dominant == "sadness" || dominant == "anger":
+		return "cbt", "mostly_" + dominant
+	case totalIntensity/float64(len(entries)) < lowMoodThreshold:
+		return "cbt", "low_average_mood"
+	default:
+		return "gratitude", "mostly_positive"
+	}
+}
+
+func isJournalPromptCategory(category string) bool {
+	for _, c := range journalPromptCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
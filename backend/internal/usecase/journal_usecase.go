@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,46 +22,60 @@ type ExternalMoodModelResponse struct {
 	Mood string `json:"mood"`
 }
 
-// MoodToIntensityMap maps mood strings to intensity levels (0.0 to 1.0)
-var MoodToIntensityMap = map[string]float64{
-	"joy":      1.0, // 100
-	"love":     0.9, // 90
-	"surprise": 0.7, // 70
-	"fear":     0.3, // 30
-	"sadness":  0.2, // 20
-	"anger":    0.1, // 10
-	// Add other moods if the model returns them, or a default
-}
+// fallbackEmotionLabel is used when the mood model returns a label the taxonomy doesn't map
+const fallbackEmotionLabel = "neutral"
 
 // Removed const moodModelAPIURL
 
+// JournalVersionConflictError is returned when a journal was changed since the version the client edited
+type JournalVersionConflictError struct {
+	Current *domain.Journal
+}
+
+func (e *JournalVersionConflictError) Error() string {
+	return fmt.Sprintf("journal entry was modified by another device (current version %d)", e.Current.Version)
+}
+
+// ErrJournalNotInTrash is returned when restoring or purging a journal that isn't in the trash
+var ErrJournalNotInTrash = errors.New("journal entry not found in trash")
+
 type journalUsecase struct {
-	journalRepo postgres.JournalRepository
-	moodUsecase MoodUsecase
-	cfg         *config.Config // Added config dependency
+	journalRepo    postgres.JournalRepository
+	promptRepo     postgres.JournalPromptRepository
+	moodUsecase    MoodUsecase
+	emotionUsecase EmotionUsecase
+	cfg            *config.Config // Added config dependency
 }
 
 // JournalUsecase interface
 type JournalUsecase interface {
-	Create(userID int, content string) (*domain.Journal, error)
+	Create(userID int, content string, promptID int) (*domain.Journal, error)
 	GetByID(id int, userID int) (*domain.Journal, error)
 	GetAll(userID int, limit, offset int, startDate, endDate string) ([]*domain.Journal, int, error)
-	Update(id int, userID int, content string) (*domain.Journal, error)
+	Update(id int, userID int, content string, expectedVersion int) (*domain.Journal, error)
 	Delete(id int, userID int) error
-	AnalyzeAndSaveJournalWithMood(userID int, textContent string) (*domain.Journal, *domain.MoodEntry, error)
+	AnalyzeAndSaveJournalWithMood(userID int, textContent string, promptID int) (*domain.Journal, *domain.MoodEntry, error)
+	Import(userID int, opts JournalImportOptions) (*domain.JournalImportResult, error)
+	GetTrash(userID int, limit, offset int) ([]*domain.Journal, int, error)
+	Restore(id int, userID int) (*domain.Journal, error)
+	DeletePermanently(id int, userID int) error
+	PurgeTrash(retention time.Duration) (int64, error)
+	AnalyzeQueuedMoods(ctx context.Context) (int, error)
 }
 
 // NewJournalUsecase creates a new journal use case
-func NewJournalUsecase(journalRepo postgres.JournalRepository, moodUsecase MoodUsecase, cfg *config.Config) JournalUsecase { // Updated signature
+func NewJournalUsecase(journalRepo postgres.JournalRepository, promptRepo postgres.JournalPromptRepository, moodUsecase MoodUsecase, emotionUsecase EmotionUsecase, cfg *config.Config) JournalUsecase { // Updated signature
 	return &journalUsecase{
-		journalRepo: journalRepo,
-		moodUsecase: moodUsecase,
-		cfg:         cfg, // Initialize config
+		journalRepo:    journalRepo,
+		promptRepo:     promptRepo,
+		moodUsecase:    moodUsecase,
+		emotionUsecase: emotionUsecase,
+		cfg:            cfg, // Initialize config
 	}
 }
 
 // AnalyzeAndSaveJournalWithMood is the new method
-func (u *journalUsecase) AnalyzeAndSaveJournalWithMood(userID int, textContent string) (*domain.Journal, *domain.MoodEntry, error) {
+func (u *journalUsecase) AnalyzeAndSaveJournalWithMood(userID int, textContent string, promptID int) (*domain.Journal, *domain.MoodEntry, error) {
 	if textContent == "" {
 		log.Println("WARN: textContent for mood analysis is empty, model API might reject.")
 		// Model API kemungkinan akan mengembalikan 422 jika ini kosong.
@@ -68,22 +84,56 @@ func (u *journalUsecase) AnalyzeAndSaveJournalWithMood(userID int, textContent s
 
 	// 1. Panggil API model eksternal dengan field "text"
 	// textContent adalah input asli dari pengguna.
-	moodModelReqBody := map[string]string{"text": textContent} // <--- PERUBAHAN DI SINI
+	predictedMood, intensity, err := u.predictMood(textContent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 2. Simpan entri jurnal ke database Anda
+	// textContent (input asli pengguna) disimpan ke kolom 'content' di database Anda.
+	createdJournal, err := u.Create(userID, textContent, promptID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	// 3. Simpan entri mood
+	if u.moodUsecase == nil {
+		return createdJournal, nil, errors.New("moodUsecase is not initialized in journalUsecase")
+	}
+	createdMoodEntry, err := u.moodUsecase.Create(
+		userID,
+		createdJournal.ID,
+		"journal",
+		predictedMood,
+		intensity,
+		"", "",
+	)
+	if err != nil {
+		return createdJournal, nil, fmt.Errorf("failed to create mood entry: %w", err)
+	}
+
+	return createdJournal, createdMoodEntry, nil
+}
+
+// predictMood sends text to the external mood model and maps the predicted label to an
+// emotion from the taxonomy, whose valence becomes the intensity
+func (u *journalUsecase) predictMood(textContent string) (string, float64, error) {
+	moodModelReqBody := map[string]string{"text": textContent}
 	jsonMoodModelBody, err := json.Marshal(moodModelReqBody)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request body for mood API: %w", err)
+		return "", 0, fmt.Errorf("failed to marshal request body for mood API: %w", err)
 	}
 
 	moodModelURL := u.cfg.MoodModelAPIURL
 	if moodModelURL == "" {
-		return nil, nil, errors.New("mood model API URL is not configured")
+		return "", 0, errors.New("mood model API URL is not configured")
 	}
 
 	log.Printf("DEBUG: Sending to Mood Model API URL (%s) with payload: %s", moodModelURL, string(jsonMoodModelBody))
 
 	resp, err := http.Post(moodModelURL, "application/json", bytes.NewBuffer(jsonMoodModelBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to call mood prediction API (%s): %w", moodModelURL, err)
+		return "", 0, fmt.Errorf("failed to call mood prediction API (%s): %w", moodModelURL, err)
 	}
 	defer resp.Body.Close()
 
@@ -94,53 +144,51 @@ func (u *journalUsecase) AnalyzeAndSaveJournalWithMood(userID int, textContent s
 		}
 		errorBodyStr := string(errorBodyBytes)
 		log.Printf("ERROR: Mood prediction API at %s returned status %s. Body: %s", moodModelURL, resp.Status, errorBodyStr)
-		return nil, nil, fmt.Errorf("mood prediction API returned non-OK status: %s. Details: %s", resp.Status, errorBodyStr)
+		return "", 0, fmt.Errorf("mood prediction API returned non-OK status: %s. Details: %s", resp.Status, errorBodyStr)
 	}
 
 	var moodAPIResponse ExternalMoodModelResponse
 	if err := json.NewDecoder(resp.Body).Decode(&moodAPIResponse); err != nil {
-		return nil, nil, fmt.Errorf("failed to decode successful mood prediction API response: %w", err)
-	}
-
-	predictedMood := moodAPIResponse.Mood
-	intensity, ok := MoodToIntensityMap[predictedMood]
-	if !ok {
-		log.Printf("WARN: Unknown mood predicted: '%s'. Assigning neutral intensity.", predictedMood)
-		intensity = 0.5
+		return "", 0, fmt.Errorf("failed to decode successful mood prediction API response: %w", err)
 	}
 
-	// 2. Simpan entri jurnal ke database Anda
-	// textContent (input asli pengguna) disimpan ke kolom 'content' di database Anda.
-	createdJournal, err := u.Create(userID, textContent)
+	emotion, err := u.emotionUsecase.MapModelLabel(moodAPIResponse.Mood)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create journal entry: %w", err)
+		return "", 0, fmt.Errorf("failed to load emotion taxonomy: %w", err)
 	}
-
-	// 3. Simpan entri mood
-	if u.moodUsecase == nil {
-		return createdJournal, nil, errors.New("moodUsecase is not initialized in journalUsecase")
-	}
-	createdMoodEntry, err := u.moodUsecase.Create(
-		userID,
-		createdJournal.ID,
-		"journal",
-		predictedMood,
-		intensity,
-		"", "",
-	)
-	if err != nil {
-		return createdJournal, nil, fmt.Errorf("failed to create mood entry: %w", err)
+	if emotion == nil {
+		log.Printf("WARN: Unknown mood predicted: '%s'. Assigning %s.", moodAPIResponse.Mood, fallbackEmotionLabel)
+		emotion, err = u.emotionUsecase.ResolveEmotion(fallbackEmotionLabel)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to load emotion taxonomy: %w", err)
+		}
+		if emotion == nil {
+			return "", 0, fmt.Errorf("mood model returned unmapped label %q", moodAPIResponse.Mood)
+		}
 	}
 
-	return createdJournal, createdMoodEntry, nil
+	return emotion.Label, emotion.Valence, nil
 }
 
 // Implement or ensure Create, GetByID, GetAll, Update, Delete methods are complete as previously discussed
-func (u *journalUsecase) Create(userID int, content string) (*domain.Journal, error) {
+// Create saves a journal entry. A promptID > 0 records which daily prompt the entry answered.
+func (u *journalUsecase) Create(userID int, content string, promptID int) (*domain.Journal, error) {
 	journal := &domain.Journal{
 		UserID:  userID,
 		Content: content,
 	}
+
+	if promptID > 0 {
+		prompt, err := u.promptRepo.GetByID(promptID)
+		if err != nil {
+			return nil, err
+		}
+		if prompt == nil {
+			return nil, errors.New("journal prompt not found")
+		}
+		journal.PromptID = &prompt.ID
+	}
+
 	return u.journalRepo.Create(journal)
 }
 
@@ -170,7 +218,10 @@ func (u *journalUsecase) GetAll(userID int, limit, offset int, startDateStr, end
 	return u.journalRepo.GetByUserID(userID, limit, offset, startDate, endDate)
 }
 
-func (u *journalUsecase) Update(id int, userID int, content string) (*domain.Journal, error) {
+// Update changes a journal's content if it's still at expectedVersion. An expectedVersion of 0
+// skips the check (If-Match: *). On a mismatch a *JournalVersionConflictError with the stored
+// journal is returned so the client can merge.
+func (u *journalUsecase) Update(id int, userID int, content string, expectedVersion int) (*domain.Journal, error) {
 	journal, err := u.journalRepo.GetByID(id, userID)
 	if err != nil {
 		return nil, err
@@ -178,8 +229,23 @@ func (u *journalUsecase) Update(id int, userID int, content string) (*domain.Jou
 	if journal == nil {
 		return nil, errors.New("journal entry not found or does not belong to user")
 	}
+	if expectedVersion > 0 && journal.Version != expectedVersion {
+		return nil, &JournalVersionConflictError{Current: journal}
+	}
+
 	journal.Content = content
 	err = u.journalRepo.Update(journal)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Someone saved between our read and write
+		current, getErr := u.journalRepo.GetByID(id, userID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		if current == nil {
+			return nil, errors.New("journal entry not found or does not belong to user")
+		}
+		return nil, &JournalVersionConflictError{Current: current}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -195,3 +261,31 @@ func (u *journalUsecase) Delete(id int, userID int) error {
 	// }
 	return u.journalRepo.Delete(id, userID)
 }
+
+func (u *journalUsecase) GetTrash(userID int, limit, offset int) ([]*domain.Journal, int, error) {
+	return u.journalRepo.GetTrashByUserID(userID, limit, offset)
+}
+
+func (u *journalUsecase) Restore(id int, userID int) (*domain.Journal, error) {
+	err := u.journalRepo.Restore(id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJournalNotInTrash
+		}
+		return nil, err
+	}
+	return u.journalRepo.GetByID(id, userID)
+}
+
+func (u *journalUsecase) DeletePermanently(id int, userID int) error {
+	err := u.journalRepo.DeletePermanently(id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrJournalNotInTrash
+	}
+	return err
+}
+
+// PurgeTrash permanently deletes journals that have been in the trash longer than retention
+func (u *journalUsecase) PurgeTrash(retention time.Duration) (int64, error) {
+	return u.journalRepo.PurgeTrash(time.Now().Add(-retention))
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// geminiProvider talks to Google's Gemini generateContent API
+type geminiProvider struct {
+	llmOptions
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      float64 `json:"temperature"`
+	MaxOutputTokens  int     `json:"maxOutputTokens"`
+	ResponseMimeType string  `json:"responseMimeType,omitempty"`
+}
+
+type geminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+	SafetySettings    []geminiSafetySetting  `json:"safetySettings"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+var geminiSafetySettings = []geminiSafetySetting{
+	{Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_MEDIUM_AND_ABOVE"},
+	{Category: "HARM_CATEGORY_HATE_SPEECH", Threshold: "BLOCK_MEDIUM_AND_ABOVE"},
+	{Category: "HARM_CATEGORY_SEXUALLY_EXPLICIT", Threshold: "BLOCK_MEDIUM_AND_ABOVE"},
+	{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Threshold: "BLOCK_MEDIUM_AND_ABOVE"},
+}
+
+func (p *geminiProvider) Name() string {
+	return "gemini"
+}
+
+func (p *geminiProvider) Model() string {
+	return p.model
+}
+
+// buildRequest moves system messages into systemInstruction, Gemini calls the assistant "model"
+func (p *geminiProvider) buildRequest(req *LLMRequest) geminiRequest {
+	body := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     p.temperature,
+			MaxOutputTokens: p.maxTokensFor(req),
+		},
+		SafetySettings: geminiSafetySettings,
+	}
+	if req.JSONOutput {
+		body.GenerationConfig.ResponseMimeType = "application/json"
+	}
+
+	for _, message := range req.Messages {
+		switch message.Role {
+		case LLMRoleSystem:
+			if body.SystemInstruction == nil {
+				body.SystemInstruction = &geminiContent{}
+			}
+			body.SystemInstruction.Parts = append(body.SystemInstruction.Parts, geminiPart{Text: message.Content})
+		case LLMRoleAssistant:
+			body.Contents = append(body.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: message.Content}}})
+		default:
+			body.Contents = append(body.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: message.Content}}})
+		}
+	}
+
+	return body
+}
+
+func (p *geminiProvider) headers() (map[string]string, error) {
+	if p.apiKey == "" {
+		return nil, errors.New("no Gemini API key set, configure GEMINI_API_KEY or LLM_API_KEY")
+	}
+	return map[string]string{"x-goog-api-key": p.apiKey}, nil
+}
+
+func (p *geminiProvider) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	headers, err := p.headers()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, llmRequestTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", p.baseURL, p.model)
+	resp, err := postLLMJSON(ctx, p.httpClient, url, headers, p.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	var geminiResp geminiResponse
+	if err := decodeLLMJSON(resp, &geminiResp); err != nil {
+		return nil, err
+	}
+
+	result := &LLMResponse{Model: p.model}
+	p.collect(&geminiResp, result, nil)
+	result.Text = strings.TrimSpace(result.Text)
+	return result, nil
+}
+
+func (p *geminiProvider) Stream(ctx context.Context, req *LLMRequest, onChunk func(text string) error) (*LLMResponse, error) {
+	headers, err := p.headers()
+	if err != nil {
+		return nil, err
+	}
+
+	// alt=sse makes Gemini send one "data: {...}" line per chunk instead of a JSON array
+	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", p.baseURL, p.model)
+	resp, err := postLLMJSON(ctx, p.httpClient, url, headers, p.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &LLMResponse{Model: p.model}
+	err = scanLLMLines(resp.Body, true, func(line string) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		return p.collect(&chunk, result, onChunk)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// collect appends the text of the first candidate to result and keeps the latest usage.
+// Streamed chunks report the running usage, so the last one holds the totals.
+func (p *geminiProvider) collect(resp *geminiResponse, result *LLMResponse, onChunk func(text string) error) error {
+	if resp.UsageMetadata.PromptTokenCount > 0 {
+		result.PromptTokens = resp.UsageMetadata.PromptTokenCount
+		result.CompletionTokens = resp.UsageMetadata.CandidatesTokenCount
+	}
+	if len(resp.Candidates) == 0 {
+		return nil
+	}
+
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if part.Text == "" {
+			continue
+		}
+		result.Text += part.Text
+		if onChunk != nil {
+			if err := onChunk(part.Text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
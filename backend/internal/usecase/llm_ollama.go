@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ollamaProvider talks to a local Ollama server's chat API
+type ollamaProvider struct {
+	llmOptions
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaRequest struct {
+	Model    string             `json:"model"`
+	Messages []ollamaMessage    `json:"messages"`
+	Stream   bool               `json:"stream"`
+	Format   string             `json:"format,omitempty"`
+	Options  map[string]float64 `json:"options"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func (p *ollamaProvider) Name() string {
+	return "ollama"
+}
+
+func (p *ollamaProvider) Model() string {
+	return p.model
+}
+
+func (p *ollamaProvider) buildRequest(req *LLMRequest, stream bool) ollamaRequest {
+	body := ollamaRequest{
+		Model:  p.model,
+		Stream: stream,
+		Options: map[string]float64{
+			"temperature": p.temperature,
+			"num_predict": float64(p.maxTokensFor(req)),
+		},
+	}
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, ollamaMessage{Role: message.Role, Content: message.Content})
+	}
+	if req.JSONOutput {
+		body.Format = "json"
+	}
+	return body
+}
+
+func (p *ollamaProvider) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, llmRequestTimeout)
+	defer cancel()
+
+	resp, err := postLLMJSON(ctx, p.httpClient, p.baseURL+"/api/chat", nil, p.buildRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResp ollamaResponse
+	if err := decodeLLMJSON(resp, &ollamaResp); err != nil {
+		return nil, err
+	}
+
+	return &LLMResponse{
+		Text:             strings.TrimSpace(ollamaResp.Message.Content),
+		Model:            p.model,
+		PromptTokens:     ollamaResp.PromptEvalCount,
+		CompletionTokens: ollamaResp.EvalCount,
+	}, nil
+}
+
+// Stream reads Ollama's newline delimited JSON, the final object has done set and the usage
+func (p *ollamaProvider) Stream(ctx context.Context, req *LLMRequest, onChunk func(text string) error) (*LLMResponse, error) {
+	resp, err := postLLMJSON(ctx, p.httpClient, p.baseURL+"/api/chat", nil, p.buildRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &LLMResponse{Model: p.model}
+	err = scanLLMLines(resp.Body, false, func(line string) error {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Done {
+			result.PromptTokens = chunk.PromptEvalCount
+			result.CompletionTokens = chunk.EvalCount
+		}
+		if chunk.Message.Content == "" {
+			return nil
+		}
+
+		result.Text += chunk.Message.Content
+		return onChunk(chunk.Message.Content)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
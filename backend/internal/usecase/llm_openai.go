@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// openAIProvider talks to the chat completions API of OpenAI and compatible servers
+// (Azure OpenAI, Groq, vLLM, LM Studio and similar)
+type openAIProvider struct {
+	llmOptions
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model          string            `json:"model"`
+	Messages       []openAIMessage   `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	MaxTokens      int               `json:"max_tokens"`
+	Stream         bool              `json:"stream,omitempty"`
+	StreamOptions  map[string]bool   `json:"stream_options,omitempty"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+func (p *openAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *openAIProvider) Model() string {
+	return p.model
+}
+
+func (p *openAIProvider) buildRequest(req *LLMRequest, stream bool) openAIRequest {
+	body := openAIRequest{
+		Model:       p.model,
+		Temperature: p.temperature,
+		MaxTokens:   p.maxTokensFor(req),
+	}
+	for _, message := range req.Messages {
+		body.Messages = append(body.Messages, openAIMessage{Role: message.Role, Content: message.Content})
+	}
+	if req.JSONOutput {
+		body.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+	if stream {
+		body.Stream = true
+		// Without this the usage is missing from streamed responses
+		body.StreamOptions = map[string]bool{"include_usage": true}
+	}
+	return body
+}
+
+// headers sends the key as a bearer token, local servers often don't need one
+func (p *openAIProvider) headers() map[string]string {
+	if p.apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.apiKey}
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, llmRequestTimeout)
+	defer cancel()
+
+	resp, err := postLLMJSON(ctx, p.httpClient, p.baseURL+"/chat/completions", p.headers(), p.buildRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+
+	var openAIResp openAIResponse
+	if err := decodeLLMJSON(resp, &openAIResp); err != nil {
+		return nil, err
+	}
+
+	result := &LLMResponse{Model: p.model}
+	if len(openAIResp.Choices) > 0 {
+		result.Text = strings.TrimSpace(openAIResp.Choices[0].Message.Content)
+	}
+	if openAIResp.Usage != nil {
+		result.PromptTokens = openAIResp.Usage.PromptTokens
+		result.CompletionTokens = openAIResp.Usage.CompletionTokens
+	}
+	return result, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req *LLMRequest, onChunk func(text string) error) (*LLMResponse, error) {
+	resp, err := postLLMJSON(ctx, p.httpClient, p.baseURL+"/chat/completions", p.headers(), p.buildRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &LLMResponse{Model: p.model}
+	err = scanLLMLines(resp.Body, true, func(line string) error {
+		if line == "[DONE]" {
+			return nil
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			result.PromptTokens = chunk.Usage.PromptTokens
+			result.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+
+		text := chunk.Choices[0].Delta.Content
+		result.Text += text
+		return onChunk(text)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"warasin/internal/config"
+)
+
+// LLM message roles, mapped to each provider's own names
+const (
+	LLMRoleSystem    = "system"
+	LLMRoleUser      = "user"
+	LLMRoleAssistant = "assistant"
+)
+
+// Non-streaming calls give up after this long, streams are bounded by the caller's context
+const llmRequestTimeout = 60 * time.Second
+
+// LLMMessage is one turn of a conversation
+type LLMMessage struct {
+	Role    string
+	Content string
+}
+
+// LLMRequest is a provider independent generation request. A zero MaxTokens uses the
+// configured limit. With JSONOutput the model is asked to answer with a JSON document.
+type LLMRequest struct {
+	Messages   []LLMMessage
+	MaxTokens  int
+	JSONOutput bool
+}
+
+// LLMResponse is the generated text and the token usage reported by the provider
+type LLMResponse struct {
+	Text             string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// TotalTokens is the prompt and completion tokens together
+func (r *LLMResponse) TotalTokens() int {
+	return r.PromptTokens + r.CompletionTokens
+}
+
+// LLMProvider generates text with a language model. Use cases take it as a dependency,
+// so tests can pass a fake instead of calling a real API.
+type LLMProvider interface {
+	Name() string
+	Model() string
+	Generate(ctx context.Context, req *LLMRequest) (*LLMResponse, error)
+	// Stream passes each piece of text to onChunk as it arrives and returns the whole
+	// response at the end. An error from onChunk stops the stream.
+	Stream(ctx context.Context, req *LLMRequest, onChunk func(text string) error) (*LLMResponse, error)
+}
+
+// llmOptions are the settings shared by all providers
+type llmOptions struct {
+	model       string
+	baseURL     string
+	apiKey      string
+	temperature float64
+	maxTokens   int
+	httpClient  *http.Client
+}
+
+// maxTokensFor returns the request's limit or the configured one
+func (o *llmOptions) maxTokensFor(req *LLMRequest) int {
+	if req.MaxTokens > 0 {
+		return req.MaxTokens
+	}
+	return o.maxTokens
+}
+
+// NewLLMProvider creates the provider selected by LLM_PROVIDER
+func NewLLMProvider(cfg *config.Config) (LLMProvider, error) {
+	opts := llmOptions{
+		model:       cfg.LLMModel,
+		baseURL:     strings.TrimRight(cfg.LLMBaseURL, "/"),
+		apiKey:      cfg.LLMAPIKey,
+		temperature: cfg.LLMTemperature,
+		maxTokens:   cfg.LLMMaxOutputTokens,
+		// No client timeout, streams can run longer than any fixed limit
+		httpClient: &http.Client{},
+	}
+
+	switch strings.ToLower(cfg.LLMProvider) {
+	case "gemini":
+		if opts.model == "" {
+			opts.model = cfg.GeminiModel
+		}
+		if opts.apiKey == "" {
+			opts.apiKey = cfg.GeminiAPIKey
+		}
+		if opts.baseURL == "" {
+			opts.baseURL = "https://generativelanguage.googleapis.com/v1beta"
+		}
+		return &geminiProvider{opts}, nil
+	case "openai":
+		if opts.model == "" {
+			opts.model = "gpt-4o-mini"
+		}
+		if opts.baseURL == "" {
+			opts.baseURL = "https://api.openai.com/v1"
+		}
+		return &openAIProvider{opts}, nil
+	case "ollama":
+		if opts.model == "" {
+			opts.model = "llama3.1"
+		}
+		if opts.baseURL == "" {
+			opts.baseURL = "http://localhost:11434"
+		}
+		return &ollamaProvider{opts}, nil
+	}
+
+	return nil, fmt.Errorf("unknown LLM_PROVIDER %q, expected gemini, openai or ollama", cfg.LLMProvider)
+}
+
+// postLLMJSON sends body as JSON and returns the response when the status is 200
+func postLLMJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(errBody))
+	}
+
+	return resp, nil
+}
+
+// decodeLLMJSON reads a whole JSON response into out
+func decodeLLMJSON(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// scanLLMLines calls fn for every non-empty line of a streamed response. For Server-Sent
+// Events only the payload of "data:" lines is passed on.
+func scanLLMLines(body io.Reader, sse bool, fn func(line string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if sse {
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			line = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
+
+// generateFromPrompt sends a single user prompt and fails when the model returns no text
+func generateFromPrompt(ctx context.Context, llm LLMProvider, prompt string, maxTokens int, jsonOutput bool) (*LLMResponse, error) {
+	resp, err := llm.Generate(ctx, &LLMRequest{
+		Messages:   []LLMMessage{{Role: LLMRoleUser, Content: prompt}},
+		MaxTokens:  maxTokens,
+		JSONOutput: jsonOutput,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Text == "" {
+		return nil, errors.New("model returned no content")
+	}
+	return resp, nil
+}
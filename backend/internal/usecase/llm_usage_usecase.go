@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"warasin/internal/config"
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Features that call the language model, recorded with each request
+const (
+	LLMFeatureChat              = "chat"
+	LLMFeatureChatSummary       = "chat_summary"
+	LLMFeatureChatTitle         = "chat_title"
+	LLMFeatureSafetyCheck       = "safety_check"
+	LLMFeatureJournalReflection = "journal_reflection"
+	LLMFeatureJournalSummary    = "journal_summary"
+)
+
+// Features counted against the daily quota. Requests count what the user asked for, tokens
+// also include the chat summaries and titles those requests cause. Safety checks and the
+// scheduled weekly summaries are recorded but never block the user.
+var (
+	quotaRequestFeatures = map[string]bool{LLMFeatureChat: true, LLMFeatureJournalReflection: true}
+	quotaTokenFeatures   = map[string]bool{LLMFeatureChat: true, LLMFeatureChatSummary: true, LLMFeatureChatTitle: true, LLMFeatureJournalReflection: true}
+)
+
+// ErrLLMQuotaExceeded is matched by every LLMQuotaError
+var ErrLLMQuotaExceeded = errors.New("daily AI usage limit reached")
+
+// LLMQuotaError is returned when a user used up today's requests or tokens
+type LLMQuotaError struct {
+	ResetAt time.Time
+}
+
+func (e *LLMQuotaError) Error() string {
+	return fmt.Sprintf("%s, it resets at %s", ErrLLMQuotaExceeded, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *LLMQuotaError) Is(target error) bool {
+	return target == ErrLLMQuotaExceeded
+}
+
+// Quota days follow Indonesian western time, which has no daylight saving
+var usageLocation = time.FixedZone("WIB", 7*60*60)
+
+// llmPrice is USD per million tokens
+type llmPrice struct {
+	prompt     float64
+	completion float64
+}
+
+// llmPrices are list prices of the hosted models we support, matched by model name prefix.
+// Longer prefixes are tried first so gpt-4o-mini doesn't get the gpt-4o price.
+var llmPrices = map[string]llmPrice{
+	"gemini-1.5-flash": {prompt: 0.075, completion: 0.30},
+	"gemini-1.5-pro":   {prompt: 1.25, completion: 5.00},
+	"gemini-2.0-flash": {prompt: 0.10, completion: 0.40},
+	"gpt-4o-mini":      {prompt: 0.15, completion: 0.60},
+	"gpt-4o":           {prompt: 2.50, completion: 10.00},
+	"gpt-4.1-mini":     {prompt: 0.40, completion: 1.60},
+	"gpt-4.1":          {prompt: 2.00, completion: 8.00},
+}
+
+const (
+	maxUsageReportDays     = 366
+	usageReportTopUsers    = 10
+	defaultUsageReportDays = 30
+)
+
+type llmUsageUsecase struct {
+	usageRepo postgres.LLMUsageRepository
+	userRepo  postgres.UserRepository
+
+	requestLimits map[string]int
+	tokenLimits   map[string]int
+	// Configured price of the provider's model, overrides llmPrices when set
+	model      string
+	modelPrice llmPrice
+}
+
+// LLMUsageUsecase interface
+type LLMUsageUsecase interface {
+	Record(userID int, feature string, resp *LLMResponse)
+	CheckQuota(userID int) error
+	GetQuotaStatus(userID int) (*domain.LLMQuotaStatus, error)
+	GetReport(startDate, endDate string) (*domain.LLMUsageReport, error)
+}
+
+// NewLLMUsageUsecase creates a new LLM usage use case. model is the provider's model, priced
+// with LLM_PRICE_* when those are set.
+func NewLLMUsageUsecase(usageRepo postgres.LLMUsageRepository, userRepo postgres.UserRepository, model string, cfg *config.Config) LLMUsageUsecase {
+	return &llmUsageUsecase{
+		usageRepo: usageRepo,
+		userRepo:  userRepo,
+		requestLimits: map[string]int{
+			"standard": cfg.LLMDailyRequestsStandard,
+			"premium":  cfg.LLMDailyRequestsPremium,
+		},
+		tokenLimits: map[string]int{
+			"standard": cfg.LLMDailyTokensStandard,
+			"premium":  cfg.LLMDailyTokensPremium,
+		},
+		model: model,
+		modelPrice: llmPrice{
+			prompt:     cfg.LLMPricePromptPerMillion,
+			completion: cfg.LLMPriceCompletionPerMillion,
+		},
+	}
+}
+
+// usageDay returns the start of the quota day t falls in and when it resets
+func usageDay(t time.Time) (time.Time, time.Time) {
+	local := t.In(usageLocation)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, usageLocation)
+	return day, day.AddDate(0, 0, 1)
+}
+
+// Record adds one model call to the user's usage. Failures are logged, the answer was
+// already generated and shouldn't be lost over accounting.
+func (u *llmUsageUsecase) Record(userID int, feature string, resp *LLMResponse) {
+	if userID <= 0 || resp == nil {
+		return
+	}
+
+	day, _ := usageDay(time.Now())
+	if err := u.usageRepo.Record(userID, day, feature, resp.Model, resp.PromptTokens, resp.CompletionTokens); err != nil {
+		log.Printf("ERROR: Failed to record %s usage for user %d: %v", feature, userID, err)
+	}
+}
+
+// CheckQuota returns an LLMQuotaError when the user has no requests or tokens left today
+func (u *llmUsageUsecase) CheckQuota(userID int) error {
+	status, err := u.GetQuotaStatus(userID)
+	if err != nil {
+		return err
+	}
+
+	if (status.RequestsLimit > 0 && status.RequestsUsed >= status.RequestsLimit) ||
+		(status.TokensLimit > 0 && status.TokensUsed >= status.TokensLimit) {
+		return &LLMQuotaError{ResetAt: status.ResetAt}
+	}
+	return nil
+}
+
+// GetQuotaStatus returns today's usage and the limits of the user's plan. Admins and
+// unknown user types are unlimited.
+func (u *llmUsageUsecase) GetQuotaStatus(userID int) (*domain.LLMQuotaStatus, error) {
+	user, err := u.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	day, resetAt := usageDay(time.Now())
+	usage, err := u.usageRepo.GetUserUsage(userID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, tokens := 0, 0
+	for _, row := range usage {
+		if quotaRequestFeatures[row.Feature] {
+			requests += row.Requests
+		}
+		if quotaTokenFeatures[row.Feature] {
+			tokens += row.PromptTokens + row.CompletionTokens
+		}
+	}
+
+	return &domain.LLMQuotaStatus{
+		UserType:      user.UserType,
+		Date:          day.Format("2006-01-02"),
+		RequestsUsed:  requests,
+		RequestsLimit: u.requestLimits[user.UserType],
+		TokensUsed:    tokens,
+		TokensLimit:   u.tokenLimits[user.UserType],
+		ResetAt:       resetAt,
+	}, nil
+}
+
+// priceOf returns the price of model and whether it is known
+func (u *llmUsageUsecase) priceOf(model string) (llmPrice, bool) {
+	if model == u.model && (u.modelPrice.prompt > 0 || u.modelPrice.completion > 0) {
+		return u.modelPrice, true
+	}
+
+	best := ""
+	for prefix := range llmPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return llmPrice{}, false
+	}
+	return llmPrices[best], true
+}
+
+func (p llmPrice) cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.prompt + float64(completionTokens)*p.completion) / 1e6
+}
+
+// reportDateRange parses the YYYY-MM-DD days of an admin report in WIB, both inclusive.
+// Without dates the report covers the last defaultUsageReportDays days.
+func reportDateRange(startDateStr, endDateStr string) (time.Time, time.Time, error) {
+	today, _ := usageDay(time.Now())
+	endDate := today
+	startDate := today.AddDate(0, 0, -(defaultUsageReportDays - 1))
+
+	if endDateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", endDateStr, usageLocation)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid end_date format, use YYYY-MM-DD")
+		}
+		endDate = parsed
+		if startDateStr == "" {
+			startDate = endDate.AddDate(0, 0, -(defaultUsageReportDays - 1))
+		}
+	}
+	if startDateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", startDateStr, usageLocation)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid start_date format, use YYYY-MM-DD")
+		}
+		startDate = parsed
+	}
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, errors.New("end_date must not be before start_date")
+	}
+	if endDate.Sub(startDate) > maxUsageReportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("the report covers at most %d days", maxUsageReportDays)
+	}
+
+	return startDate, endDate, nil
+}
+
+// GetReport sums usage and estimated cost between two dates (YYYY-MM-DD, both inclusive).
+// Without dates it covers the last 30 days.
+func (u *llmUsageUsecase) GetReport(startDateStr, endDateStr string) (*domain.LLMUsageReport, error) {
+	startDate, endDate, err := reportDateRange(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := u.usageRepo.GetDailyUsage(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.LLMUsageReport{
+		StartDate:      startDate.Format("2006-01-02"),
+		EndDate:        endDate.Format("2006-01-02"),
+		ByModel:        []*domain.LLMUsageTotals{},
+		ByFeature:      []*domain.LLMUsageTotals{},
+		ByDay:          []*domain.LLMUsageTotals{},
+		TopUsers:       []*domain.LLMUserUsage{},
+		UnpricedModels: []string{},
+	}
+
+	byModel := make(map[string]*domain.LLMUsageTotals)
+	byFeature := make(map[string]*domain.LLMUsageTotals)
+	byDay := make(map[string]*domain.LLMUsageTotals)
+	unpriced := make(map[string]bool)
+
+	add := func(groups map[string]*domain.LLMUsageTotals, list *[]*domain.LLMUsageTotals, name string, row *domain.LLMUsageRow, cost float64) {
+		totals, ok := groups[name]
+		if !ok {
+			totals = &domain.LLMUsageTotals{Name: name}
+			groups[name] = totals
+			*list = append(*list, totals)
+		}
+		addUsage(totals, row, cost)
+	}
+
+	for _, row := range rows {
+		price, known := u.priceOf(row.Model)
+		if !known {
+			unpriced[row.Model] = true
+		}
+		cost := price.cost(row.PromptTokens, row.CompletionTokens)
+
+		addUsage(&report.LLMUsageTotals, row, cost)
+		add(byModel, &report.ByModel, row.Model, row, cost)
+		add(byFeature, &report.ByFeature, row.Feature, row, cost)
+		add(byDay, &report.ByDay, row.UsageDate.Format("2006-01-02"), row, cost)
+	}
+
+	sort.Slice(report.ByModel, func(i, j int) bool {
+		return report.ByModel[i].EstimatedCostUSD > report.ByModel[j].EstimatedCostUSD
+	})
+	sort.Slice(report.ByFeature, func(i, j int) bool {
+		return report.ByFeature[i].Requests > report.ByFeature[j].Requests
+	})
+	for model := range unpriced {
+		report.UnpricedModels = append(report.UnpricedModels, model)
+	}
+	sort.Strings(report.UnpricedModels)
+
+	users, userModels, err := u.usageRepo.GetTopUsers(startDate, endDate, usageReportTopUsers)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		for _, row := range userModels[user.UserID] {
+			price, _ := u.priceOf(row.Model)
+			user.EstimatedCostUSD += price.cost(row.PromptTokens, row.CompletionTokens)
+		}
+		report.TopUsers = append(report.TopUsers, user)
+	}
+
+	return report, nil
+}
+
+func addUsage(totals *domain.LLMUsageTotals, row *domain.LLMUsageRow, cost float64) {
+	totals.Requests += row.Requests
+	totals.PromptTokens += row.PromptTokens
+	totals.CompletionTokens += row.CompletionTokens
+	totals.EstimatedCostUSD += cost
+}
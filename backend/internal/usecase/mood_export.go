@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Invalid export options, the caller's fault unlike the other errors of Export
+var (
+	ErrMoodExportTimezone  = errors.New("invalid timezone")
+	ErrMoodExportEndDate   = errors.New("invalid end_date format, expected YYYY-MM-DD")
+	ErrMoodExportStartDate = errors.New("invalid start_date format, expected YYYY-MM-DD")
+	ErrMoodExportRange     = errors.New("start_date must not be after end_date")
+	ErrMoodExportFormat    = errors.New("format must be csv, ics or fhir")
+)
+
+// MoodExportOptions selects the format and the local date range of an export
+type MoodExportOptions struct {
+	Format    string // csv, ics, fhir
+	StartDate string // YYYY-MM-DD, the whole history when empty
+	EndDate   string // YYYY-MM-DD, today when empty
+	Timezone  string // IANA name, defaults to UTC
+}
+
+// MoodExport is a rendered export file
+type MoodExport struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// moodCodeSystem identifies WarasIn's own codes in FHIR resources, there is no
+// standard code for a self-reported 0-1 mood score
+const moodCodeSystem = "https://warasin.id/fhir/CodeSystem/mood"
+
+// Export renders every mood entry in the range, oldest first, as CSV rows, an iCalendar
+// feed with one all-day event per day, or a FHIR R4 Bundle of Observations
+func (u *moodUsecase) Export(userID int, opts MoodExportOptions) (*MoodExport, error) {
+	loc := time.UTC
+	if opts.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(opts.Timezone)
+		if err != nil {
+			return nil, ErrMoodExportTimezone
+		}
+	}
+
+	now := time.Now().In(loc)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if opts.EndDate != "" {
+		var err error
+		endDate, err = time.ParseInLocation("2006-01-02", opts.EndDate, loc)
+		if err != nil {
+			return nil, ErrMoodExportEndDate
+		}
+	}
+
+	var startDate time.Time
+	if opts.StartDate != "" {
+		var err error
+		startDate, err = time.ParseInLocation("2006-01-02", opts.StartDate, loc)
+		if err != nil {
+			return nil, ErrMoodExportStartDate
+		}
+		if startDate.After(endDate) {
+			return nil, ErrMoodExportRange
+		}
+	}
+
+	// The repository's end is inclusive, stop just before the next local midnight
+	rangeEnd := endDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	entries, _, err := u.moodRepo.GetByUserID(userID, postgres.NoLimit, 0, startDate, rangeEnd, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// GetByUserID returns the newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	period := "all-" + endDate.Format("2006-01-02")
+	if !startDate.IsZero() {
+		period = startDate.Format("2006-01-02") + "-" + endDate.Format("2006-01-02")
+	}
+	fileName := "warasin-mood-" + period
+
+	switch opts.Format {
+	case "", "csv":
+		data, err := moodEntriesToCSV(entries, loc)
+		if err != nil {
+			return nil, err
+		}
+		return &MoodExport{FileName: fileName + ".csv", ContentType: "text/csv; charset=utf-8", Data: data}, nil
+	case "ics":
+		data := moodEntriesToICS(userID, entries, loc, now)
+		return &MoodExport{FileName: fileName + ".ics", ContentType: "text/calendar; charset=utf-8", Data: data}, nil
+	case "fhir":
+		data, err := moodEntriesToFHIR(entries, loc, now)
+		if err != nil {
+			return nil, err
+		}
+		return &MoodExport{FileName: fileName + ".json", ContentType: "application/fhir+json", Data: data}, nil
+	default:
+		return nil, ErrMoodExportFormat
+	}
+}
+
+func moodEntriesToCSV(entries []*domain.MoodEntry, loc *time.Location) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"entry_id", "recorded_at", "local_date", "entry_type", "source", "primary_emotion", "intensity_level", "trigger_factor", "coping_strategy", "journal_id"}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		local := entry.RecordedAt.In(loc)
+		journalID := ""
+		if entry.JournalID > 0 {
+			journalID = strconv.Itoa(entry.JournalID)
+		}
+
+		record := []string{
+			strconv.Itoa(entry.ID),
+			local.Format(time.RFC3339),
+			local.Format("2006-01-02"),
+			entry.EntryType,
+			entry.Source,
+			entry.PrimaryEmotion,
+			strconv.FormatFloat(entry.IntensityLevel, 'f', 2, 64),
+			csvSafe(entry.TriggerFactor),
+			csvSafe(entry.CopingStrategy),
+			journalID,
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvSafe stops spreadsheet apps from running free text as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
+
+// moodEntriesToICS builds one all-day event per local day with the dominant emotion and
+// average intensity in the summary and every entry in the description
+func moodEntriesToICS(userID int, entries []*domain.MoodEntry, loc *time.Location, now time.Time) []byte {
+	days := map[string][]*domain.MoodEntry{}
+	var dates []string
+	for _, entry := range entries {
+		date := entry.RecordedAt.In(loc).Format("2006-01-02")
+		if _, ok := days[date]; !ok {
+			dates = append(dates, date)
+		}
+		days[date] = append(days[date], entry)
+	}
+	sort.Strings(dates)
+
+	var buf bytes.Buffer
+	line := func(value string) {
+		buf.WriteString(foldICSLine(value))
+		buf.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//WarasIn//Mood Export//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:WarasIn Mood")
+	line("X-WR-TIMEZONE:" + loc.String())
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, date := range dates {
+		dayEntries := days[date]
+		day, _ := time.ParseInLocation("2006-01-02", date, loc)
+
+		counts := map[string]int{}
+		var intensitySum float64
+		var description []string
+		for _, entry := range dayEntries {
+			counts[entry.PrimaryEmotion]++
+			intensitySum += entry.IntensityLevel
+			description = append(description, fmt.Sprintf("%s %s %.2f (%s)",
+				entry.RecordedAt.In(loc).Format("15:04"), entry.PrimaryEmotion, entry.IntensityLevel, entry.Source))
+		}
+
+		dominant := ""
+		for emotion, count := range counts {
+			if dominant == "" || count > counts[dominant] || (count == counts[dominant] && emotion < dominant) {
+				dominant = emotion
+			}
+		}
+		average := intensitySum / float64(len(dayEntries))
+
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:mood-%d-%s@warasin.id", userID, day.Format("20060102")))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + day.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICSText(fmt.Sprintf("Mood: %s (%.2f, %d entries)", dominant, average, len(dayEntries))))
+		line("DESCRIPTION:" + escapeICSText(strings.Join(description, "\n")))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func escapeICSText(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// foldICSLine splits lines longer than 75 octets as RFC 5545 requires, without
+// breaking a UTF-8 character
+func foldICSLine(value string) string {
+	if len(value) <= 75 {
+		return value
+	}
+
+	var b strings.Builder
+	width := 0
+	limit := 75
+	for _, r := range value {
+		size := utf8.RuneLen(r)
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 0
+			limit = 74 // The leading space counts towards the next line
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+type fhirCoding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type fhirCodeableConcept struct {
+	Coding []fhirCoding `json:"coding,omitempty"`
+	Text   string       `json:"text,omitempty"`
+}
+
+type fhirQuantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+	System string  `json:"system"`
+	Code   string  `json:"code"`
+}
+
+type fhirObservationComponent struct {
+	Code                 fhirCodeableConcept `json:"code"`
+	ValueCodeableConcept fhirCodeableConcept `json:"valueCodeableConcept"`
+}
+
+type fhirAnnotation struct {
+	Text string `json:"text"`
+}
+
+type fhirObservation struct {
+	ResourceType      string                     `json:"resourceType"`
+	ID                string                     `json:"id"`
+	Status            string                     `json:"status"`
+	Category          []fhirCodeableConcept      `json:"category"`
+	Code              fhirCodeableConcept        `json:"code"`
+	EffectiveDateTime string                     `json:"effectiveDateTime"`
+	ValueQuantity     fhirQuantity               `json:"valueQuantity"`
+	Method            *fhirCodeableConcept       `json:"method,omitempty"`
+	Component         []fhirObservationComponent `json:"component,omitempty"`
+	Note              []fhirAnnotation           `json:"note,omitempty"`
+}
+
+type fhirBundleEntry struct {
+	Resource *fhirObservation `json:"resource"`
+}
+
+type fhirBundle struct {
+	ResourceType string            `json:"resourceType"`
+	Type         string            `json:"type"`
+	Timestamp    string            `json:"timestamp"`
+	Total        int               `json:"total"`
+	Entry        []fhirBundleEntry `json:"entry"`
+}
+
+// moodEntriesToFHIR builds a FHIR R4 collection Bundle with one Observation per entry
+func moodEntriesToFHIR(entries []*domain.MoodEntry, loc *time.Location, now time.Time) ([]byte, error) {
+	bundle := fhirBundle{
+		ResourceType: "Bundle",
+		Type:         "collection",
+		Timestamp:    now.Format(time.RFC3339),
+		Total:        len(entries),
+		Entry:        make([]fhirBundleEntry, 0, len(entries)),
+	}
+
+	for _, entry := range entries {
+		observation := &fhirObservation{
+			ResourceType: "Observation",
+			ID:           "mood-" + strconv.Itoa(entry.ID),
+			Status:       "final",
+			Category: []fhirCodeableConcept{{
+				Coding: []fhirCoding{{
+					System:  "http://terminology.hl7.org/CodeSystem/observation-category",
+					Code:    "survey",
+					Display: "Survey",
+				}},
+			}},
+			Code: fhirCodeableConcept{
+				Coding: []fhirCoding{{System: moodCodeSystem, Code: "mood-intensity", Display: "Mood intensity"}},
+				Text:   "Self-reported mood intensity (0 = very negative, 1 = very positive)",
+			},
+			EffectiveDateTime: entry.RecordedAt.In(loc).Format(time.RFC3339),
+			ValueQuantity: fhirQuantity{
+				Value:  entry.IntensityLevel,
+				Unit:   "score",
+				System: "http://unitsofmeasure.org",
+				Code:   "1",
+			},
+			Component: []fhirObservationComponent{{
+				Code: fhirCodeableConcept{
+					Coding: []fhirCoding{{System: moodCodeSystem, Code: "primary-emotion", Display: "Primary emotion"}},
+				},
+				ValueCodeableConcept: fhirCodeableConcept{
+					Coding: []fhirCoding{{System: moodCodeSystem + "/emotion", Code: entry.PrimaryEmotion}},
+					Text:   entry.PrimaryEmotion,
+				},
+			}},
+		}
+
+		if entry.Source != "" {
+			observation.Method = &fhirCodeableConcept{
+				Coding: []fhirCoding{{System: moodCodeSystem + "/source", Code: entry.Source}},
+				Text:   entry.Source,
+			}
+		}
+		if entry.TriggerFactor != "" {
+			observation.Note = append(observation.Note, fhirAnnotation{Text: "Trigger: " + entry.TriggerFactor})
+		}
+		if entry.CopingStrategy != "" {
+			observation.Note = append(observation.Note, fhirAnnotation{Text: "Coping strategy: " + entry.CopingStrategy})
+		}
+
+		bundle.Entry = append(bundle.Entry, fhirBundleEntry{Resource: observation})
+	}
+
+	return json.MarshalIndent(bundle, "", "  ")
+}
@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+const (
+	forecastHistoryDays = 90
+	forecastHorizonDays = 7
+	// Below these the seasonal effects are mostly noise, so no forecast is shown
+	forecastMinDays    = 14
+	forecastMinEntries = 20
+
+	// Smoothing factor of the level, higher reacts faster to recent days
+	forecastAlpha = 0.3
+	// Effects are shrunk towards zero by n / (n + k), so a pattern needs repeated
+	// evidence before it moves the forecast
+	forecastWeekdayShrink     = 2.0
+	forecastPeriodShrink      = 3.0
+	forecastInteractionShrink = 4.0
+	// Days used to warm up the level before one-step errors are collected
+	forecastWarmupDays = 7
+
+	forecastConfidenceLevel = 0.8
+	forecastZScore          = 1.2816 // Two-sided 80% normal interval
+	// Used until there are enough one-step errors to estimate the spread
+	forecastDefaultSigma = 0.15
+)
+
+var forecastPeriods = []string{"night", "morning", "afternoon", "evening"}
+
+// forecastPeriod maps an hour to its index in forecastPeriods
+func forecastPeriod(hour int) int {
+	return hour / 6
+}
+
+// forecastDay is the entries of one local day
+type forecastDay struct {
+	date    time.Time
+	mean    float64
+	entries []*domain.MoodEntry
+}
+
+// GetForecast fits a small per-user model on the last 90 days of entries and predicts
+// the next 7 days. The model is an additive seasonal baseline (weekday, part of the day
+// and their interaction, all shrunk towards zero) on top of an exponentially smoothed level.
+// Bands come from the one-step-ahead errors of the smoothing.
+func (u *moodUsecase) GetForecast(userID int, timezone string) (*domain.MoodForecast, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	since := today.AddDate(0, 0, -forecastHistoryDays)
+
+	entries, _, err := u.moodRepo.GetByUserID(userID, postgres.NoLimit, 0, since, now, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	days := groupForecastDays(entries, loc)
+
+	forecast := &domain.MoodForecast{
+		Timezone:        loc.String(),
+		GeneratedAt:     now,
+		DaysWithData:    len(days),
+		EntriesUsed:     len(entries),
+		ConfidenceLevel: forecastConfidenceLevel,
+		Days:            []*domain.MoodForecastDay{},
+	}
+
+	if len(days) < forecastMinDays || len(entries) < forecastMinEntries {
+		forecast.Message = fmt.Sprintf(
+			"Not enough mood history for a forecast yet. It needs entries on at least %d different days and %d entries in the last %d days, you have %d days and %d entries.",
+			forecastMinDays, forecastMinEntries, forecastHistoryDays, len(days), len(entries))
+		return forecast, nil
+	}
+	forecast.SufficientData = true
+
+	// Seasonal baseline: how far each weekday sits from the overall mean
+	overall := 0.0
+	for _, day := range days {
+		overall += day.mean
+	}
+	overall /= float64(len(days))
+
+	var weekdaySums [7]float64
+	var weekdayCounts [7]int
+	for _, day := range days {
+		weekday := int(day.date.Weekday())
+		weekdaySums[weekday] += day.mean - overall
+		weekdayCounts[weekday]++
+	}
+	var weekdayEffect [7]float64
+	for weekday := range weekdayEffect {
+		weekdayEffect[weekday] = shrunkMean(weekdaySums[weekday], weekdayCounts[weekday], forecastWeekdayShrink)
+	}
+
+	// Part of the day effects, from what is left of each entry after the weekday effect
+	periodCount := len(forecastPeriods)
+	periodSums := make([]float64, periodCount)
+	periodCounts := make([]int, periodCount)
+	for _, day := range days {
+		weekday := int(day.date.Weekday())
+		for _, entry := range day.entries {
+			period := forecastPeriod(entry.RecordedAt.In(loc).Hour())
+			periodSums[period] += entry.IntensityLevel - overall - weekdayEffect[weekday]
+			periodCounts[period]++
+		}
+	}
+	periodEffect := make([]float64, periodCount)
+	for period := range periodEffect {
+		periodEffect[period] = shrunkMean(periodSums[period], periodCounts[period], forecastPeriodShrink)
+	}
+
+	// Weekday and part of the day together, e.g. Sunday evening dips
+	var interactionSums [7][]float64
+	var interactionCounts [7][]int
+	for weekday := range interactionSums {
+		interactionSums[weekday] = make([]float64, periodCount)
+		interactionCounts[weekday] = make([]int, periodCount)
+	}
+	var withinDaySquares float64
+	withinDayCount := 0
+	for _, day := range days {
+		weekday := int(day.date.Weekday())
+		for _, entry := range day.entries {
+			period := forecastPeriod(entry.RecordedAt.In(loc).Hour())
+			interactionSums[weekday][period] += entry.IntensityLevel - overall - weekdayEffect[weekday] - periodEffect[period]
+			interactionCounts[weekday][period]++
+			if len(day.entries) > 1 {
+				diff := entry.IntensityLevel - day.mean
+				withinDaySquares += diff * diff
+				withinDayCount++
+			}
+		}
+	}
+	var interactionEffect [7][]float64
+	for weekday := range interactionEffect {
+		interactionEffect[weekday] = make([]float64, periodCount)
+		for period := range interactionEffect[weekday] {
+			interactionEffect[weekday][period] = shrunkMean(interactionSums[weekday][period], interactionCounts[weekday][period], forecastInteractionShrink)
+		}
+	}
+	withinDayVariance := 0.0
+	if withinDayCount > 0 {
+		withinDayVariance = withinDaySquares / float64(withinDayCount)
+	}
+
+	// Exponential smoothing of the deseasonalised daily means. Missing days are skipped,
+	// the level simply carries over.
+	level := days[0].mean - weekdayEffect[int(days[0].date.Weekday())]
+	var squaredErrors float64
+	errorCount := 0
+	for i, day := range days[1:] {
+		seasonal := weekdayEffect[int(day.date.Weekday())]
+		if i+1 >= forecastWarmupDays {
+			diff := day.mean - (level + seasonal)
+			squaredErrors += diff * diff
+			errorCount++
+		}
+		level = forecastAlpha*(day.mean-seasonal) + (1-forecastAlpha)*level
+	}
+
+	sigma := forecastDefaultSigma
+	if errorCount >= forecastWarmupDays {
+		sigma = math.Sqrt(squaredErrors / float64(errorCount))
+	}
+
+	for h := 1; h <= forecastHorizonDays; h++ {
+		date := today.AddDate(0, 0, h)
+		weekday := int(date.Weekday())
+
+		// Variance of a simple exponential smoothing forecast h steps ahead
+		daySigma := sigma * math.Sqrt(1+float64(h-1)*forecastAlpha*forecastAlpha)
+		predicted := clampUnit(level + weekdayEffect[weekday])
+
+		forecastDay := &domain.MoodForecastDay{
+			Date:               date.Format("2006-01-02"),
+			Weekday:            date.Weekday().String(),
+			PredictedIntensity: roundForecast(predicted),
+			Lower:              roundForecast(clampUnit(predicted - forecastZScore*daySigma)),
+			Upper:              roundForecast(clampUnit(predicted + forecastZScore*daySigma)),
+			LowMoodRisk:        predicted < lowMoodThreshold,
+		}
+
+		periodSigma := math.Sqrt(daySigma*daySigma + withinDayVariance)
+		for period, name := range forecastPeriods {
+			value := clampUnit(level + weekdayEffect[weekday] + periodEffect[period] + interactionEffect[weekday][period])
+			forecastDay.Periods = append(forecastDay.Periods, &domain.MoodForecastPeriod{
+				Period:             name,
+				PredictedIntensity: roundForecast(value),
+				Lower:              roundForecast(clampUnit(value - forecastZScore*periodSigma)),
+				Upper:              roundForecast(clampUnit(value + forecastZScore*periodSigma)),
+				LowMoodRisk:        value < lowMoodThreshold,
+			})
+			if value < lowMoodThreshold {
+				forecastDay.LowMoodRisk = true
+			}
+		}
+
+		forecast.Days = append(forecast.Days, forecastDay)
+	}
+
+	return forecast, nil
+}
+
+// groupForecastDays groups entries by local date, oldest day first
+func groupForecastDays(entries []*domain.MoodEntry, loc *time.Location) []*forecastDay {
+	byDate := map[string]*forecastDay{}
+	for _, entry := range entries {
+		local := entry.RecordedAt.In(loc)
+		key := local.Format("2006-01-02")
+		day, ok := byDate[key]
+		if !ok {
+			day = &forecastDay{date: time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)}
+			byDate[key] = day
+		}
+		day.entries = append(day.entries, entry)
+		day.mean += entry.IntensityLevel
+	}
+
+	days := make([]*forecastDay, 0, len(byDate))
+	for _, day := range byDate {
+		day.mean /= float64(len(day.entries))
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].date.Before(days[j].date) })
+	return days
+}
+
+// shrunkMean is sum / (count + k), the mean pulled towards zero when count is small
+func shrunkMean(sum float64, count int, k float64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / (float64(count) + k)
+}
+
+func clampUnit(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+func roundForecast(value float64) float64 {
+	return math.Round(value*100) / 100
+}
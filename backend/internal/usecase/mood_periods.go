@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"time"
+
+	"warasin/internal/repository/postgres"
+)
+
+// userLocation returns the timezone of the user's reminder schedule, Asia/Jakarta when they
+// have none. Daily mood figures split at the user's local midnight, not at UTC's.
+func userLocation(notificationRepo postgres.NotificationRepository, userID int) (*time.Location, error) {
+	schedule, err := notificationRepo.GetSchedule(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule != nil && schedule.Timezone != "" {
+		if loc, err := time.LoadLocation(schedule.Timezone); err == nil {
+			return loc, nil
+		}
+	}
+	return time.LoadLocation(defaultReminderTimezone)
+}
+
+// moodPeriods holds the daily average moods of a recent period and of the period before it
+type moodPeriods struct {
+	// First day of the recent period and today, the user's local dates at midnight UTC
+	RecentStart time.Time
+	Today       time.Time
+	Recent      []float64
+	Previous    []float64
+}
+
+// dailyMoodPeriods returns the user's daily average moods of the last recentDays days,
+// today included, and of the previousDays days before them. Days are the user's local days.
+func dailyMoodPeriods(moodRepo postgres.MoodRepository, notificationRepo postgres.NotificationRepository, userID int, now time.Time, recentDays, previousDays int) (*moodPeriods, error) {
+	loc, err := userLocation(notificationRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	recentStart := today.AddDate(0, 0, -(recentDays - 1))
+	previousStart := recentStart.AddDate(0, 0, -previousDays)
+
+	daily, err := moodRepo.GetAverages(userID, "day", loc.String(), previousStart, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	periods := &moodPeriods{
+		RecentStart: time.Date(recentStart.Year(), recentStart.Month(), recentStart.Day(), 0, 0, 0, 0, time.UTC),
+		Today:       time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC),
+	}
+
+	// Periods are YYYY-MM-DD so they compare correctly as strings
+	recentKey := recentStart.Format("2006-01-02")
+	for _, day := range daily {
+		if day.Period >= recentKey {
+			periods.Recent = append(periods.Recent, day.AverageIntensity)
+		} else {
+			periods.Previous = append(periods.Previous, day.AverageIntensity)
+		}
+	}
+
+	return periods, nil
+}
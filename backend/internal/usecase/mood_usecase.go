@@ -1,93 +1,263 @@
 package usecase
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"warasin/internal/domain"
 	"warasin/internal/repository/postgres"
 )
 
+// ErrMoodEntryNotFound is returned when an entry doesn't exist or belongs to another user
+var ErrMoodEntryNotFound = errors.New("mood entry not found")
+
+// MoodEntryUpdate holds the fields of a partial update, nil fields are left unchanged
+type MoodEntryUpdate struct {
+	JournalID      *int
+	EntryType      *string
+	PrimaryEmotion *string
+	IntensityLevel *float64
+	TriggerFactor  *string
+	CopingStrategy *string
+	RecordedAt     *time.Time
+}
+
 type moodUsecase struct {
-	moodRepo    postgres.MoodRepository
-	journalRepo postgres.JournalRepository
+	moodRepo       postgres.MoodRepository
+	journalRepo    postgres.JournalRepository
+	tagRepo        postgres.MoodTagRepository
+	emotionUsecase EmotionUsecase
 }
 
 // MoodUsecase interface
 type MoodUsecase interface {
 	Create(userID int, journalID int, entryType, primaryEmotion string, intensityLevel float64, triggerFactor, copingStrategy string) (*domain.MoodEntry, error)
+	CreateWithRecordedAt(userID int, journalID int, entryType, primaryEmotion string, intensityLevel float64, triggerFactor, copingStrategy string, recordedAt time.Time) (*domain.MoodEntry, error)
 	GetByID(id int, userID int) (*domain.MoodEntry, error)
-	GetAll(userID int, limit, offset int, startDate, endDate, entryType string) ([]*domain.MoodEntry, int, error)
+	Update(id int, userID int, update MoodEntryUpdate) (*domain.MoodEntry, error)
+	Delete(id int, userID int) error
+	GetHistory(id int, userID int) ([]*domain.MoodEntryAudit, error)
+	GetAll(userID int, limit, offset int, startDate, endDate, entryType, source string) ([]*domain.MoodEntry, int, error)
+	GetAnalytics(userID int, startDate, endDate, timezone string) (*domain.MoodAnalytics, error)
+	Export(userID int, opts MoodExportOptions) (*MoodExport, error)
+	GetForecast(userID int, timezone string) (*domain.MoodForecast, error)
+	GetTags(userID int, kind string) ([]*domain.MoodTag, error)
+	GetTagInsights(userID int, days, followUpHours int) (*domain.MoodTagInsights, error)
 }
 
+// defaultAnalyticsDays is the range used when the client doesn't send start_date
+const defaultAnalyticsDays = 30
+
+const (
+	defaultInsightDays = 90
+	maxInsightDays     = 365
+	// Follow-up window for coping strategies, in hours
+	minFollowUpHours     = 24
+	defaultFollowUpHours = 48
+	maxFollowUpHours     = 48
+	// Average intensity gain after a coping strategy that counts as an improvement
+	copingImprovementThreshold = 0.1
+	// Insights with fewer samples than this are flagged as low confidence
+	insightMinSamples = 5
+	maxMoodTagLength  = 100
+)
+
 // NewMoodUsecase creates a new mood use case
-func NewMoodUsecase(moodRepo postgres.MoodRepository, journalRepo postgres.JournalRepository) MoodUsecase {
+func NewMoodUsecase(moodRepo postgres.MoodRepository, journalRepo postgres.JournalRepository, tagRepo postgres.MoodTagRepository, emotionUsecase EmotionUsecase) MoodUsecase {
 	return &moodUsecase{
-		moodRepo:    moodRepo,
-		journalRepo: journalRepo,
+		moodRepo:       moodRepo,
+		journalRepo:    journalRepo,
+		tagRepo:        tagRepo,
+		emotionUsecase: emotionUsecase,
 	}
 }
 
 func (u *moodUsecase) Create(userID int, journalID int, entryType, primaryEmotion string, intensityLevel float64, triggerFactor, copingStrategy string) (*domain.MoodEntry, error) {
-	// Validate entry type
-	// Add "journal" to the list of valid entry types
-	isValidEntryType := false
-	allowedEntryTypes := []string{"daily", "event", "reflection", "journal"} // <-- MODIFIED HERE
-	for _, allowedType := range allowedEntryTypes {
-		if entryType == allowedType {
-			isValidEntryType = true
-			break
-		}
+	return u.CreateWithRecordedAt(userID, journalID, entryType, primaryEmotion, intensityLevel, triggerFactor, copingStrategy, time.Time{})
+}
+
+// CreateWithRecordedAt creates a mood entry at a given time, e.g. for imported journals.
+// A zero recordedAt means "now".
+func (u *moodUsecase) CreateWithRecordedAt(userID int, journalID int, entryType, primaryEmotion string, intensityLevel float64, triggerFactor, copingStrategy string, recordedAt time.Time) (*domain.MoodEntry, error) {
+	if err := u.validateEntry(userID, journalID, entryType, intensityLevel); err != nil {
+		return nil, err
+	}
+
+	primaryEmotion, err := u.canonicalEmotion(primaryEmotion)
+	if err != nil {
+		return nil, err
+	}
+
+	// A journalID of 0 means the entry isn't linked, the repository stores it as NULL
+	source := MoodSourceManual
+	if journalID > 0 {
+		source = MoodSourceJournal
+	}
+
+	entry := &domain.MoodEntry{
+		UserID:         userID,
+		JournalID:      journalID,
+		EntryType:      entryType,
+		PrimaryEmotion: primaryEmotion,
+		IntensityLevel: intensityLevel,
+		TriggerFactor:  triggerFactor,
+		CopingStrategy: copingStrategy,
+		RecordedAt:     recordedAt, // moodRepo.Create uses the current time when zero
+		Source:         source,
+	}
+
+	created, err := u.moodRepo.Create(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	u.syncTags(created)
+	return created, nil
+}
+
+// validateEntry holds the checks shared by Create and Update
+func (u *moodUsecase) validateEntry(userID int, journalID int, entryType string, intensityLevel float64) error {
+	// Validate entry type against the mood_entry_types table
+	isValidEntryType, err := u.emotionUsecase.IsValidEntryType(entryType)
+	if err != nil {
+		return err
 	}
 	if !isValidEntryType {
-		return nil, errors.New("invalid entry type")
+		return errors.New("invalid entry type")
 	}
 
 	// Validate intensity level (0.0 to 1.0 as per previous context)
 	if intensityLevel < 0 || intensityLevel > 1.0 { // Assuming 0-1 scale
-		return nil, errors.New("intensity level must be between 0.0 and 1.0")
+		return errors.New("intensity level must be between 0.0 and 1.0")
 	}
 
 	// Validate journal exists and belongs to the user if journal ID is provided (optional, but good practice)
 	if journalID > 0 { // Assuming journalID can be 0 or less if not linked
 		if u.journalRepo == nil {
 			// This check is important if journalRepo is optional or might not be initialized
-			return nil, errors.New("journal repository is not initialized in moodUsecase")
+			return errors.New("journal repository is not initialized in moodUsecase")
 		}
 		journal, err := u.journalRepo.GetByID(journalID, userID)
 		if err != nil {
 			// Log the actual error from journalRepo.GetByID for debugging
 			// log.Printf("Error fetching journal %d for user %d: %v", journalID, userID, err)
-			return nil, errors.New("failed to verify journal existence")
+			return errors.New("failed to verify journal existence")
 		}
 		if journal == nil {
-			return nil, errors.New("journal entry not found or does not belong to user")
+			return errors.New("journal entry not found or does not belong to user")
 		}
 	}
 
-	entry := &domain.MoodEntry{
-		UserID: userID,
-		// JournalID can be tricky if 0 is a valid ID.
-		// If 0 means "not linked", ensure your DB schema allows NULL for journal_id
-		// and your MoodEntry struct uses a pointer like *int or sql.NullInt64 for JournalID.
-		// For simplicity, assuming journalID > 0 means it's linked.
-		JournalID:      journalID,
-		EntryType:      entryType,
-		PrimaryEmotion: primaryEmotion,
-		IntensityLevel: intensityLevel,
-		TriggerFactor:  triggerFactor,
-		CopingStrategy: copingStrategy,
-		// RecordedAt will be set by moodRepo.Create
-	}
+	return nil
+}
 
-	return u.moodRepo.Create(entry)
+// canonicalEmotion checks an emotion against the taxonomy and returns its stored label
+func (u *moodUsecase) canonicalEmotion(primaryEmotion string) (string, error) {
+	emotion, err := u.emotionUsecase.ResolveEmotion(primaryEmotion)
+	if err != nil {
+		return "", err
+	}
+	if emotion == nil {
+		return "", errors.New("unknown primary emotion")
+	}
+	return emotion.Label, nil
 }
 
 func (u *moodUsecase) GetByID(id int, userID int) (*domain.MoodEntry, error) {
 	return u.moodRepo.GetByID(id, userID)
 }
 
-func (u *moodUsecase) GetAll(userID int, limit, offset int, startDateStr, endDateStr, entryType string) ([]*domain.MoodEntry, int, error) {
+// Update applies a partial update with the same validation as Create. The previous
+// values are kept in the audit trail.
+func (u *moodUsecase) Update(id int, userID int, update MoodEntryUpdate) (*domain.MoodEntry, error) {
+	entry, err := u.moodRepo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrMoodEntryNotFound
+	}
+
+	// Only check the journal when the link changes, the current one may be in the trash by now
+	journalToCheck := 0
+	if update.JournalID != nil && *update.JournalID != entry.JournalID {
+		entry.JournalID = *update.JournalID
+		journalToCheck = entry.JournalID
+	}
+	if update.EntryType != nil {
+		entry.EntryType = *update.EntryType
+	}
+	if update.PrimaryEmotion != nil && *update.PrimaryEmotion != entry.PrimaryEmotion {
+		if entry.PrimaryEmotion, err = u.canonicalEmotion(*update.PrimaryEmotion); err != nil {
+			return nil, err
+		}
+	}
+	if update.IntensityLevel != nil {
+		entry.IntensityLevel = *update.IntensityLevel
+	}
+	if update.TriggerFactor != nil {
+		entry.TriggerFactor = *update.TriggerFactor
+	}
+	if update.CopingStrategy != nil {
+		entry.CopingStrategy = *update.CopingStrategy
+	}
+	if update.RecordedAt != nil {
+		if update.RecordedAt.After(time.Now()) {
+			return nil, errors.New("recorded_at cannot be in the future")
+		}
+		entry.RecordedAt = *update.RecordedAt
+	}
+
+	if err := u.validateEntry(userID, journalToCheck, entry.EntryType, entry.IntensityLevel); err != nil {
+		return nil, err
+	}
+
+	if err := u.moodRepo.Update(entry); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMoodEntryNotFound // Deleted in the meantime
+		}
+		return nil, err
+	}
+
+	if update.TriggerFactor != nil || update.CopingStrategy != nil {
+		u.syncTags(entry)
+	}
+	return entry, nil
+}
+
+func (u *moodUsecase) Delete(id int, userID int) error {
+	if err := u.moodRepo.Delete(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrMoodEntryNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetHistory returns the audit trail of an entry, including entries that were deleted
+func (u *moodUsecase) GetHistory(id int, userID int) ([]*domain.MoodEntryAudit, error) {
+	audits, err := u.moodRepo.GetAuditByEntryID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(audits) == 0 {
+		entry, err := u.moodRepo.GetByID(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return nil, ErrMoodEntryNotFound
+		}
+	}
+	return audits, nil
+}
+
+func (u *moodUsecase) GetAll(userID int, limit, offset int, startDateStr, endDateStr, entryType, source string) ([]*domain.MoodEntry, int, error) {
 	var startDate, endDate time.Time
 
 	if startDateStr != "" {
@@ -106,5 +276,231 @@ func (u *moodUsecase) GetAll(userID int, limit, offset int, startDateStr, endDat
 		}
 	}
 
-	return u.moodRepo.GetByUserID(userID, limit, offset, startDate, endDate, entryType)
+	switch source {
+	case "", MoodSourceJournal, MoodSourceManual, MoodSourceFacial:
+	default:
+		return nil, 0, errors.New("invalid source")
+	}
+
+	// Only the export may read without a page limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	return u.moodRepo.GetByUserID(userID, limit, offset, startDate, endDate, entryType, source)
+}
+
+// GetAnalytics aggregates the user's mood entries between two local dates (YYYY-MM-DD, both
+// inclusive) in their IANA timezone. Without dates it covers the last 30 days.
+func (u *moodUsecase) GetAnalytics(userID int, startDateStr, endDateStr, timezone string) (*domain.MoodAnalytics, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+	}
+
+	now := time.Now().In(loc)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if endDateStr != "" {
+		var err error
+		endDate, err = time.ParseInLocation("2006-01-02", endDateStr, loc)
+		if err != nil {
+			return nil, errors.New("invalid end_date format, expected YYYY-MM-DD")
+		}
+	}
+
+	startDate := endDate.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if startDateStr != "" {
+		var err error
+		startDate, err = time.ParseInLocation("2006-01-02", startDateStr, loc)
+		if err != nil {
+			return nil, errors.New("invalid start_date format, expected YYYY-MM-DD")
+		}
+	}
+
+	if startDate.After(endDate) {
+		return nil, errors.New("start_date must not be after end_date")
+	}
+
+	// The repository takes a half-open range, include the whole end date
+	rangeEnd := endDate.AddDate(0, 0, 1)
+	tz := loc.String()
+
+	analytics := &domain.MoodAnalytics{
+		Timezone:  tz,
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+	}
+
+	var err error
+	if analytics.Daily, err = u.moodRepo.GetAverages(userID, "day", tz, startDate, rangeEnd); err != nil {
+		return nil, err
+	}
+	if analytics.Weekly, err = u.moodRepo.GetAverages(userID, "week", tz, startDate, rangeEnd); err != nil {
+		return nil, err
+	}
+	if analytics.Monthly, err = u.moodRepo.GetAverages(userID, "month", tz, startDate, rangeEnd); err != nil {
+		return nil, err
+	}
+	if analytics.EmotionDistribution, err = u.moodRepo.GetEmotionDistribution(userID, startDate, rangeEnd); err != nil {
+		return nil, err
+	}
+	if analytics.Weekdays, err = u.moodRepo.GetWeekdayAverages(userID, tz, startDate, rangeEnd); err != nil {
+		return nil, err
+	}
+	if analytics.Streak, err = u.moodRepo.GetStreak(userID, tz); err != nil {
+		return nil, err
+	}
+	sourceAverages, err := u.moodRepo.GetSourceAverages(userID, tz, startDate, rangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Overall figures come from the daily buckets so they match the charts
+	var intensitySum float64
+	for _, day := range analytics.Daily {
+		analytics.TotalEntries += day.EntryCount
+		intensitySum += day.AverageIntensity * float64(day.EntryCount)
+	}
+	if analytics.TotalEntries > 0 {
+		analytics.AverageIntensity = intensitySum / float64(analytics.TotalEntries)
+	}
+
+	// Rows come ordered by source, so each source's days are contiguous
+	analytics.Sources = []*domain.MoodSource{}
+	var current *domain.MoodSource
+	for _, day := range sourceAverages {
+		if current == nil || current.Source != day.Source {
+			current = &domain.MoodSource{Source: day.Source, Daily: []*domain.MoodAverage{}}
+			analytics.Sources = append(analytics.Sources, current)
+		}
+		current.AverageIntensity += day.AverageIntensity * float64(day.EntryCount)
+		current.EntryCount += day.EntryCount
+		current.Daily = append(current.Daily, day)
+	}
+	for _, source := range analytics.Sources {
+		if source.EntryCount > 0 {
+			source.AverageIntensity /= float64(source.EntryCount)
+		}
+	}
+
+	for _, emotion := range analytics.EmotionDistribution {
+		if analytics.TotalEntries > 0 {
+			emotion.Percentage = float64(emotion.Count) * 100 / float64(analytics.TotalEntries)
+		}
+	}
+
+	for _, weekday := range analytics.Weekdays {
+		weekday.Name = time.Weekday(weekday.Weekday % 7).String()
+		if analytics.BestWeekday == nil || weekday.AverageIntensity > analytics.BestWeekday.AverageIntensity {
+			analytics.BestWeekday = weekday
+		}
+		if analytics.WorstWeekday == nil || weekday.AverageIntensity < analytics.WorstWeekday.AverageIntensity {
+			analytics.WorstWeekday = weekday
+		}
+	}
+
+	return analytics, nil
+}
+
+// syncTags stores the entry's trigger and coping text as normalized tags. Tags are derived
+// data, so a failure is logged instead of failing the entry that was already saved.
+func (u *moodUsecase) syncTags(entry *domain.MoodEntry) {
+	tags := append(parseMoodTags("trigger", entry.TriggerFactor), parseMoodTags("coping", entry.CopingStrategy)...)
+	if err := u.tagRepo.SetEntryTags(entry.ID, entry.UserID, tags); err != nil {
+		log.Printf("ERROR: Failed to save tags for mood entry %d: %v", entry.ID, err)
+	}
+}
+
+// parseMoodTags splits free text on commas, semicolons and new lines into tags.
+// "Work, deadline;  work" gives the tags "work" and "deadline".
+func parseMoodTags(kind, text string) []*domain.MoodTag {
+	parts := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n'
+	})
+
+	var tags []*domain.MoodTag
+	seen := map[string]bool{}
+	for _, part := range parts {
+		display := strings.Join(strings.Fields(part), " ")
+		if display == "" {
+			continue
+		}
+		display = truncateRunes(display, maxMoodTagLength, "")
+
+		name := strings.ToLower(display)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, &domain.MoodTag{Kind: kind, Name: name, DisplayName: display})
+	}
+	return tags
+}
+
+func (u *moodUsecase) GetTags(userID int, kind string) ([]*domain.MoodTag, error) {
+	if kind != "" && kind != "trigger" && kind != "coping" {
+		return nil, errors.New("kind must be trigger or coping")
+	}
+	return u.tagRepo.GetTagsByUserID(userID, kind)
+}
+
+// GetTagInsights reports which triggers come with low moods and which coping strategies are
+// followed by a better mood. Every figure carries its sample count, and small samples are
+// flagged so the client can say the data is still thin.
+func (u *moodUsecase) GetTagInsights(userID int, days, followUpHours int) (*domain.MoodTagInsights, error) {
+	if days <= 0 {
+		days = defaultInsightDays
+	}
+	if days > maxInsightDays {
+		return nil, fmt.Errorf("days must be at most %d", maxInsightDays)
+	}
+	if followUpHours == 0 {
+		followUpHours = defaultFollowUpHours
+	}
+	if followUpHours < minFollowUpHours || followUpHours > maxFollowUpHours {
+		return nil, fmt.Errorf("follow-up window must be between %d and %d hours", minFollowUpHours, maxFollowUpHours)
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	insights := &domain.MoodTagInsights{
+		PeriodDays:       days,
+		FollowUpHours:    followUpHours,
+		LowMoodThreshold: lowMoodThreshold,
+		MinSamples:       insightMinSamples,
+	}
+
+	total, low, err := u.tagRepo.GetLowMoodBaseline(userID, since, lowMoodThreshold)
+	if err != nil {
+		return nil, err
+	}
+	insights.BaselineSampleCount = total
+	if total > 0 {
+		insights.BaselineLowMoodRate = float64(low) / float64(total)
+	}
+
+	if insights.Triggers, err = u.tagRepo.GetTriggerInsights(userID, since, lowMoodThreshold, followUpHours); err != nil {
+		return nil, err
+	}
+	for _, trigger := range insights.Triggers {
+		if trigger.SampleCount > 0 {
+			trigger.LowMoodRate = float64(trigger.LowMoodCount) / float64(trigger.SampleCount)
+		}
+		trigger.LowConfidence = trigger.SampleCount < insightMinSamples
+	}
+
+	if insights.CopingStrategies, err = u.tagRepo.GetCopingInsights(userID, since, copingImprovementThreshold, followUpHours); err != nil {
+		return nil, err
+	}
+	for _, coping := range insights.CopingStrategies {
+		if coping.SampleCount > 0 {
+			coping.ImprovementRate = float64(coping.ImprovedCount) / float64(coping.SampleCount)
+		}
+		coping.LowConfidence = coping.SampleCount < insightMinSamples
+	}
+
+	return insights, nil
 }
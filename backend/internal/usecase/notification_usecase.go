@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+var (
+	ErrNotificationNotFound     = errors.New("notification not found")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+	ErrPushNotConfigured        = errors.New("web push is not configured")
+)
+
+type notificationUsecase struct {
+	notificationRepo postgres.NotificationRepository
+	pushPublicKey    string
+}
+
+// NotificationUsecase interface
+type NotificationUsecase interface {
+	GetNotifications(userID int, unreadOnly bool, limit, offset int) ([]*domain.Notification, int, int, error)
+	MarkRead(id, userID int) error
+	MarkAllRead(userID int) (int, error)
+	GetPushPublicKey() (string, error)
+	AddPushSubscription(subscription *domain.PushSubscription) (*domain.PushSubscription, error)
+	DeletePushSubscription(id, userID int) error
+}
+
+// NewNotificationUsecase creates a new notification use case. The push public key is
+// taken from the web push notifier when one is configured.
+func NewNotificationUsecase(notificationRepo postgres.NotificationRepository, notifiers []Notifier) NotificationUsecase {
+	u := &notificationUsecase{
+		notificationRepo: notificationRepo,
+	}
+	for _, notifier := range notifiers {
+		if pushNotifier, ok := notifier.(*webPushNotifier); ok {
+			u.pushPublicKey = pushNotifier.PublicKey()
+		}
+	}
+	return u
+}
+
+func (u *notificationUsecase) GetNotifications(userID int, unreadOnly bool, limit, offset int) ([]*domain.Notification, int, int, error) {
+	return u.notificationRepo.GetNotificationsByUserID(userID, unreadOnly, limit, offset)
+}
+
+func (u *notificationUsecase) MarkRead(id, userID int) error {
+	if err := u.notificationRepo.MarkNotificationRead(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotificationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *notificationUsecase) MarkAllRead(userID int) (int, error) {
+	return u.notificationRepo.MarkAllNotificationsRead(userID)
+}
+
+func (u *notificationUsecase) GetPushPublicKey() (string, error) {
+	if u.pushPublicKey == "" {
+		return "", ErrPushNotConfigured
+	}
+	return u.pushPublicKey, nil
+}
+
+func (u *notificationUsecase) AddPushSubscription(subscription *domain.PushSubscription) (*domain.PushSubscription, error) {
+	if u.pushPublicKey == "" {
+		return nil, ErrPushNotConfigured
+	}
+
+	endpoint, err := parsePushEndpoint(subscription.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Endpoint = endpoint.String()
+
+	if strings.TrimSpace(subscription.P256dh) == "" || strings.TrimSpace(subscription.Auth) == "" {
+		return nil, errors.New("p256dh and auth keys are required")
+	}
+
+	return u.notificationRepo.SavePushSubscription(subscription)
+}
+
+func (u *notificationUsecase) DeletePushSubscription(id, userID int) error {
+	if err := u.notificationRepo.DeletePushSubscription(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPushSubscriptionNotFound
+		}
+		return err
+	}
+	return nil
+}
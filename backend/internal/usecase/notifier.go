@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"warasin/internal/config"
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Notification channels users can pick in their reminder schedule
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+	NotificationChannelPush  = "push"
+)
+
+// Notifier delivers a notification to a user over one channel
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, user *domain.User, notification *domain.Notification) error
+}
+
+// NewNotifiers returns the notifiers enabled by the configuration. The in-app inbox
+// is always available, email and push only when their credentials are set.
+func NewNotifiers(cfg *config.Config, notificationRepo postgres.NotificationRepository) []Notifier {
+	notifiers := []Notifier{NewInAppNotifier(notificationRepo)}
+
+	if cfg.SMTPHost != "" {
+		notifiers = append(notifiers, NewEmailNotifier(cfg))
+	}
+
+	if cfg.VAPIDPrivateKey != "" {
+		pushNotifier, err := NewWebPushNotifier(cfg, notificationRepo)
+		if err != nil {
+			log.Printf("Web push disabled: %v", err)
+		} else {
+			notifiers = append(notifiers, pushNotifier)
+		}
+	}
+
+	return notifiers
+}
+
+// inAppNotifier stores the notification in the user's inbox
+type inAppNotifier struct {
+	notificationRepo postgres.NotificationRepository
+}
+
+func NewInAppNotifier(notificationRepo postgres.NotificationRepository) Notifier {
+	return &inAppNotifier{
+		notificationRepo: notificationRepo,
+	}
+}
+
+func (n *inAppNotifier) Channel() string {
+	return NotificationChannelInApp
+}
+
+func (n *inAppNotifier) Notify(ctx context.Context, user *domain.User, notification *domain.Notification) error {
+	notification.UserID = user.ID
+	_, err := n.notificationRepo.CreateNotification(notification)
+	return err
+}
+
+// emailNotifier sends a plain text email over SMTP
+type emailNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewEmailNotifier(cfg *config.Config) Notifier {
+	return &emailNotifier{
+		addr:     net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		host:     cfg.SMTPHost,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+	}
+}
+
+func (n *emailNotifier) Channel() string {
+	return NotificationChannelEmail
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, user *domain.User, notification *domain.Notification) error {
+	if user.Email == "" {
+		return errors.New("user has no email address")
+	}
+
+	sender, err := mail.ParseAddress(n.from)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+
+	var message strings.Builder
+	message.WriteString("From: " + n.from + "\r\n")
+	message.WriteString("To: " + user.Email + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", notification.Title) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(notification.Body + "\r\n")
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	return smtp.SendMail(n.addr, auth, sender.Address, []string{user.Email}, []byte(message.String()))
+}
+
+// pushServiceHosts are the push services browsers hand out subscriptions for. The notifier
+// POSTs to stored endpoints, so anything else is refused to keep it off internal hosts.
+var pushServiceHosts = []string{
+	"fcm.googleapis.com",
+	"android.googleapis.com",
+	".push.services.mozilla.com",
+	".push.apple.com",
+	".notify.windows.com",
+}
+
+// parsePushEndpoint parses a subscription endpoint and checks it is an https URL on a
+// known push service. Hosts starting with a dot match their subdomains.
+func parsePushEndpoint(raw string) (*url.URL, error) {
+	endpoint, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || endpoint.Scheme != "https" || endpoint.User != nil {
+		return nil, errors.New("endpoint must be an https URL")
+	}
+	if port := endpoint.Port(); port != "" && port != "443" {
+		return nil, errors.New("endpoint must use the default https port")
+	}
+
+	host := strings.ToLower(endpoint.Hostname())
+	for _, allowed := range pushServiceHosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return endpoint, nil
+		}
+	}
+	return nil, errors.New("endpoint is not a known push service")
+}
+
+// webPushNotifier sends Web Push messages signed with VAPID. Messages carry no payload,
+// so no RFC 8291 encryption is needed: the service worker shows the reminder and fetches
+// the inbox for details.
+type webPushNotifier struct {
+	notificationRepo postgres.NotificationRepository
+	privateKey       *ecdsa.PrivateKey
+	publicKey        string
+	subject          string
+	httpClient       *http.Client
+}
+
+// NewWebPushNotifier parses the VAPID private key and derives the public key from it
+func NewWebPushNotifier(cfg *config.Config, notificationRepo postgres.NotificationRepository) (*webPushNotifier, error) {
+	scalar, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cfg.VAPIDPrivateKey, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID_PRIVATE_KEY: %w", err)
+	}
+
+	ecdhKey, err := ecdh.P256().NewPrivateKey(scalar)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID_PRIVATE_KEY: %w", err)
+	}
+
+	// Uncompressed point: 0x04 || X || Y
+	point := ecdhKey.PublicKey().Bytes()
+	privateKey := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(scalar),
+	}
+
+	return &webPushNotifier{
+		notificationRepo: notificationRepo,
+		privateKey:       privateKey,
+		publicKey:        base64.RawURLEncoding.EncodeToString(point),
+		subject:          cfg.VAPIDSubject,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+			// Push services answer directly, a redirect could point anywhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+func (n *webPushNotifier) Channel() string {
+	return NotificationChannelPush
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with
+func (n *webPushNotifier) PublicKey() string {
+	return n.publicKey
+}
+
+func (n *webPushNotifier) Notify(ctx context.Context, user *domain.User, notification *domain.Notification) error {
+	subscriptions, err := n.notificationRepo.GetPushSubscriptionsByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return errors.New("user has no push subscriptions")
+	}
+
+	delivered := 0
+	var lastErr error
+	for _, subscription := range subscriptions {
+		if err := n.send(ctx, subscription); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered++
+	}
+
+	if delivered == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+func (n *webPushNotifier) send(ctx context.Context, subscription *domain.PushSubscription) error {
+	// Checked again here for subscriptions stored before endpoints were restricted
+	endpoint, err := parsePushEndpoint(subscription.Endpoint)
+	if err != nil {
+		return fmt.Errorf("push subscription %d: %w", subscription.ID, err)
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": n.subject,
+	}).SignedString(n.privateKey)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "vapid t="+token+", k="+n.publicKey)
+	req.Header.Set("TTL", "86400")
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The browser unsubscribed, stop sending to it
+		if err := n.notificationRepo.DeletePushSubscriptionByEndpoint(subscription.Endpoint); err != nil {
+			log.Printf("Failed to delete expired push subscription %d: %v", subscription.ID, err)
+		}
+		return fmt.Errorf("push subscription %d expired", subscription.ID)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+var ErrQuestionnaireNotFound = errors.New("questionnaire not found")
+
+const (
+	defaultQuestionnaireTrendDays = 180
+	maxQuestionnaireTrendDays     = 730
+)
+
+// Shown when a safety rule matches, e.g. PHQ-9 item 9 about thoughts of self-harm
+const (
+	questionnaireSafetyMessageID = "Terima kasih sudah jujur menjawab. Jawabanmu menunjukkan kamu mungkin sedang memikirkan untuk menyakiti diri sendiri. Kamu tidak sendirian, bicarakan dengan seseorang sekarang juga melalui layanan di bawah ini."
+	questionnaireSafetyMessageEN = "Thank you for answering honestly. Your answers suggest you may be having thoughts of hurting yourself. You are not alone, please talk to someone now through one of the services below."
+)
+
+type questionnaireUsecase struct {
+	questionnaireRepo postgres.QuestionnaireRepository
+}
+
+// QuestionnaireUsecase interface
+type QuestionnaireUsecase interface {
+	GetQuestionnaires() ([]*domain.Questionnaire, error)
+	GetQuestionnaire(code string) (*domain.Questionnaire, error)
+	Submit(userID int, code string, answers []int) (*domain.QuestionnaireResponse, error)
+	GetHistory(userID int, code string, limit, offset int) ([]*domain.QuestionnaireResponse, int, error)
+	GetTrend(userID int, code string, days int) (*domain.QuestionnaireTrend, error)
+}
+
+// NewQuestionnaireUsecase creates a new questionnaire use case
+func NewQuestionnaireUsecase(questionnaireRepo postgres.QuestionnaireRepository) QuestionnaireUsecase {
+	return &questionnaireUsecase{
+		questionnaireRepo: questionnaireRepo,
+	}
+}
+
+func (u *questionnaireUsecase) GetQuestionnaires() ([]*domain.Questionnaire, error) {
+	return u.questionnaireRepo.GetActive()
+}
+
+// GetQuestionnaire returns the latest active version of an instrument
+func (u *questionnaireUsecase) GetQuestionnaire(code string) (*domain.Questionnaire, error) {
+	questionnaire, err := u.questionnaireRepo.GetLatestByCode(strings.ToLower(code))
+	if err != nil {
+		return nil, err
+	}
+	if questionnaire == nil {
+		return nil, ErrQuestionnaireNotFound
+	}
+	return questionnaire, nil
+}
+
+// Submit scores the answers against the latest version of the instrument and stores them.
+// Answers are given in item order.
+func (u *questionnaireUsecase) Submit(userID int, code string, answers []int) (*domain.QuestionnaireResponse, error) {
+	questionnaire, err := u.GetQuestionnaire(code)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := questionnaire.Definition
+	if len(answers) != len(definition.Items) {
+		return nil, fmt.Errorf("expected %d answers, got %d", len(definition.Items), len(answers))
+	}
+
+	validValues := make(map[int]bool, len(definition.Options))
+	for _, option := range definition.Options {
+		validValues[option.Value] = true
+	}
+	for i, answer := range answers {
+		if !validValues[answer] {
+			return nil, fmt.Errorf("invalid answer %d for item %d", answer, definition.Items[i].Number)
+		}
+	}
+
+	total := scoreQuestionnaire(definition, answers)
+	band := severityBandFor(definition, total)
+
+	response := &domain.QuestionnaireResponse{
+		UserID:          userID,
+		QuestionnaireID: questionnaire.ID,
+		Code:            questionnaire.Code,
+		Version:         questionnaire.Version,
+		Answers:         answers,
+		TotalScore:      total,
+		MaxScore:        maxQuestionnaireScore(definition),
+		SafetyFlagged:   safetyRuleMatched(definition, answers),
+	}
+	if band != nil {
+		response.Severity = band.Severity
+		response.SeverityBand = band
+	}
+
+	response, err = u.questionnaireRepo.CreateResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.SafetyFlagged {
+		response.Safety = &domain.SafetyNotice{
+			MessageID: questionnaireSafetyMessageID,
+			MessageEN: questionnaireSafetyMessageEN,
+			Hotlines:  IndonesianHotlines,
+		}
+	}
+
+	return response, nil
+}
+
+func (u *questionnaireUsecase) GetHistory(userID int, code string, limit, offset int) ([]*domain.QuestionnaireResponse, int, error) {
+	responses, total, err := u.questionnaireRepo.GetResponsesByUserID(userID, strings.ToLower(code), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Max scores come from the version each response was given on
+	definitions := map[int]*domain.Questionnaire{}
+	for _, response := range responses {
+		questionnaire, ok := definitions[response.QuestionnaireID]
+		if !ok {
+			questionnaire, err = u.questionnaireRepo.GetByID(response.QuestionnaireID)
+			if err != nil {
+				return nil, 0, err
+			}
+			definitions[response.QuestionnaireID] = questionnaire
+		}
+		if questionnaire != nil {
+			response.MaxScore = maxQuestionnaireScore(questionnaire.Definition)
+		}
+	}
+
+	return responses, total, nil
+}
+
+// GetTrend returns the scores of the last days, using the bands of the latest version
+func (u *questionnaireUsecase) GetTrend(userID int, code string, days int) (*domain.QuestionnaireTrend, error) {
+	if days <= 0 {
+		days = defaultQuestionnaireTrendDays
+	}
+	if days > maxQuestionnaireTrendDays {
+		days = maxQuestionnaireTrendDays
+	}
+
+	questionnaire, err := u.GetQuestionnaire(code)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := u.questionnaireRepo.GetScoresByUserID(userID, questionnaire.Code, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.QuestionnaireTrend{
+		Code:          questionnaire.Code,
+		MaxScore:      maxQuestionnaireScore(questionnaire.Definition),
+		SeverityBands: questionnaire.Definition.SeverityBands,
+		Points:        points,
+	}, nil
+}
+
+func maxOptionValue(definition domain.QuestionnaireDefinition) int {
+	max := 0
+	for _, option := range definition.Options {
+		if option.Value > max {
+			max = option.Value
+		}
+	}
+	return max
+}
+
+func maxQuestionnaireScore(definition domain.QuestionnaireDefinition) int {
+	return maxOptionValue(definition) * len(definition.Items)
+}
+
+// scoreQuestionnaire sums the answers, flipping reverse scored items
+func scoreQuestionnaire(definition domain.QuestionnaireDefinition, answers []int) int {
+	reverse := make(map[int]bool, len(definition.ReverseScored))
+	for _, number := range definition.ReverseScored {
+		reverse[number] = true
+	}
+
+	maxValue := maxOptionValue(definition)
+	total := 0
+	for i, answer := range answers {
+		if reverse[definition.Items[i].Number] {
+			answer = maxValue - answer
+		}
+		total += answer
+	}
+	return total
+}
+
+func severityBandFor(definition domain.QuestionnaireDefinition, total int) *domain.SeverityBand {
+	for i := range definition.SeverityBands {
+		band := definition.SeverityBands[i]
+		if total >= band.Min && total <= band.Max {
+			return &band
+		}
+	}
+	return nil
+}
+
+// safetyRuleMatched checks the raw answers, reverse scoring doesn't apply here
+func safetyRuleMatched(definition domain.QuestionnaireDefinition, answers []int) bool {
+	for _, rule := range definition.SafetyRules {
+		for i, item := range definition.Items {
+			if item.Number == rule.Item && answers[i] >= rule.MinValue {
+				return true
+			}
+		}
+	}
+	return false
+}
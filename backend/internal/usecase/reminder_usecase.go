@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+// Defaults for users who haven't saved a schedule yet. Reminders are opt-in.
+const (
+	defaultReminderTimezone = "Asia/Jakarta"
+	defaultReminderTime     = "20:00"
+)
+
+const (
+	moodReminderTitle = "Bagaimana perasaanmu hari ini?"
+	moodReminderBody  = "Luangkan satu menit untuk mencatat suasana hatimu di WarasIn. Catatan kecil setiap hari membantumu mengenali polanya."
+	moodReminderLink  = "/main/monitoring"
+)
+
+type reminderUsecase struct {
+	notificationRepo postgres.NotificationRepository
+	moodRepo         postgres.MoodRepository
+	userRepo         postgres.UserRepository
+	notifiers        map[string]Notifier
+	channels         []string
+}
+
+// ReminderUsecase interface
+type ReminderUsecase interface {
+	GetSchedule(userID int) (*domain.ReminderSchedule, error)
+	UpdateSchedule(schedule *domain.ReminderSchedule) (*domain.ReminderSchedule, error)
+	SendDueReminders(ctx context.Context, now time.Time) (int, error)
+}
+
+// NewReminderUsecase creates a new reminder use case
+func NewReminderUsecase(notificationRepo postgres.NotificationRepository, moodRepo postgres.MoodRepository, userRepo postgres.UserRepository, notifiers []Notifier) ReminderUsecase {
+	u := &reminderUsecase{
+		notificationRepo: notificationRepo,
+		moodRepo:         moodRepo,
+		userRepo:         userRepo,
+		notifiers:        make(map[string]Notifier, len(notifiers)),
+	}
+	for _, notifier := range notifiers {
+		u.notifiers[notifier.Channel()] = notifier
+		u.channels = append(u.channels, notifier.Channel())
+	}
+	return u
+}
+
+// GetSchedule returns the user's schedule, or the disabled default when they have none
+func (u *reminderUsecase) GetSchedule(userID int) (*domain.ReminderSchedule, error) {
+	schedule, err := u.notificationRepo.GetSchedule(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule == nil {
+		schedule = &domain.ReminderSchedule{
+			UserID:       userID,
+			Enabled:      false,
+			Timezone:     defaultReminderTimezone,
+			ReminderTime: defaultReminderTime,
+			Channels:     []string{NotificationChannelInApp},
+		}
+	}
+
+	schedule.AvailableChannels = u.channels
+	return schedule, nil
+}
+
+func (u *reminderUsecase) UpdateSchedule(schedule *domain.ReminderSchedule) (*domain.ReminderSchedule, error) {
+	if schedule.Timezone == "" {
+		schedule.Timezone = defaultReminderTimezone
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+
+	if _, err := parseClock(schedule.ReminderTime); err != nil {
+		return nil, errors.New("reminder_time must be in HH:MM format")
+	}
+
+	if (schedule.QuietHoursStart == nil) != (schedule.QuietHoursEnd == nil) {
+		return nil, errors.New("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if schedule.QuietHoursStart != nil {
+		start, err := parseClock(*schedule.QuietHoursStart)
+		if err != nil {
+			return nil, errors.New("quiet_hours_start must be in HH:MM format")
+		}
+		end, err := parseClock(*schedule.QuietHoursEnd)
+		if err != nil {
+			return nil, errors.New("quiet_hours_end must be in HH:MM format")
+		}
+		if start == end {
+			return nil, errors.New("quiet hours must not start and end at the same time")
+		}
+	}
+
+	channels := []string{}
+	seen := map[string]bool{}
+	for _, channel := range schedule.Channels {
+		if seen[channel] {
+			continue
+		}
+		if _, ok := u.notifiers[channel]; !ok {
+			return nil, fmt.Errorf("notification channel %q is not available", channel)
+		}
+		seen[channel] = true
+		channels = append(channels, channel)
+	}
+	if len(channels) == 0 {
+		return nil, errors.New("at least one notification channel is required")
+	}
+	schedule.Channels = channels
+
+	updated, err := u.notificationRepo.UpsertSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.AvailableChannels = u.channels
+	return updated, nil
+}
+
+// SendDueReminders reminds every user whose reminder time has passed today in their own
+// timezone, who isn't in quiet hours and who hasn't logged a mood yet today. A reminder
+// held back by quiet hours goes out when they end, if it's still the same day.
+// Returns the number of users reminded.
+func (u *reminderUsecase) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	schedules, err := u.notificationRepo.GetEnabledSchedules()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, schedule := range schedules {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		location, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			log.Printf("Skipping reminder for user %d: invalid timezone %q", schedule.UserID, schedule.Timezone)
+			continue
+		}
+
+		local := now.In(location)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+		if schedule.LastSentOn != nil && schedule.LastSentOn.Format("2006-01-02") >= today.Format("2006-01-02") {
+			continue
+		}
+		if !reminderDue(schedule, local) {
+			continue
+		}
+
+		checkedIn, err := u.moodRepo.HasEntriesSince(schedule.UserID, today)
+		if err != nil {
+			log.Printf("Failed to check mood entries for user %d: %v", schedule.UserID, err)
+			continue
+		}
+		if checkedIn {
+			continue
+		}
+
+		// Claiming first means a failed delivery isn't retried, but a reminder is never sent twice
+		claimed, err := u.notificationRepo.ClaimReminder(schedule.UserID, today)
+		if err != nil {
+			log.Printf("Failed to claim reminder for user %d: %v", schedule.UserID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if u.deliver(ctx, schedule) {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// deliver sends the reminder over every channel the user picked, reporting whether any succeeded
+func (u *reminderUsecase) deliver(ctx context.Context, schedule *domain.ReminderSchedule) bool {
+	user, err := u.userRepo.GetByID(schedule.UserID)
+	if err != nil || user == nil {
+		log.Printf("Failed to load user %d for reminder: %v", schedule.UserID, err)
+		return false
+	}
+
+	delivered := false
+	for _, channel := range schedule.Channels {
+		notifier, ok := u.notifiers[channel]
+		if !ok {
+			continue // Channel was disabled in the configuration after the user picked it
+		}
+
+		notification := &domain.Notification{
+			UserID: user.ID,
+			Kind:   "mood_reminder",
+			Title:  moodReminderTitle,
+			Body:   moodReminderBody,
+			Link:   moodReminderLink,
+		}
+		if err := notifier.Notify(ctx, user, notification); err != nil {
+			log.Printf("Failed to send %s reminder to user %d: %v", channel, user.ID, err)
+			continue
+		}
+		delivered = true
+	}
+
+	return delivered
+}
+
+// reminderDue reports whether the reminder time has passed and local is outside quiet hours
+func reminderDue(schedule *domain.ReminderSchedule, local time.Time) bool {
+	reminderAt, err := parseClock(schedule.ReminderTime)
+	if err != nil {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	if minute < reminderAt {
+		return false
+	}
+
+	if schedule.QuietHoursStart != nil && schedule.QuietHoursEnd != nil {
+		start, errStart := parseClock(*schedule.QuietHoursStart)
+		end, errEnd := parseClock(*schedule.QuietHoursEnd)
+		if errStart == nil && errEnd == nil && inQuietHours(minute, start, end) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inQuietHours handles ranges that wrap past midnight, such as 22:00 to 07:00
+func inQuietHours(minute, start, end int) bool {
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock converts HH:MM to minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"warasin/internal/domain"
+)
+
+const (
+	// Most keywords of a message searched for, the first ones win
+	retrievalMaxTerms = 12
+	// Shorter words are too vague to search for
+	retrievalMinTermChars = 3
+	// From this many keywords on a resource has to match two of them
+	retrievalStrictTerms = 4
+)
+
+// Words that say nothing about the topic of a message, in Indonesian and English. Resources
+// are indexed without stemming or stop words, so these would match almost everything.
+var retrievalStopWords = map[string]bool{
+	// Indonesian
+	"aku": true, "saya": true, "kamu": true, "anda": true, "dia": true, "kami": true, "kita": true, "mereka": true,
+	"yang": true, "dan": true, "atau": true, "tapi": true, "tetapi": true, "karena": true, "jadi": true, "kalau": true,
+	"jika": true, "untuk": true, "dari": true, "dengan": true, "pada": true, "dalam": true, "akan": true, "sudah": true,
+	"belum": true, "sedang": true, "masih": true, "lagi": true, "juga": true, "saja": true, "aja": true, "banget": true,
+	"sekali": true, "sangat": true, "tidak": true, "nggak": true, "enggak": true, "gak": true, "bukan": true, "ini": true,
+	"itu": true, "ada": true, "bisa": true, "mau": true, "ingin": true, "pengen": true, "harus": true, "apa": true,
+	"bagaimana": true, "gimana": true, "kenapa": true, "mengapa": true, "kapan": true, "dimana": true, "siapa": true,
+	"seperti": true, "kayak": true, "hari": true, "waktu": true, "lebih": true, "terus": true, "sama": true, "udah": true,
+	"dong": true, "deh": true, "nih": true, "sih": true, "kok": true, "yah": true, "punya": true, "merasa": true, "rasanya": true,
+	// English
+	"the": true, "and": true, "but": true, "for": true, "with": true, "about": true, "from": true, "this": true,
+	"that": true, "these": true, "those": true, "you": true, "your": true, "they": true, "them": true, "she": true,
+	"him": true, "her": true, "his": true, "our": true, "are": true, "was": true, "were": true, "been": true,
+	"being": true, "have": true, "has": true, "had": true, "not": true, "don": true, "can": true, "could": true,
+	"would": true, "should": true, "will": true, "just": true, "really": true, "very": true, "much": true, "what": true,
+	"how": true, "why": true, "when": true, "where": true, "who": true, "which": true, "feel": true, "feeling": true,
+	"like": true, "get": true, "got": true, "all": true, "any": true, "some": true, "more": true, "still": true,
+	"even": true, "because": true, "there": true, "here": true, "then": true, "than": true, "into": true, "out": true,
+	"want": true, "know": true, "today": true, "lately": true, "always": true, "never": true, "again": true,
+}
+
+// retrievalTerms picks the keywords of a message to search resources with, lowercased and
+// without stop words, in the order they appear
+func retrievalTerms(message string) []string {
+	words := strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < retrievalMinTermChars || retrievalStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == retrievalMaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// Retrieve finds up to limit resources relevant to a chat message. Messages with many
+// keywords need resources that match at least two of them, so one common word doesn't
+// pull in unrelated articles. Premium resources are only found for premium users.
+func (u *resourceUsecase) Retrieve(message, userType string, limit int) ([]*domain.ResourceMatch, error) {
+	terms := retrievalTerms(message)
+	if len(terms) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	minMatched := 1
+	if len(terms) >= retrievalStrictTerms {
+		minMatched = 2
+	}
+
+	return u.resourceRepo.Search(terms, guessLanguage(strings.ToLower(message)), userType == "premium", minMatched, limit)
+}
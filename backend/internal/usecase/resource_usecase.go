@@ -17,6 +17,7 @@ type ResourceUsecase interface {
 	GetResourceDetails(id int) (*domain.Resource, error)
 	CreateInteraction(userID, resourceID int, rating float64, feedbackText string) (*domain.ResourceInteraction, error)
 	GetInteractions(resourceID int, limit, offset int) ([]*domain.ResourceInteraction, int, error)
+	Retrieve(message, userType string, limit int) ([]*domain.ResourceMatch, error)
 }
 
 // NewResourceUsecase creates a new resource use case
@@ -0,0 +1,349 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"warasin/internal/domain"
+	"warasin/internal/repository/postgres"
+)
+
+var (
+	ErrWellbeingAlertNotFound = errors.New("wellbeing alert not found")
+	ErrTrustedContactNotFound = errors.New("trusted contact not found")
+)
+
+// Detection thresholds, on the same 0-1 intensity scale as mood entries
+const (
+	wellbeingWindowDays   = 7  // Recent period that is checked
+	wellbeingBaselineDays = 21 // Period before the window the recent mood is compared to
+	wellbeingMinDays      = 4  // Days with entries needed before a period is trusted
+
+	sustainedLowThreshold = 0.35 // Rolling mean below this is a sustained low
+	veryLowMoodThreshold  = 0.2
+	sharpDropThreshold    = 0.25 // Fall from the baseline mean that counts as a sharp drop
+	severeDropThreshold   = 0.4
+
+	// No new alert is raised within this long of the previous one
+	wellbeingAlertCooldown = 7 * 24 * time.Hour
+)
+
+// IndonesianHotlines are shown with every wellbeing alert
+var IndonesianHotlines = []domain.Hotline{
+	{Name: "Layanan SEJIWA (Sehat Jiwa)", Number: "119 ext. 8", Description: "Free mental health counselling from the Ministry of Health"},
+	{Name: "Halo Kemenkes", Number: "1500-567", Description: "Ministry of Health information and referral line"},
+	{Name: "Emergency services", Number: "112", Description: "Call if you or someone else is in immediate danger"},
+}
+
+type wellbeingUsecase struct {
+	wellbeingRepo    postgres.WellbeingRepository
+	moodRepo         postgres.MoodRepository
+	resourceRepo     postgres.ResourceRepository
+	notificationRepo postgres.NotificationRepository
+}
+
+// WellbeingUsecase interface
+type WellbeingUsecase interface {
+	AnalyzeMoodTrends(ctx context.Context, now time.Time) (int, error)
+	GetAlerts(userID int, status string, limit, offset int) ([]*domain.WellbeingAlert, int, error)
+	GetAlert(id, userID int) (*domain.WellbeingAlert, error)
+	UpdateAlertStatus(id, userID int, status string) (*domain.WellbeingAlert, error)
+	ShareAlert(id, userID, contactID int) (*domain.WellbeingAlertShare, error)
+	AddContact(userID int, name, relationship, email, phone string) (*domain.TrustedContact, error)
+	GetContacts(userID int) ([]*domain.TrustedContact, error)
+	DeleteContact(id, userID int) error
+}
+
+// NewWellbeingUsecase creates a new wellbeing use case
+func NewWellbeingUsecase(wellbeingRepo postgres.WellbeingRepository, moodRepo postgres.MoodRepository, resourceRepo postgres.ResourceRepository, notificationRepo postgres.NotificationRepository) WellbeingUsecase {
+	return &wellbeingUsecase{
+		wellbeingRepo:    wellbeingRepo,
+		moodRepo:         moodRepo,
+		resourceRepo:     resourceRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+// AnalyzeMoodTrends checks every user who logged a mood in the last week and raises an alert
+// when their mood has stayed low or dropped sharply. Returns the number of alerts raised.
+func (u *wellbeingUsecase) AnalyzeMoodTrends(ctx context.Context, now time.Time) (int, error) {
+	userIDs, err := u.moodRepo.GetUserIDsWithEntriesSince(now.AddDate(0, 0, -wellbeingWindowDays))
+	if err != nil {
+		return 0, err
+	}
+
+	raised := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return raised, ctx.Err()
+		}
+
+		alert, err := u.analyzeUser(userID, now)
+		if err != nil {
+			log.Printf("ERROR: Wellbeing analysis for user %d failed: %v", userID, err)
+			continue
+		}
+		if alert != nil {
+			raised++
+		}
+	}
+
+	return raised, nil
+}
+
+func (u *wellbeingUsecase) analyzeUser(userID int, now time.Time) (*domain.WellbeingAlert, error) {
+	latest, err := u.wellbeingRepo.GetLatestAlert(userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && now.Sub(latest.CreatedAt) < wellbeingAlertCooldown {
+		return nil, nil
+	}
+
+	// Days are the user's local days, so late-night entries count toward the right day
+	periods, err := dailyMoodPeriods(u.moodRepo, u.notificationRepo, userID, now, wellbeingWindowDays, wellbeingBaselineDays)
+	if err != nil {
+		return nil, err
+	}
+	recent, baseline := periods.Recent, periods.Previous
+
+	if len(recent) < wellbeingMinDays {
+		return nil, nil
+	}
+
+	recentAverage := mean(recent)
+	var baselineAverage *float64
+	if len(baseline) >= wellbeingMinDays {
+		b := mean(baseline)
+		baselineAverage = &b
+	}
+
+	alertType, severity := detectMoodDeterioration(recentAverage, baselineAverage)
+	if alertType == "" {
+		return nil, nil
+	}
+
+	return u.wellbeingRepo.CreateAlert(&domain.WellbeingAlert{
+		UserID:          userID,
+		AlertType:       alertType,
+		Severity:        severity,
+		RecentAverage:   recentAverage,
+		BaselineAverage: baselineAverage,
+		WindowStart:     periods.RecentStart,
+		WindowEnd:       periods.Today,
+	})
+}
+
+// detectMoodDeterioration returns the alert type and severity for a recent rolling mean,
+// or empty strings when the mood looks fine. baseline is nil without enough history.
+func detectMoodDeterioration(recent float64, baseline *float64) (string, string) {
+	if recent < sustainedLowThreshold {
+		if recent < veryLowMoodThreshold {
+			return "sustained_low", "high"
+		}
+		return "sustained_low", "moderate"
+	}
+
+	if baseline != nil {
+		drop := *baseline - recent
+		if drop >= severeDropThreshold {
+			return "sharp_drop", "high"
+		}
+		if drop >= sharpDropThreshold {
+			return "sharp_drop", "moderate"
+		}
+	}
+
+	return "", ""
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func (u *wellbeingUsecase) GetAlerts(userID int, status string, limit, offset int) ([]*domain.WellbeingAlert, int, error) {
+	alerts, total, err := u.wellbeingRepo.GetAlertsByUserID(userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resources := u.suggestedResources()
+	for _, alert := range alerts {
+		decorateAlert(alert, resources)
+	}
+
+	return alerts, total, nil
+}
+
+func (u *wellbeingUsecase) GetAlert(id, userID int) (*domain.WellbeingAlert, error) {
+	alert, err := u.wellbeingRepo.GetAlertByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrWellbeingAlertNotFound
+	}
+
+	decorateAlert(alert, u.suggestedResources())
+	return alert, nil
+}
+
+// UpdateAlertStatus lets the user acknowledge or dismiss an alert
+func (u *wellbeingUsecase) UpdateAlertStatus(id, userID int, status string) (*domain.WellbeingAlert, error) {
+	if status != "acknowledged" && status != "dismissed" {
+		return nil, errors.New("status must be acknowledged or dismissed")
+	}
+
+	if err := u.wellbeingRepo.UpdateAlertStatus(id, userID, status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWellbeingAlertNotFound
+		}
+		return nil, err
+	}
+
+	return u.GetAlert(id, userID)
+}
+
+// ShareAlert records that the user chose to share an alert with one of their trusted contacts
+// and returns a message for them to send. Nothing is sent on the user's behalf.
+func (u *wellbeingUsecase) ShareAlert(id, userID, contactID int) (*domain.WellbeingAlertShare, error) {
+	alert, err := u.GetAlert(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contact, err := u.wellbeingRepo.GetContactByID(contactID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		return nil, ErrTrustedContactNotFound
+	}
+
+	if err := u.wellbeingRepo.MarkAlertShared(id, userID, contactID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	alert.SharedContactID = &contact.ID
+	alert.SharedAt = &now
+
+	message := fmt.Sprintf(
+		"Hi %s, I'm using WarasIn to look after my mental health. My mood has been lower than usual between %s and %s and I wanted you to know. Could we talk soon?",
+		contact.Name,
+		alert.WindowStart.Format("2 Jan"),
+		alert.WindowEnd.Format("2 Jan 2006"),
+	)
+
+	share := &domain.WellbeingAlertShare{
+		Alert:   alert,
+		Contact: contact,
+		Message: message,
+	}
+	if contact.Email != "" {
+		share.EmailURL = "mailto:" + url.PathEscape(contact.Email) + "?subject=" + mailtoEscape("Checking in") + "&body=" + mailtoEscape(message)
+	}
+	if contact.Phone != "" {
+		share.WhatsAppURL = "https://wa.me/" + whatsAppNumber(contact.Phone) + "?text=" + url.QueryEscape(message)
+	}
+
+	return share, nil
+}
+
+// mailtoEscape escapes a mailto header value. Mail clients don't read + as a space,
+// so spaces are sent as %20.
+func mailtoEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func (u *wellbeingUsecase) AddContact(userID int, name, relationship, email, phone string) (*domain.TrustedContact, error) {
+	name = strings.TrimSpace(name)
+	email = strings.TrimSpace(email)
+	phone = strings.TrimSpace(phone)
+
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if email == "" && phone == "" {
+		return nil, errors.New("an email or phone number is required")
+	}
+	if phone != "" && len(digitsOnly(phone)) < 8 {
+		return nil, errors.New("invalid phone number")
+	}
+
+	return u.wellbeingRepo.CreateContact(&domain.TrustedContact{
+		UserID:       userID,
+		Name:         name,
+		Relationship: strings.TrimSpace(relationship),
+		Email:        email,
+		Phone:        phone,
+	})
+}
+
+func (u *wellbeingUsecase) GetContacts(userID int) ([]*domain.TrustedContact, error) {
+	return u.wellbeingRepo.GetContactsByUserID(userID)
+}
+
+func (u *wellbeingUsecase) DeleteContact(id, userID int) error {
+	if err := u.wellbeingRepo.DeleteContact(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTrustedContactNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// suggestedResources picks a few free resources to show with alerts. A failure here
+// shouldn't hide the alert itself.
+func (u *wellbeingUsecase) suggestedResources() []*domain.Resource {
+	resources, _, err := u.resourceRepo.GetResources("standard", "", "", 3, 0)
+	if err != nil {
+		log.Printf("WARN: Could not load resources for wellbeing alert: %v", err)
+		return nil
+	}
+	return resources
+}
+
+func decorateAlert(alert *domain.WellbeingAlert, resources []*domain.Resource) {
+	switch alert.AlertType {
+	case "sharp_drop":
+		alert.Message = "Your mood over the past week has been noticeably lower than the weeks before. It might help to slow down, reach out to someone you trust, or try one of the resources below."
+	default:
+		alert.Message = "Your mood has been low for several days in a row. You don't have to go through this alone. Consider talking to someone you trust or a professional."
+	}
+	alert.Resources = resources
+	alert.Hotlines = IndonesianHotlines
+}
+
+// whatsAppNumber formats a phone number for wa.me links, which need the country code.
+// Local Indonesian numbers (08...) get the +62 prefix.
+func whatsAppNumber(phone string) string {
+	digits := digitsOnly(phone)
+	if strings.HasPrefix(digits, "0") {
+		return "62" + digits[1:]
+	}
+	return digits
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,3 @@
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ; -- NULL kecuali jurnal ada di trash
+
+CREATE INDEX IF NOT EXISTS idx_journals_deleted_at ON journals (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS journal_summaries;
+
+DROP TABLE IF EXISTS journal_reflections;
+
+DROP TABLE IF EXISTS journal_ai_settings;
@@ -0,0 +1,41 @@
+CREATE TABLE
+    IF NOT EXISTS journal_ai_settings (
+        user_id INT PRIMARY KEY,
+        reflections_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+        weekly_summary_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE TABLE
+    IF NOT EXISTS journal_reflections (
+        reflection_id SERIAL PRIMARY KEY,
+        journal_id INT NOT NULL UNIQUE, -- Satu refleksi per jurnal, dibuat ulang jika diminta lagi
+        user_id INT NOT NULL,
+        content TEXT NOT NULL,
+        model VARCHAR(100),
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_journal FOREIGN KEY (journal_id) REFERENCES journals (journal_id) ON DELETE CASCADE,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE TABLE
+    IF NOT EXISTS journal_summaries (
+        summary_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        period_start DATE NOT NULL,
+        period_end DATE NOT NULL,
+        entry_count INT DEFAULT 0 NOT NULL,
+        summary TEXT NOT NULL,
+        themes TEXT[],
+        mood_shifts TEXT,
+        suggested_prompts TEXT[],
+        model VARCHAR(100),
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT uq_journal_summaries_period UNIQUE (user_id, period_start)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_journal_reflections_user_id ON journal_reflections (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_journal_summaries_user_id ON journal_summaries (user_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_journals_prompt_id;
+
+ALTER TABLE journals DROP CONSTRAINT IF EXISTS fk_prompt;
+
+ALTER TABLE journals DROP COLUMN IF EXISTS prompt_id;
+
+DROP TABLE IF EXISTS journal_prompts;
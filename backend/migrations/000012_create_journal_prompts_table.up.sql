@@ -0,0 +1,53 @@
+CREATE TABLE
+    IF NOT EXISTS journal_prompts (
+        prompt_id SERIAL PRIMARY KEY,
+        category VARCHAR(50) NOT NULL, -- 'gratitude', 'cbt', 'anxiety', 'sleep'
+        language VARCHAR(10) NOT NULL, -- 'id', 'en'
+        text TEXT NOT NULL,
+        is_active BOOLEAN DEFAULT TRUE NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_journal_prompts_category_language ON journal_prompts (category, language);
+
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS prompt_id INT; -- Prompt yang dijawab oleh jurnal ini, NULL jika ditulis bebas
+
+ALTER TABLE journals ADD CONSTRAINT fk_prompt FOREIGN KEY (prompt_id) REFERENCES journal_prompts (prompt_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_journals_prompt_id ON journals (prompt_id);
+
+INSERT INTO
+    journal_prompts (category, language, text)
+VALUES
+    ('gratitude', 'en', 'Write about three small things that went well today, and why they mattered to you.'),
+    ('gratitude', 'en', 'Who made your life a little easier recently? What would you like to tell them?'),
+    ('gratitude', 'en', 'Describe a place where you feel safe and calm. What do you appreciate about it?'),
+    ('gratitude', 'en', 'What is something about yourself you are grateful for this week?'),
+    ('gratitude', 'id', 'Tuliskan tiga hal kecil yang berjalan baik hari ini, dan mengapa hal itu berarti bagimu.'),
+    ('gratitude', 'id', 'Siapa yang belakangan ini membuat hidupmu sedikit lebih mudah? Apa yang ingin kamu sampaikan kepadanya?'),
+    ('gratitude', 'id', 'Gambarkan tempat di mana kamu merasa aman dan tenang. Apa yang kamu syukuri dari tempat itu?'),
+    ('gratitude', 'id', 'Apa satu hal tentang dirimu yang kamu syukuri minggu ini?'),
+    ('cbt', 'en', 'Think of a moment today that upset you. What happened, what did you think, and how did you feel?'),
+    ('cbt', 'en', 'Pick one harsh thought you had about yourself. What evidence supports it, and what evidence doesn''t?'),
+    ('cbt', 'en', 'If a close friend were in your situation, what would you say to them?'),
+    ('cbt', 'en', 'What is a more balanced way of looking at something that has been bothering you?'),
+    ('cbt', 'id', 'Ingat satu momen hari ini yang membuatmu kesal. Apa yang terjadi, apa yang kamu pikirkan, dan apa yang kamu rasakan?'),
+    ('cbt', 'id', 'Pilih satu pikiran keras tentang dirimu sendiri. Bukti apa yang mendukungnya, dan bukti apa yang tidak?'),
+    ('cbt', 'id', 'Jika sahabatmu berada di posisimu sekarang, apa yang akan kamu katakan kepadanya?'),
+    ('cbt', 'id', 'Bagaimana cara pandang yang lebih seimbang terhadap hal yang sedang mengganggumu?'),
+    ('anxiety', 'en', 'What is worrying you right now? Write it down, then note which parts are within your control.'),
+    ('anxiety', 'en', 'Describe what you can see, hear and feel around you at this moment.'),
+    ('anxiety', 'en', 'What is the worst that could happen, the best, and the most likely outcome?'),
+    ('anxiety', 'en', 'Recall a time you got through something that scared you. What helped you then?'),
+    ('anxiety', 'id', 'Apa yang sedang kamu khawatirkan saat ini? Tuliskan, lalu tandai bagian mana yang bisa kamu kendalikan.'),
+    ('anxiety', 'id', 'Ceritakan apa yang bisa kamu lihat, dengar, dan rasakan di sekitarmu saat ini.'),
+    ('anxiety', 'id', 'Apa kemungkinan terburuk, terbaik, dan yang paling mungkin terjadi?'),
+    ('anxiety', 'id', 'Ingat saat kamu berhasil melewati sesuatu yang menakutkan. Apa yang membantumu waktu itu?'),
+    ('sleep', 'en', 'Empty your mind onto the page: what is still on your mind from today?'),
+    ('sleep', 'en', 'Write down tomorrow''s three most important tasks so you can let them go for tonight.'),
+    ('sleep', 'en', 'How did you sleep last night, and what might have affected it?'),
+    ('sleep', 'en', 'Describe your ideal calm evening. Which part of it could you do tonight?'),
+    ('sleep', 'id', 'Tuangkan isi pikiranmu ke sini: apa yang masih terpikirkan dari hari ini?'),
+    ('sleep', 'id', 'Tuliskan tiga tugas terpenting untuk besok agar kamu bisa melepaskannya malam ini.'),
+    ('sleep', 'id', 'Bagaimana tidurmu semalam, dan apa yang mungkin memengaruhinya?'),
+    ('sleep', 'id', 'Gambarkan malam tenang yang ideal bagimu. Bagian mana yang bisa kamu lakukan malam ini?');
@@ -0,0 +1 @@
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS version INT DEFAULT 1 NOT NULL; -- Naik setiap kali jurnal diubah, dipakai untuk ETag/If-Match
\ No newline at end of file
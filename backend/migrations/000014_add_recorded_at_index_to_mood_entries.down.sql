@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_mood_entries_user_recorded_at;
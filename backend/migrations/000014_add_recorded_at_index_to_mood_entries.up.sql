@@ -0,0 +1,2 @@
+-- Analytics aggregate a user's entries by date range
+CREATE INDEX IF NOT EXISTS idx_mood_entries_user_recorded_at ON mood_entries (user_id, recorded_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS wellbeing_alerts;
+
+DROP TABLE IF EXISTS trusted_contacts;
@@ -0,0 +1,35 @@
+CREATE TABLE
+    IF NOT EXISTS trusted_contacts (
+        contact_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        name VARCHAR(255) NOT NULL,
+        relationship VARCHAR(100),
+        email VARCHAR(255),
+        phone VARCHAR(50),
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT chk_trusted_contacts_reachable CHECK (email IS NOT NULL OR phone IS NOT NULL)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS wellbeing_alerts (
+        alert_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        alert_type VARCHAR(50) NOT NULL, -- sustained_low, sharp_drop
+        severity VARCHAR(20) NOT NULL, -- moderate, high
+        recent_average REAL NOT NULL,
+        baseline_average REAL, -- NULL jika belum ada data pembanding
+        window_start DATE NOT NULL,
+        window_end DATE NOT NULL,
+        status VARCHAR(20) DEFAULT 'active' NOT NULL, -- active, acknowledged, dismissed
+        shared_contact_id INT, -- Hanya terisi jika user sendiri memilih untuk membagikan
+        shared_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT fk_shared_contact FOREIGN KEY (shared_contact_id) REFERENCES trusted_contacts (contact_id) ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_trusted_contacts_user_id ON trusted_contacts (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_wellbeing_alerts_user_id ON wellbeing_alerts (user_id, created_at);
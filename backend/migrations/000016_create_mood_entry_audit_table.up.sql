@@ -0,0 +1,15 @@
+CREATE TABLE
+    IF NOT EXISTS mood_entry_audit (
+        audit_id SERIAL PRIMARY KEY,
+        entry_id INT NOT NULL, -- Tanpa FK, riwayat entri yang dihapus tetap disimpan
+        user_id INT NOT NULL,
+        action VARCHAR(20) NOT NULL, -- update, delete
+        old_values JSONB NOT NULL,
+        new_values JSONB, -- NULL untuk delete
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_mood_entry_audit_entry_id ON mood_entry_audit (entry_id);
+
+CREATE INDEX IF NOT EXISTS idx_mood_entry_audit_user_id ON mood_entry_audit (user_id, created_at);
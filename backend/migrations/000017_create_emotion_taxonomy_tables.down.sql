@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS mood_entry_types;
+
+DROP TABLE IF EXISTS emotions;
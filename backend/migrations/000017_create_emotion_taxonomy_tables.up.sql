@@ -0,0 +1,46 @@
+CREATE TABLE
+    IF NOT EXISTS emotions (
+        emotion_id SERIAL PRIMARY KEY,
+        label VARCHAR(50) UNIQUE NOT NULL, -- Disimpan di mood_entries.primary_emotion
+        valence REAL NOT NULL, -- 0 (sangat negatif) sampai 1 (sangat positif), dipakai sebagai intensity_level
+        arousal REAL NOT NULL, -- 0 (tenang) sampai 1 (sangat aktif)
+        name_id VARCHAR(100) NOT NULL,
+        name_en VARCHAR(100) NOT NULL,
+        emoji VARCHAR(16),
+        model_labels TEXT[] DEFAULT '{}' NOT NULL, -- Label dari model mood yang dipetakan ke emosi ini
+        is_active BOOLEAN DEFAULT TRUE NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT chk_emotions_valence CHECK (valence >= 0 AND valence <= 1),
+        CONSTRAINT chk_emotions_arousal CHECK (arousal >= 0 AND arousal <= 1)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS mood_entry_types (
+        entry_type VARCHAR(50) PRIMARY KEY,
+        name_id VARCHAR(100) NOT NULL,
+        name_en VARCHAR(100) NOT NULL,
+        is_active BOOLEAN DEFAULT TRUE NOT NULL
+    );
+
+-- Nilai valence sama dengan MoodToIntensityMap sebelumnya
+INSERT INTO
+    emotions (label, valence, arousal, name_id, name_en, emoji, model_labels)
+VALUES
+    ('joy', 1.0, 0.7, 'Senang', 'Joy', '😊', '{joy,happy,happiness}'),
+    ('love', 0.9, 0.5, 'Cinta', 'Love', '🥰', '{love}'),
+    ('surprise', 0.7, 0.8, 'Terkejut', 'Surprise', '😮', '{surprise}'),
+    ('neutral', 0.5, 0.3, 'Netral', 'Neutral', '😐', '{neutral}'),
+    ('fear', 0.3, 0.8, 'Takut', 'Fear', '😨', '{fear,anxiety,scared}'),
+    ('sadness', 0.2, 0.3, 'Sedih', 'Sadness', '😢', '{sadness,sad}'),
+    ('anger', 0.1, 0.9, 'Marah', 'Anger', '😠', '{anger,angry}')
+ON CONFLICT (label) DO NOTHING;
+
+INSERT INTO
+    mood_entry_types (entry_type, name_id, name_en)
+VALUES
+    ('daily', 'Harian', 'Daily'),
+    ('event', 'Kejadian', 'Event'),
+    ('reflection', 'Refleksi', 'Reflection'),
+    ('journal', 'Jurnal', 'Journal')
+ON CONFLICT (entry_type) DO NOTHING;
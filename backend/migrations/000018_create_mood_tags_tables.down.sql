@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS mood_entry_tags;
+
+DROP TABLE IF EXISTS mood_tags;
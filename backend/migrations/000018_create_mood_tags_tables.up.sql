@@ -0,0 +1,49 @@
+CREATE TABLE
+    IF NOT EXISTS mood_tags (
+        tag_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        kind VARCHAR(20) NOT NULL, -- trigger, coping
+        name VARCHAR(100) NOT NULL, -- Huruf kecil, dipakai untuk mencocokkan tag yang sama
+        display_name VARCHAR(100) NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT uq_mood_tags_name UNIQUE (user_id, kind, name)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS mood_entry_tags (
+        entry_id INT NOT NULL,
+        tag_id INT NOT NULL,
+        PRIMARY KEY (entry_id, tag_id),
+        CONSTRAINT fk_entry FOREIGN KEY (entry_id) REFERENCES mood_entries (entry_id) ON DELETE CASCADE,
+        CONSTRAINT fk_tag FOREIGN KEY (tag_id) REFERENCES mood_tags (tag_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_mood_entry_tags_tag_id ON mood_entry_tags (tag_id);
+
+-- Isi tag dari teks bebas yang sudah ada, dipisah dengan koma, titik koma atau baris baru
+INSERT INTO
+    mood_tags (user_id, kind, name, display_name)
+SELECT user_id, kind, lower(tag), tag
+FROM (
+        SELECT user_id, 'trigger' AS kind, left(regexp_replace(btrim(part), '\s+', ' ', 'g'), 100) AS tag
+        FROM mood_entries, regexp_split_to_table(trigger_factor, '[,;\n]') AS part
+        UNION ALL
+        SELECT user_id, 'coping' AS kind, left(regexp_replace(btrim(part), '\s+', ' ', 'g'), 100) AS tag
+        FROM mood_entries, regexp_split_to_table(coping_strategy, '[,;\n]') AS part
+    ) parts
+WHERE tag <> ''
+ON CONFLICT (user_id, kind, name) DO NOTHING;
+
+INSERT INTO
+    mood_entry_tags (entry_id, tag_id)
+SELECT DISTINCT parts.entry_id, t.tag_id
+FROM (
+        SELECT entry_id, user_id, 'trigger' AS kind, lower(left(regexp_replace(btrim(part), '\s+', ' ', 'g'), 100)) AS name
+        FROM mood_entries, regexp_split_to_table(trigger_factor, '[,;\n]') AS part
+        UNION ALL
+        SELECT entry_id, user_id, 'coping' AS kind, lower(left(regexp_replace(btrim(part), '\s+', ' ', 'g'), 100)) AS name
+        FROM mood_entries, regexp_split_to_table(coping_strategy, '[,;\n]') AS part
+    ) parts
+    JOIN mood_tags t ON t.user_id = parts.user_id AND t.kind = parts.kind AND t.name = parts.name
+ON CONFLICT DO NOTHING;
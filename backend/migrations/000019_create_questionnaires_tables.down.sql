@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS questionnaire_responses;
+
+DROP TABLE IF EXISTS questionnaires;
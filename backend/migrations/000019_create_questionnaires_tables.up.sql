@@ -0,0 +1,62 @@
+CREATE TABLE
+    IF NOT EXISTS questionnaires (
+        questionnaire_id SERIAL PRIMARY KEY,
+        code VARCHAR(20) NOT NULL, -- phq9, gad7, pss
+        version INT NOT NULL,
+        title_id VARCHAR(255) NOT NULL,
+        title_en VARCHAR(255) NOT NULL,
+        instructions_id TEXT NOT NULL,
+        instructions_en TEXT NOT NULL,
+        definition JSONB NOT NULL, -- Item, pilihan jawaban, aturan skor, severity band dan aturan keamanan
+        is_active BOOLEAN DEFAULT TRUE NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT uq_questionnaires_version UNIQUE (code, version)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS questionnaire_responses (
+        response_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        questionnaire_id INT NOT NULL, -- Versi yang diisi, skor lama tetap bisa dibaca setelah ada versi baru
+        answers INT[] NOT NULL,
+        total_score INT NOT NULL,
+        severity VARCHAR(50) NOT NULL,
+        safety_flagged BOOLEAN DEFAULT FALSE NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT fk_questionnaire FOREIGN KEY (questionnaire_id) REFERENCES questionnaires (questionnaire_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_questionnaire_responses_user_id ON questionnaire_responses (user_id, created_at);
+
+INSERT INTO
+    questionnaires (code, version, title_id, title_en, instructions_id, instructions_en, definition)
+VALUES
+    (
+        'phq9',
+        1,
+        'PHQ-9: Kuesioner Kesehatan Pasien',
+        'PHQ-9: Patient Health Questionnaire',
+        'Selama 2 minggu terakhir, seberapa sering Anda terganggu oleh masalah-masalah berikut?',
+        'Over the last 2 weeks, how often have you been bothered by any of the following problems?',
+        '{"items": [{"number": 1, "text_id": "Kurang tertarik atau bergairah dalam melakukan apa pun", "text_en": "Little interest or pleasure in doing things"}, {"number": 2, "text_id": "Merasa murung, sedih, atau putus asa", "text_en": "Feeling down, depressed, or hopeless"}, {"number": 3, "text_id": "Sulit tidur atau mudah terbangun, atau terlalu banyak tidur", "text_en": "Trouble falling or staying asleep, or sleeping too much"}, {"number": 4, "text_id": "Merasa lelah atau kurang bertenaga", "text_en": "Feeling tired or having little energy"}, {"number": 5, "text_id": "Kurang nafsu makan atau terlalu banyak makan", "text_en": "Poor appetite or overeating"}, {"number": 6, "text_id": "Merasa buruk tentang diri sendiri, merasa gagal, atau telah mengecewakan diri sendiri atau keluarga", "text_en": "Feeling bad about yourself, or that you are a failure or have let yourself or your family down"}, {"number": 7, "text_id": "Sulit berkonsentrasi, misalnya saat membaca atau menonton televisi", "text_en": "Trouble concentrating on things, such as reading the newspaper or watching television"}, {"number": 8, "text_id": "Bergerak atau berbicara sangat lambat sehingga orang lain memperhatikannya, atau sebaliknya, sangat gelisah sehingga lebih sering bergerak dari biasanya", "text_en": "Moving or speaking so slowly that other people could have noticed, or the opposite, being so fidgety or restless that you have been moving around a lot more than usual"}, {"number": 9, "text_id": "Pikiran bahwa lebih baik mati atau ingin melukai diri sendiri dengan cara apa pun", "text_en": "Thoughts that you would be better off dead, or of hurting yourself in some way"}], "options": [{"value": 0, "label_id": "Tidak sama sekali", "label_en": "Not at all"}, {"value": 1, "label_id": "Beberapa hari", "label_en": "Several days"}, {"value": 2, "label_id": "Lebih dari separuh waktu", "label_en": "More than half the days"}, {"value": 3, "label_id": "Hampir setiap hari", "label_en": "Nearly every day"}], "reverse_scored": [], "severity_bands": [{"min": 0, "max": 4, "severity": "minimal", "label_id": "Minimal", "label_en": "Minimal"}, {"min": 5, "max": 9, "severity": "mild", "label_id": "Ringan", "label_en": "Mild"}, {"min": 10, "max": 14, "severity": "moderate", "label_id": "Sedang", "label_en": "Moderate"}, {"min": 15, "max": 19, "severity": "moderately_severe", "label_id": "Cukup berat", "label_en": "Moderately severe"}, {"min": 20, "max": 27, "severity": "severe", "label_id": "Berat", "label_en": "Severe"}], "safety_rules": [{"item": 9, "min_value": 1}]}'
+    ),
+    (
+        'gad7',
+        1,
+        'GAD-7: Skala Kecemasan Umum',
+        'GAD-7: Generalized Anxiety Disorder Scale',
+        'Selama 2 minggu terakhir, seberapa sering Anda terganggu oleh masalah-masalah berikut?',
+        'Over the last 2 weeks, how often have you been bothered by the following problems?',
+        '{"items": [{"number": 1, "text_id": "Merasa gugup, cemas, atau tegang", "text_en": "Feeling nervous, anxious, or on edge"}, {"number": 2, "text_id": "Tidak mampu menghentikan atau mengendalikan rasa khawatir", "text_en": "Not being able to stop or control worrying"}, {"number": 3, "text_id": "Terlalu mengkhawatirkan berbagai hal", "text_en": "Worrying too much about different things"}, {"number": 4, "text_id": "Sulit untuk bersantai", "text_en": "Trouble relaxing"}, {"number": 5, "text_id": "Sangat gelisah sehingga sulit untuk duduk diam", "text_en": "Being so restless that it is hard to sit still"}, {"number": 6, "text_id": "Menjadi mudah kesal atau mudah tersinggung", "text_en": "Becoming easily annoyed or irritable"}, {"number": 7, "text_id": "Merasa takut seolah-olah sesuatu yang buruk akan terjadi", "text_en": "Feeling afraid, as if something awful might happen"}], "options": [{"value": 0, "label_id": "Tidak sama sekali", "label_en": "Not at all"}, {"value": 1, "label_id": "Beberapa hari", "label_en": "Several days"}, {"value": 2, "label_id": "Lebih dari separuh waktu", "label_en": "More than half the days"}, {"value": 3, "label_id": "Hampir setiap hari", "label_en": "Nearly every day"}], "reverse_scored": [], "severity_bands": [{"min": 0, "max": 4, "severity": "minimal", "label_id": "Minimal", "label_en": "Minimal"}, {"min": 5, "max": 9, "severity": "mild", "label_id": "Ringan", "label_en": "Mild"}, {"min": 10, "max": 14, "severity": "moderate", "label_id": "Sedang", "label_en": "Moderate"}, {"min": 15, "max": 21, "severity": "severe", "label_id": "Berat", "label_en": "Severe"}], "safety_rules": []}'
+    ),
+    (
+        'pss',
+        1,
+        'PSS-10: Skala Stres yang Dirasakan',
+        'PSS-10: Perceived Stress Scale',
+        'Dalam sebulan terakhir, seberapa sering Anda...',
+        'In the last month, how often have you...',
+        '{"items": [{"number": 1, "text_id": "merasa kesal karena sesuatu yang terjadi secara tidak terduga?", "text_en": "been upset because of something that happened unexpectedly?"}, {"number": 2, "text_id": "merasa tidak mampu mengendalikan hal-hal penting dalam hidup Anda?", "text_en": "felt that you were unable to control the important things in your life?"}, {"number": 3, "text_id": "merasa gugup dan tertekan?", "text_en": "felt nervous and stressed?"}, {"number": 4, "text_id": "merasa yakin dengan kemampuan Anda untuk menangani masalah pribadi?", "text_en": "felt confident about your ability to handle your personal problems?"}, {"number": 5, "text_id": "merasa segala sesuatu berjalan sesuai keinginan Anda?", "text_en": "felt that things were going your way?"}, {"number": 6, "text_id": "merasa tidak mampu menyelesaikan semua hal yang harus Anda kerjakan?", "text_en": "found that you could not cope with all the things that you had to do?"}, {"number": 7, "text_id": "mampu mengendalikan rasa kesal dalam hidup Anda?", "text_en": "been able to control irritations in your life?"}, {"number": 8, "text_id": "merasa bahwa Anda menguasai keadaan?", "text_en": "felt that you were on top of things?"}, {"number": 9, "text_id": "marah karena hal-hal yang berada di luar kendali Anda?", "text_en": "been angered because of things that were outside of your control?"}, {"number": 10, "text_id": "merasa kesulitan menumpuk begitu tinggi sehingga Anda tidak dapat mengatasinya?", "text_en": "felt difficulties were piling up so high that you could not overcome them?"}], "options": [{"value": 0, "label_id": "Tidak pernah", "label_en": "Never"}, {"value": 1, "label_id": "Hampir tidak pernah", "label_en": "Almost never"}, {"value": 2, "label_id": "Kadang-kadang", "label_en": "Sometimes"}, {"value": 3, "label_id": "Cukup sering", "label_en": "Fairly often"}, {"value": 4, "label_id": "Sangat sering", "label_en": "Very often"}], "reverse_scored": [4, 5, 7, 8], "severity_bands": [{"min": 0, "max": 13, "severity": "low", "label_id": "Stres rendah", "label_en": "Low stress"}, {"min": 14, "max": 26, "severity": "moderate", "label_id": "Stres sedang", "label_en": "Moderate stress"}, {"min": 27, "max": 40, "severity": "high", "label_id": "Stres tinggi", "label_en": "High stress"}], "safety_rules": []}'
+    )
+ON CONFLICT (code, version) DO NOTHING;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS push_subscriptions;
+
+DROP TABLE IF EXISTS notifications;
+
+DROP TABLE IF EXISTS reminder_schedules;
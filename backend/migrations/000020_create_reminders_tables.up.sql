@@ -0,0 +1,45 @@
+CREATE TABLE
+    IF NOT EXISTS reminder_schedules (
+        user_id INT PRIMARY KEY,
+        enabled BOOLEAN DEFAULT TRUE NOT NULL,
+        timezone VARCHAR(64) DEFAULT 'Asia/Jakarta' NOT NULL,
+        reminder_time TIME DEFAULT '20:00' NOT NULL, -- Waktu lokal user
+        quiet_hours_start TIME, -- NULL jika tidak ada jam tenang
+        quiet_hours_end TIME,
+        channels TEXT[] DEFAULT ARRAY['in_app'] NOT NULL, -- in_app, email, push
+        last_sent_on DATE, -- Tanggal lokal pengingat terakhir dikirim
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT chk_reminder_quiet_hours CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+    );
+
+CREATE TABLE
+    IF NOT EXISTS notifications (
+        notification_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        kind VARCHAR(50) NOT NULL, -- mood_reminder
+        title VARCHAR(255) NOT NULL,
+        body TEXT NOT NULL,
+        link VARCHAR(255),
+        read_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE TABLE
+    IF NOT EXISTS push_subscriptions (
+        subscription_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        endpoint TEXT NOT NULL UNIQUE,
+        p256dh VARCHAR(255) NOT NULL,
+        auth VARCHAR(255) NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_reminder_schedules_enabled ON reminder_schedules (enabled) WHERE enabled = TRUE;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id ON push_subscriptions (user_id);
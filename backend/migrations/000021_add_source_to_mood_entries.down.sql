@@ -0,0 +1,12 @@
+UPDATE emotions
+SET
+    model_labels = array_remove(array_remove(array_remove(model_labels, 'fearful'), 'surprised'), 'disgusted');
+
+DROP TABLE IF EXISTS facial_emotion_readings;
+
+DROP INDEX IF EXISTS idx_mood_entries_facial_session;
+
+ALTER TABLE mood_entries
+DROP CONSTRAINT IF EXISTS chk_mood_entries_source,
+DROP COLUMN IF EXISTS facial_session_id,
+DROP COLUMN IF EXISTS source;
@@ -0,0 +1,53 @@
+ALTER TABLE mood_entries
+ADD COLUMN IF NOT EXISTS source VARCHAR(20) DEFAULT 'manual' NOT NULL, -- journal, manual, facial
+ADD COLUMN IF NOT EXISTS facial_session_id VARCHAR(64); -- Hanya terisi untuk source facial
+
+ALTER TABLE mood_entries
+ADD CONSTRAINT chk_mood_entries_source CHECK (source IN ('journal', 'manual', 'facial'));
+
+UPDATE mood_entries
+SET
+    source = 'journal'
+WHERE
+    journal_id IS NOT NULL
+    OR entry_type = 'journal';
+
+-- Satu mood entry per sesi deteksi wajah
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mood_entries_facial_session ON mood_entries (user_id, facial_session_id)
+WHERE
+    facial_session_id IS NOT NULL;
+
+-- Hanya skor emosi yang disimpan, tidak pernah gambar
+CREATE TABLE
+    IF NOT EXISTS facial_emotion_readings (
+        reading_id BIGSERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        session_id VARCHAR(64) NOT NULL,
+        captured_at TIMESTAMPTZ NOT NULL,
+        scores JSONB NOT NULL, -- {"happy": 0.82, "neutral": 0.12, ...}
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT uq_facial_reading UNIQUE (user_id, session_id, captured_at)
+    );
+
+-- Label ekspresi dari face-api.js di browser
+UPDATE emotions
+SET
+    model_labels = array_append(model_labels, 'fearful')
+WHERE
+    label = 'fear'
+    AND NOT ('fearful' = ANY (model_labels));
+
+UPDATE emotions
+SET
+    model_labels = array_append(model_labels, 'surprised')
+WHERE
+    label = 'surprise'
+    AND NOT ('surprised' = ANY (model_labels));
+
+UPDATE emotions
+SET
+    model_labels = array_append(model_labels, 'disgusted')
+WHERE
+    label = 'anger'
+    AND NOT ('disgusted' = ANY (model_labels));
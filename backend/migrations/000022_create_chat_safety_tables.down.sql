@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS chat_safety_events;
+
+DROP TABLE IF EXISTS chat_safety_settings;
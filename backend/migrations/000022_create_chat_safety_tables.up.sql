@@ -0,0 +1,32 @@
+CREATE TABLE
+    IF NOT EXISTS chat_safety_settings (
+        user_id INT PRIMARY KEY,
+        emergency_contact_id INT, -- Kontak dari trusted_contacts
+        notify_contact BOOLEAN DEFAULT FALSE NOT NULL, -- Persetujuan user untuk menghubungi kontak darurat
+        consented_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT fk_emergency_contact FOREIGN KEY (emergency_contact_id) REFERENCES trusted_contacts (contact_id) ON DELETE SET NULL
+    );
+
+CREATE TABLE
+    IF NOT EXISTS chat_safety_events (
+        event_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        session_id INT, -- NULL untuk chat tanpa sesi
+        risk_level VARCHAR(20) NOT NULL, -- medium, high
+        matched_rules TEXT[] DEFAULT ARRAY[]::TEXT[] NOT NULL, -- ID aturan, isi pesan tidak disimpan
+        model_risk_level VARCHAR(20), -- NULL jika model tidak dipakai atau gagal
+        language VARCHAR(5) NOT NULL, -- id, en
+        notified_contact_id INT,
+        notified_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT fk_session FOREIGN KEY (session_id) REFERENCES chat_sessions (session_id) ON DELETE SET NULL,
+        CONSTRAINT fk_notified_contact FOREIGN KEY (notified_contact_id) REFERENCES trusted_contacts (contact_id) ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_chat_safety_events_user_id ON chat_safety_events (user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_chat_safety_events_risk_level ON chat_safety_events (risk_level, created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE
+    IF NOT EXISTS chat_session_summaries (
+        session_id INT PRIMARY KEY,
+        summary TEXT NOT NULL,
+        summarized_until_id INT NOT NULL, -- message_id terakhir yang sudah masuk ringkasan
+        message_count INT NOT NULL, -- Jumlah pesan yang sudah diringkas
+        model VARCHAR(100) NOT NULL,
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_session FOREIGN KEY (session_id) REFERENCES chat_sessions (session_id) ON DELETE CASCADE
+    );
@@ -0,0 +1,15 @@
+CREATE TABLE
+    IF NOT EXISTS llm_usage_daily (
+        user_id INT NOT NULL,
+        usage_date DATE NOT NULL, -- Tanggal dalam WIB, sama dengan pergantian kuota
+        feature VARCHAR(50) NOT NULL, -- chat, chat_summary, safety_check, journal_reflection, journal_summary
+        model VARCHAR(100) NOT NULL,
+        requests INT DEFAULT 0 NOT NULL,
+        prompt_tokens BIGINT DEFAULT 0 NOT NULL,
+        completion_tokens BIGINT DEFAULT 0 NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        PRIMARY KEY (user_id, usage_date, feature, model),
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_daily_usage_date ON llm_usage_daily (usage_date);
@@ -0,0 +1,11 @@
+ALTER TABLE chat_messages DROP COLUMN IF EXISTS read_at;
+
+ALTER TABLE chat_messages DROP COLUMN IF EXISTS delivered_at;
+
+DROP INDEX IF EXISTS idx_chat_sessions_counselor_id;
+
+ALTER TABLE chat_sessions DROP CONSTRAINT IF EXISTS fk_counselor;
+
+ALTER TABLE chat_sessions DROP COLUMN IF EXISTS counselor_id;
+
+DROP TABLE IF EXISTS counselor_assignments;
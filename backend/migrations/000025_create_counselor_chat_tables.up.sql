@@ -0,0 +1,21 @@
+-- Konselor adalah user dengan user_type 'counselor'
+CREATE TABLE
+    IF NOT EXISTS counselor_assignments (
+        user_id INT PRIMARY KEY, -- Satu konselor per user
+        counselor_id INT NOT NULL,
+        assigned_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT fk_counselor FOREIGN KEY (counselor_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_counselor_assignments_counselor_id ON counselor_assignments (counselor_id);
+
+ALTER TABLE chat_sessions ADD COLUMN IF NOT EXISTS counselor_id INT; -- NULL untuk sesi dengan bot
+
+ALTER TABLE chat_sessions ADD CONSTRAINT fk_counselor FOREIGN KEY (counselor_id) REFERENCES users (user_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_chat_sessions_counselor_id ON chat_sessions (counselor_id, start_time) WHERE counselor_id IS NOT NULL;
+
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ; -- Diterima oleh perangkat lawan bicara
+
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS read_at TIMESTAMPTZ; -- Dibaca oleh lawan bicara
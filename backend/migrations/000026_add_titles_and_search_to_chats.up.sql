@@ -0,0 +1,15 @@
+ALTER TABLE chat_sessions ADD COLUMN IF NOT EXISTS title VARCHAR(120); -- NULL sampai judul dibuat atau diisi user
+
+ALTER TABLE chat_sessions ADD COLUMN IF NOT EXISTS title_source VARCHAR(20); -- 'model', 'heuristic', 'user'
+
+ALTER TABLE chat_sessions ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMPTZ;
+
+ALTER TABLE chat_sessions ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+-- Daftar sesi per user, terbaru lebih dulu
+CREATE INDEX IF NOT EXISTS idx_chat_sessions_user_start_time ON chat_sessions (user_id, start_time DESC);
+
+-- Konfigurasi 'simple' karena pesan bercampur bahasa Indonesia dan Inggris
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', message_content)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_search_vector ON chat_messages USING GIN (search_vector);
@@ -0,0 +1,22 @@
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS model VARCHAR(100); -- Hanya untuk pesan bot
+
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50); -- Versi system prompt saat jawaban dibuat
+
+-- Satu penilaian per pesan bot, bisa diubah oleh user
+CREATE TABLE
+    IF NOT EXISTS chat_message_feedback (
+        message_id INT PRIMARY KEY,
+        user_id INT NOT NULL,
+        rating VARCHAR(10) NOT NULL CHECK (rating IN ('up', 'down')),
+        reasons TEXT[] NOT NULL DEFAULT '{}',
+        comment TEXT,
+        share_for_review BOOLEAN NOT NULL DEFAULT FALSE, -- Izin user agar percakapan ikut diekspor untuk review prompt
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_message FOREIGN KEY (message_id) REFERENCES chat_messages (message_id) ON DELETE CASCADE,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_feedback_updated_at ON chat_message_feedback (updated_at);
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_feedback_shared ON chat_message_feedback (updated_at) WHERE rating = 'down' AND share_for_review;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS chat_context_log;
+
+DROP TABLE IF EXISTS chat_personalization_settings;
@@ -0,0 +1,26 @@
+-- Izin eksplisit user agar data mood (dan opsional ringkasan jurnal) dipakai di chat
+CREATE TABLE
+    IF NOT EXISTS chat_personalization_settings (
+        user_id INT PRIMARY KEY,
+        enabled BOOLEAN NOT NULL DEFAULT FALSE,
+        include_journals BOOLEAN NOT NULL DEFAULT FALSE,
+        consented_at TIMESTAMPTZ, -- NULL saat izin dicabut
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    );
+
+-- Salinan persis konteks yang dikirim ke model, bisa dilihat dan dihapus user
+CREATE TABLE
+    IF NOT EXISTS chat_context_log (
+        log_id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL,
+        session_id INT,
+        context TEXT NOT NULL,
+        sources TEXT[] NOT NULL DEFAULT '{}', -- mood_trend, triggers, coping, journal_summary
+        created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+        CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+        CONSTRAINT fk_session FOREIGN KEY (session_id) REFERENCES chat_sessions (session_id) ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_chat_context_log_user_id ON chat_context_log (user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_resources_search_vector;
+
+ALTER TABLE resources DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,6 @@
+-- Indeks full-text untuk mencari resource yang relevan dengan pesan chat. Judul diberi bobot lebih tinggi dari isi.
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') || setweight(to_tsvector('simple', COALESCE(content, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_resources_search_vector ON resources USING GIN (search_vector);
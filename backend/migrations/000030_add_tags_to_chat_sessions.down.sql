@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_chat_sessions_tags;
+
+ALTER TABLE chat_sessions DROP COLUMN IF EXISTS tags;
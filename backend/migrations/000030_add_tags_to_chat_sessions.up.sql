@@ -0,0 +1,4 @@
+-- Label dari user, huruf kecil dan unik per sesi
+ALTER TABLE chat_sessions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_chat_sessions_tags ON chat_sessions USING GIN (tags);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_journals_mood_analysis_queued_at;
+
+ALTER TABLE journals DROP COLUMN IF EXISTS mood_analysis_attempts;
+
+ALTER TABLE journals DROP COLUMN IF EXISTS mood_analysis_queued_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS mood_analysis_queued_at TIMESTAMPTZ; -- NULL kecuali jurnal impor masih menunggu analisis mood
+
+ALTER TABLE journals ADD COLUMN IF NOT EXISTS mood_analysis_attempts INT DEFAULT 0 NOT NULL; -- Berapa kali analisis mood jurnal ini gagal
+
+CREATE INDEX IF NOT EXISTS idx_journals_mood_analysis_queued_at ON journals (mood_analysis_queued_at) WHERE mood_analysis_queued_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Idle connections are pinged this often so a dead connection is noticed
+const listenerPingInterval = 90 * time.Second
+
+// Listen passes the payload of every NOTIFY on channel to handle until ctx is done. It
+// uses its own connection and reconnects when it is lost. Notifications sent while the
+// connection was down are gone, resync is called after reconnecting so the caller can
+// catch up another way.
+func Listen(ctx context.Context, connStr, channel string, handle func(payload string), resync func()) error {
+	listener := pq.NewListener(connStr, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Listener %s: %v", channel, err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+	log.Printf("Listening for notifications on %s", channel)
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			if notification == nil {
+				log.Printf("Listener %s reconnected", channel)
+				resync()
+				continue
+			}
+			handle(notification.Extra)
+		case <-ticker.C:
+			go func() {
+				if err := listener.Ping(); err != nil {
+					log.Printf("Listener %s ping failed: %v", channel, err)
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// Exclusive wraps job so only one API replica runs it at a time. It takes a Postgres
+// session advisory lock keyed on the job name, and skips the run when another replica
+// holds it.
+func Exclusive(db *sql.DB, name string, job Job) Job {
+	return func(ctx context.Context) error {
+		// Advisory locks belong to a session, so lock and unlock on the same connection
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&acquired); err != nil {
+			return err
+		}
+		if !acquired {
+			log.Printf("Scheduler: job %s is running on another instance, skipping", name)
+			return nil
+		}
+		defer func() {
+			// Unlock even when ctx was cancelled during the job
+			if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, name); err != nil {
+				log.Printf("Scheduler: failed to release lock for job %s: %v", name, err)
+			}
+		}()
+
+		return job(ctx)
+	}
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a unit of scheduled background work
+type Job func(ctx context.Context) error
+
+// Every runs job once immediately and then on every interval until ctx is cancelled.
+// Errors are logged and don't stop the schedule.
+func Every(ctx context.Context, name string, interval time.Duration, job Job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run(ctx, name, job)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Scheduler: stopping job %s", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func run(ctx context.Context, name string, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduler: job %s panicked: %v", name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := job(ctx); err != nil {
+		log.Printf("Scheduler: job %s failed: %v", name, err)
+		return
+	}
+	log.Printf("Scheduler: job %s finished in %s", name, time.Since(start).Round(time.Millisecond))
+}
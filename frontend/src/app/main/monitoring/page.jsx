@@ -1,6 +1,6 @@
 "use client";
 
-import { useState } from "react";
+import { useState, useEffect } from "react";
 import Image from "next/image";
 import Link from "next/link";
 import { format, subDays, startOfWeek, endOfWeek } from "date-fns";
@@ -85,9 +85,47 @@ import * as z from "zod";
 import { Input } from "@/components/ui/input";
 import { Checkbox } from "@/components/ui/checkbox";
 import { Footer } from "@/components/footer";
+import { WellbeingAlertBanner } from "@/components/wellbeing-alert-banner";
+
+const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL;
+
+const EMOTION_COLORS = {
+  joy: "#4ade80",
+  love: "#f472b6",
+  surprise: "#facc15",
+  fear: "#a78bfa",
+  sadness: "#60a5fa",
+  anger: "#f87171",
+};
+
+// Jumlah hari yang dicakup setiap pilihan time range
+const RANGE_DAYS = { day: 1, week: 7, month: 30, year: 365 };
 
 export default function MonitoringPage() {
   const [timeRange, setTimeRange] = useState("week");
+  const [moodAnalytics, setMoodAnalytics] = useState(null);
+
+  // Ringkasan mood dihitung di server, halaman ini hanya mengambil hasil agregasinya
+  useEffect(() => {
+    const token =
+      typeof window !== "undefined" ? localStorage.getItem("jwt_token") : null;
+    if (!token) return;
+
+    const end = new Date();
+    const start = subDays(end, (RANGE_DAYS[timeRange] || 7) - 1);
+    const params = new URLSearchParams({
+      start_date: format(start, "yyyy-MM-dd"),
+      end_date: format(end, "yyyy-MM-dd"),
+      timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+    });
+
+    fetch(`${API_BASE_URL}/mood/analytics?${params}`, {
+      headers: { Authorization: `Bearer ${token}` },
+    })
+      .then((response) => (response.ok ? response.json() : null))
+      .then((data) => setMoodAnalytics(data))
+      .catch(() => setMoodAnalytics(null));
+  }, [timeRange]);
 
   // Track which form is currently open
   const [activeForm, setActiveForm] = useState(null);
@@ -226,16 +264,17 @@ export default function MonitoringPage() {
   ).reverse();
   const pastWeekFormatted = pastWeek.map((date) => format(date, "MMM dd"));
 
-  // Sample data for charts
-  const moodData = [
-    { name: pastWeekFormatted[0], happy: 2, neutral: 3, sad: 1 },
-    { name: pastWeekFormatted[1], happy: 4, neutral: 2, sad: 0 },
-    { name: pastWeekFormatted[2], happy: 3, neutral: 3, sad: 2 },
-    { name: pastWeekFormatted[3], happy: 1, neutral: 4, sad: 3 },
-    { name: pastWeekFormatted[4], happy: 5, neutral: 1, sad: 0 },
-    { name: pastWeekFormatted[5], happy: 4, neutral: 2, sad: 1 },
-    { name: pastWeekFormatted[6], happy: 3, neutral: 3, sad: 0 },
-  ];
+  // Rata-rata intensitas mood (0-10) dari endpoint analytics
+  const moodBuckets =
+    timeRange === "year" ? moodAnalytics?.monthly : moodAnalytics?.daily;
+  const moodData = (moodBuckets || []).map((bucket) => ({
+    name: format(
+      new Date(`${bucket.period}T00:00:00`),
+      timeRange === "year" ? "MMM yyyy" : "MMM dd"
+    ),
+    mood: Number((bucket.average_intensity * 10).toFixed(1)),
+    entries: bucket.entry_count,
+  }));
 
   const sleepData = [
     { name: pastWeekFormatted[0], hours: 7.5 },
@@ -267,11 +306,13 @@ export default function MonitoringPage() {
 
   const COLORS = ["#0088FE", "#00C49F", "#FFBB28", "#FF8042", "#8884d8"];
 
-  const moodDistribution = [
-    { name: "Happy", value: 42, color: "#4ade80" },
-    { name: "Neutral", value: 35, color: "#60a5fa" },
-    { name: "Sad", value: 23, color: "#f87171" },
-  ];
+  const moodDistribution = (moodAnalytics?.emotion_distribution || []).map(
+    (item, index) => ({
+      name: item.emotion.charAt(0).toUpperCase() + item.emotion.slice(1),
+      value: item.count,
+      color: EMOTION_COLORS[item.emotion] || COLORS[index % COLORS.length],
+    })
+  );
 
   const journalEntries = [
     {
@@ -365,6 +406,8 @@ export default function MonitoringPage() {
           </div>
 
           <div className="container mx-auto px-6 py-8">
+            <WellbeingAlertBanner />
+
             {/* Dashboard Header */}
             <div className="mb-8 flex flex-col md:flex-row md:items-center md:justify-between gap-4">
               <div>
@@ -1261,28 +1304,17 @@ export default function MonitoringPage() {
                       >
                         <CartesianGrid strokeDasharray="3 3" />
                         <XAxis dataKey="name" />
-                        <YAxis />
+                        <YAxis domain={[0, 10]} />
                         <Tooltip />
                         <Legend />
                         <Line
                           type="monotone"
-                          dataKey="happy"
+                          dataKey="mood"
+                          name="Average mood"
                           stroke="#4ade80"
                           activeDot={{ r: 8 }}
                           strokeWidth={2}
                         />
-                        <Line
-                          type="monotone"
-                          dataKey="neutral"
-                          stroke="#60a5fa"
-                          strokeWidth={2}
-                        />
-                        <Line
-                          type="monotone"
-                          dataKey="sad"
-                          stroke="#f87171"
-                          strokeWidth={2}
-                        />
                       </LineChart>
                     </ResponsiveContainer>
                   </CardContent>
@@ -1430,23 +1462,12 @@ export default function MonitoringPage() {
                           <Legend />
                           <Line
                             type="monotone"
-                            dataKey="happy"
+                            dataKey="mood"
+                            name="Average mood"
                             stroke="#4ade80"
                             activeDot={{ r: 8 }}
                             strokeWidth={2}
                           />
-                          <Line
-                            type="monotone"
-                            dataKey="neutral"
-                            stroke="#60a5fa"
-                            strokeWidth={2}
-                          />
-                          <Line
-                            type="monotone"
-                            dataKey="sad"
-                            stroke="#f87171"
-                            strokeWidth={2}
-                          />
                         </LineChart>
                       </ResponsiveContainer>
                     </CardContent>
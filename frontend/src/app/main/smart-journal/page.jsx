@@ -36,6 +36,30 @@ import {
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL;
 
+// Ubah journal dari backend ke bentuk entry yang dipakai halaman ini
+const toJournalEntry = (entry) => {
+  const contentParts = entry.content.split("\n\n");
+  let title = `Entry ${entry.journal_id}`;
+  let mainContent = entry.content;
+  if (contentParts.length > 1 && contentParts[0].length < 100) {
+    title = contentParts[0];
+    mainContent = contentParts.slice(1).join("\n\n");
+  }
+  return {
+    id: entry.journal_id,
+    title: title,
+    content: mainContent,
+    date: new Date(entry.created_at),
+    mood: "neutral", // Default, karena tidak ada di data journal backend
+    tags: [], // Default, karena tidak ada di data journal backend
+    isFavorite: false, // Default, karena tidak ada di data journal backend
+    user_id: entry.user_id,
+    created_at: entry.created_at,
+    updated_at: entry.updated_at,
+    version: entry.version,
+  };
+};
+
 export default function SmartJournalPage() {
   const router = useRouter();
   const [activeTab, setActiveTab] = useState("all");
@@ -53,6 +77,8 @@ export default function SmartJournalPage() {
   const [isLoading, setIsLoading] = useState(false);
   const [pageError, setPageError] = useState(null);
   const [pageSuccess, setPageSuccess] = useState(null);
+  // Versi server saat simpan ditolak karena entry diubah di tempat lain
+  const [conflict, setConflict] = useState(null);
 
   const messagesEndRef = useRef(null); // Tidak terpakai di kode ini, bisa dihapus jika tidak ada scroll logic lain
 
@@ -95,27 +121,7 @@ export default function SmartJournalPage() {
       }
 
       const data = await response.json();
-      const fetchedEntries = (data.entries || []).map((entry) => {
-        const contentParts = entry.content.split("\n\n");
-        let title = `Entry ${entry.journal_id}`;
-        let mainContent = entry.content;
-        if (contentParts.length > 1 && contentParts[0].length < 100) {
-          title = contentParts[0];
-          mainContent = contentParts.slice(1).join("\n\n");
-        }
-        return {
-          id: entry.journal_id,
-          title: title,
-          content: mainContent,
-          date: new Date(entry.created_at),
-          mood: "neutral", // Default, karena tidak ada di data journal backend
-          tags: [], // Default, karena tidak ada di data journal backend
-          isFavorite: false, // Default, karena tidak ada di data journal backend
-          user_id: entry.user_id,
-          created_at: entry.created_at,
-          updated_at: entry.updated_at,
-        };
-      });
+      const fetchedEntries = (data.entries || []).map(toJournalEntry);
       setJournalEntries(
         fetchedEntries.sort((a, b) => new Date(b.date) - new Date(a.date))
       );
@@ -180,6 +186,7 @@ export default function SmartJournalPage() {
 
   const handleCreateNewEntry = () => {
     setSelectedEntry(null);
+    setConflict(null);
     setIsEditing(true);
     setNewEntry({ title: "", content: "", mood: "neutral", tags: [] });
     setPageError(null);
@@ -188,6 +195,7 @@ export default function SmartJournalPage() {
 
   const handleEditEntry = (entry) => {
     setSelectedEntry(entry);
+    setConflict(null);
     setIsEditing(true);
     setNewEntry({
       title: entry.title,
@@ -225,12 +233,19 @@ export default function SmartJournalPage() {
       : `${API_BASE_URL}/journal`;
 
     try {
+      const headers = {
+        Authorization: `Bearer ${token}`,
+        "Content-Type": "application/json",
+      };
+      if (selectedEntry) {
+        headers["If-Match"] = selectedEntry.version
+          ? `"${selectedEntry.version}"`
+          : "*";
+      }
+
       const response = await fetch(endpoint, {
         method: method,
-        headers: {
-          Authorization: `Bearer ${token}`,
-          "Content-Type": "application/json",
-        },
+        headers: headers,
         body: JSON.stringify(apiPayload),
       });
 
@@ -241,6 +256,24 @@ export default function SmartJournalPage() {
       }
 
       const responseData = await response.json();
+      if (response.status === 412) {
+        // Entry diubah di tab/perangkat lain. Simpan versi server (dengan version/ETag
+        // terbarunya) dan biarkan teks user di editor supaya bisa digabung lalu disimpan lagi.
+        if (responseData.conflict?.current) {
+          const current = toJournalEntry(responseData.conflict.current);
+          setSelectedEntry({
+            ...current,
+            mood: selectedEntry.mood,
+            tags: selectedEntry.tags,
+            isFavorite: selectedEntry.isFavorite,
+          });
+          setConflict(current);
+        }
+        await fetchJournalEntries();
+        throw new Error(
+          "This entry was changed somewhere else. Compare your text with the latest version below, merge what you need and save again."
+        );
+      }
       if (!response.ok) {
         throw new Error(
           responseData.message ||
@@ -252,6 +285,7 @@ export default function SmartJournalPage() {
         `Journal entry ${selectedEntry ? "updated" : "created"} successfully!`
       );
       setIsEditing(false);
+      setConflict(null);
       await fetchJournalEntries(); // Re-fetch untuk data terbaru
 
       // Update selectedEntry dengan data dari backend jika berhasil
@@ -496,6 +530,7 @@ export default function SmartJournalPage() {
                       onClick={() => {
                         setSelectedEntry(entry);
                         setIsEditing(false);
+                        setConflict(null);
                         setPageError(null);
                         setPageSuccess(null);
                       }}
@@ -631,6 +666,7 @@ export default function SmartJournalPage() {
                           variant="outline"
                           onClick={() => {
                             setIsEditing(false);
+                            setConflict(null);
                             if (!selectedEntry && journalEntries.length > 0) {
                               // setSelectedEntry(journalEntries[0]); // Optionally select first entry or clear
                             }
@@ -653,6 +689,65 @@ export default function SmartJournalPage() {
                       </div>
                     </div>
 
+                    {conflict && (
+                      <div className="mb-6 rounded-lg border border-amber-300 bg-amber-50 p-4">
+                        <p className="text-sm font-medium text-amber-800 mb-3">
+                          Latest saved version (updated{" "}
+                          {format(new Date(conflict.updated_at), "PPp")}). Your
+                          unsaved text is still in the editor below.
+                        </p>
+                        <div className="grid gap-4 md:grid-cols-2">
+                          <div>
+                            <p className="text-xs font-semibold uppercase text-gray-500 mb-1">
+                              Saved version
+                            </p>
+                            <div className="rounded border bg-white p-3 text-sm text-gray-700 whitespace-pre-wrap max-h-64 overflow-y-auto">
+                              <p className="font-semibold mb-2">
+                                {conflict.title}
+                              </p>
+                              {conflict.content}
+                            </div>
+                          </div>
+                          <div>
+                            <p className="text-xs font-semibold uppercase text-gray-500 mb-1">
+                              Your version
+                            </p>
+                            <div className="rounded border bg-white p-3 text-sm text-gray-700 whitespace-pre-wrap max-h-64 overflow-y-auto">
+                              <p className="font-semibold mb-2">
+                                {newEntry.title}
+                              </p>
+                              {newEntry.content}
+                            </div>
+                          </div>
+                        </div>
+                        <div className="flex gap-2 mt-3">
+                          <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => {
+                              setNewEntry({
+                                ...newEntry,
+                                title: conflict.title,
+                                content: conflict.content,
+                              });
+                              setConflict(null);
+                            }}
+                            disabled={isLoading}
+                          >
+                            Use saved version
+                          </Button>
+                          <Button
+                            variant="outline"
+                            size="sm"
+                            onClick={() => setConflict(null)}
+                            disabled={isLoading}
+                          >
+                            Keep my version
+                          </Button>
+                        </div>
+                      </div>
+                    )}
+
                     <div className="space-y-4">
                       <div>
                         <label
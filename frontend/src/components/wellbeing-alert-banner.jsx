@@ -0,0 +1,161 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import Link from "next/link";
+import { HeartHandshake, Phone } from "lucide-react";
+import { Alert, AlertDescription, AlertTitle } from "@/components/ui/alert";
+import { Button } from "@/components/ui/button";
+
+const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL;
+
+// Menampilkan wellbeing alert terbaru yang masih aktif. User sendiri yang memutuskan
+// apakah alert dibagikan ke trusted contact, backend tidak mengirim apa pun.
+export const WellbeingAlertBanner = () => {
+  const [alert, setAlert] = useState(null);
+  const [contacts, setContacts] = useState(null);
+  const [isSharing, setIsSharing] = useState(false);
+  const [shareResult, setShareResult] = useState(null);
+
+  const authHeaders = () => ({
+    Authorization: `Bearer ${localStorage.getItem("jwt_token")}`,
+    "Content-Type": "application/json",
+  });
+
+  useEffect(() => {
+    if (typeof window === "undefined" || !localStorage.getItem("jwt_token")) {
+      return;
+    }
+
+    fetch(`${API_BASE_URL}/wellbeing/alerts?status=active&limit=1`, {
+      headers: authHeaders(),
+    })
+      .then((response) => (response.ok ? response.json() : null))
+      .then((data) => setAlert(data?.alerts?.[0] || null))
+      .catch(() => setAlert(null));
+  }, []);
+
+  if (!alert) return null;
+
+  const updateStatus = async (status) => {
+    await fetch(`${API_BASE_URL}/wellbeing/alerts/${alert.alert_id}`, {
+      method: "PATCH",
+      headers: authHeaders(),
+      body: JSON.stringify({ status }),
+    });
+    setAlert(null);
+  };
+
+  const openShare = async () => {
+    setIsSharing(true);
+    const response = await fetch(`${API_BASE_URL}/wellbeing/contacts`, {
+      headers: authHeaders(),
+    });
+    const data = response.ok ? await response.json() : null;
+    setContacts(data?.contacts || []);
+  };
+
+  const shareWith = async (contactId) => {
+    const response = await fetch(
+      `${API_BASE_URL}/wellbeing/alerts/${alert.alert_id}/share`,
+      {
+        method: "POST",
+        headers: authHeaders(),
+        body: JSON.stringify({ contact_id: contactId }),
+      }
+    );
+    if (!response.ok) return;
+
+    const share = await response.json();
+    setShareResult(share);
+    const link = share.whatsapp_url || share.email_url;
+    if (link) window.open(link, "_blank");
+  };
+
+  return (
+    <Alert className="mb-8 border-orange-200 bg-orange-50">
+      <HeartHandshake className="h-4 w-4" />
+      <AlertTitle>We noticed you might be going through a hard time</AlertTitle>
+      <AlertDescription>
+        <p className="mb-3">{alert.message}</p>
+
+        <div className="mb-3 space-y-1">
+          {alert.hotlines?.map((hotline) => (
+            <p key={hotline.number} className="flex items-center gap-2">
+              <Phone className="h-3 w-3" />
+              <span className="font-medium">{hotline.name}</span>
+              <a href={`tel:${hotline.number}`} className="underline">
+                {hotline.number}
+              </a>
+            </p>
+          ))}
+        </div>
+
+        {alert.resources?.length > 0 && (
+          <div className="mb-3">
+            <p className="font-medium">Resources that might help</p>
+            <ul className="list-disc pl-5">
+              {alert.resources.map((resource) => (
+                <li key={resource.resource_id}>
+                  <Link
+                    href={`/main/article/${resource.resource_id}`}
+                    className="underline"
+                  >
+                    {resource.title}
+                  </Link>
+                </li>
+              ))}
+            </ul>
+          </div>
+        )}
+
+        {isSharing && contacts && (
+          <div className="mb-3">
+            {contacts.length === 0 ? (
+              <p>
+                You haven't added a trusted contact yet. You can add one from
+                your profile.
+              </p>
+            ) : (
+              <div className="flex flex-wrap gap-2">
+                {contacts.map((contact) => (
+                  <Button
+                    key={contact.contact_id}
+                    size="sm"
+                    variant="outline"
+                    onClick={() => shareWith(contact.contact_id)}
+                  >
+                    Share with {contact.name}
+                  </Button>
+                ))}
+              </div>
+            )}
+            {shareResult && (
+              <p className="mt-2 text-sm text-gray-600">
+                Shared with {shareResult.contact.name}. Message: "
+                {shareResult.message}"
+              </p>
+            )}
+          </div>
+        )}
+
+        <div className="flex flex-wrap gap-2">
+          <Button size="sm" onClick={() => updateStatus("acknowledged")}>
+            Thanks, I've seen this
+          </Button>
+          {!isSharing && (
+            <Button size="sm" variant="outline" onClick={openShare}>
+              Share with a trusted contact
+            </Button>
+          )}
+          <Button
+            size="sm"
+            variant="ghost"
+            onClick={() => updateStatus("dismissed")}
+          >
+            Dismiss
+          </Button>
+        </div>
+      </AlertDescription>
+    </Alert>
+  );
+};